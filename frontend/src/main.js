@@ -186,11 +186,17 @@ function renderTracks(tracks) {
     `).join('');
 }
 
+// Album art thumbnails are served by the backend under /artwork/
+function artworkURL(imagePath) {
+    return '/artwork/' + imagePath.split(/[\\/]/).pop();
+}
+
 // Render albums
 function renderAlbums(albums) {
     const container = document.getElementById('albums-list');
     container.innerHTML = albums.map(album => `
         <div class="list-item album-item" data-album-id="${album.id}">
+            ${album.image_path ? `<img class="item-art" src="${artworkURL(album.image_path)}" alt="">` : ''}
             <div class="item-info">
                 <div class="item-title">${album.name}</div>
                 <div class="item-subtitle">${album.artist_name} (${album.tracks_count} tracks)</div>
@@ -1,13 +1,27 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {database} from '../models';
+import {lyrics} from '../models';
+import {app} from '../models';
 import {audio} from '../models';
 import {lrclib} from '../models';
 
 export function AddTrack(arg1:database.PersistentTrack):Promise<void>;
 
+export function AutoSyncLyrics(arg1:number,arg2:boolean):Promise<lyrics.SyncState>;
+
+export function CancelSyncSession(arg1:number):Promise<void>;
+
+export function ClearLoop():Promise<void>;
+
+export function ClearQueue():Promise<void>;
+
 export function DownloadLyrics(arg1:number):Promise<string>;
 
+export function EnqueueTracks(arg1:Array<number>):Promise<void>;
+
+export function ExportLyrics(arg1:number,arg2:string):Promise<string>;
+
 export function FlagLyrics(arg1:number,arg2:string):Promise<void>;
 
 export function GetAlbum(arg1:number):Promise<database.PersistentAlbum>;
@@ -22,10 +36,28 @@ export function GetConfig():Promise<database.PersistentConfig>;
 
 export function GetDirectories():Promise<Array<string>>;
 
+export function GetDirectorySettings():Promise<Array<database.PersistentDirectory>>;
+
 export function GetInit():Promise<boolean>;
 
+export function GetLyricsHealthReport():Promise<app.LyricsHealthReport>;
+
+export function GetLyricsHistory(arg1:number):Promise<Array<database.PersistentLyricsHistory>>;
+
+export function GetLyricsSource(arg1:number):Promise<database.PersistentLyricsSource>;
+
+export function GetParsedLyrics(arg1:number):Promise<lyrics.Lyrics>;
+
 export function GetPlayerState():Promise<audio.PlayerState>;
 
+export function GetQueue():Promise<audio.QueueState>;
+
+export function GetScanReport(arg1:number):Promise<database.PersistentScanReport>;
+
+export function GetScanReports():Promise<Array<database.PersistentScanReport>>;
+
+export function GetSyncSession(arg1:number):Promise<lyrics.SyncState>;
+
 export function GetTrack(arg1:number):Promise<database.PersistentTrack>;
 
 export function GetTracks():Promise<Array<database.PersistentTrack>>;
@@ -34,24 +66,76 @@ export function GetTracksByAlbum(arg1:number):Promise<Array<database.PersistentT
 
 export function GetTracksByArtist(arg1:number):Promise<Array<database.PersistentTrack>>;
 
+export function GetWaveformPeaks(arg1:number,arg2:number):Promise<audio.Peaks>;
+
+export function ImportLyrics(arg1:number,arg2:string):Promise<void>;
+
 export function InitializeLibrary():Promise<void>;
 
+export function InsertSyncBlank(arg1:number):Promise<lyrics.SyncState>;
+
+export function NextTrack():Promise<void>;
+
+export function NudgeSyncLine(arg1:number,arg2:number,arg3:number):Promise<lyrics.SyncState>;
+
 export function PauseTrack():Promise<void>;
 
+export function PlayAlbum(arg1:number):Promise<void>;
+
+export function PlayArtist(arg1:number):Promise<void>;
+
+export function PlayQueueIndex(arg1:number):Promise<void>;
+
 export function PlayTrack(arg1:number):Promise<void>;
 
+export function PreviewSyncLyrics(arg1:number):Promise<string>;
+
+export function PreviousTrack():Promise<void>;
+
 export function PublishLyrics(arg1:string,arg2:string,arg3:string,arg4:number,arg5:any,arg6:any,arg7:boolean):Promise<lrclib.PublishResponse>;
 
+export function RedoSync(arg1:number):Promise<lyrics.SyncState>;
+
+export function RefreshLyrics(arg1:number):Promise<app.RefreshResult>;
+
+export function RestoreLyricsVersion(arg1:number):Promise<void>;
+
 export function ResumeTrack():Promise<void>;
 
+export function SaveLyrics(arg1:number,arg2:string,arg3:string):Promise<void>;
+
+export function SaveSyncSession(arg1:number):Promise<void>;
+
 export function SearchLyrics(arg1:string,arg2:string,arg3:string,arg4:string):Promise<lrclib.SearchResponse>;
 
 export function SeekTrack(arg1:number):Promise<void>;
 
+export function SelectSyncLine(arg1:number,arg2:number):Promise<lyrics.SyncState>;
+
 export function SetDirectories(arg1:Array<string>):Promise<void>;
 
+export function SetDirectoryPatterns(arg1:string,arg2:Array<string>,arg3:Array<string>):Promise<void>;
+
+export function SetLoop(arg1:number,arg2:number):Promise<void>;
+
+export function SetPlaybackRate(arg1:number):Promise<void>;
+
+export function SetRepeatMode(arg1:string):Promise<void>;
+
+export function SetShuffle(arg1:boolean):Promise<void>;
+
 export function SetVolume(arg1:number):Promise<void>;
 
+export function ShiftAlbumLyrics(arg1:number,arg2:number):Promise<number>;
+
+export function ShiftLyrics(arg1:number,arg2:number):Promise<void>;
+
+export function StampSyncLine(arg1:number):Promise<lyrics.SyncState>;
+
+export function StartSyncSession(arg1:number):Promise<lyrics.SyncState>;
+
 export function StopTrack():Promise<void>;
 
+export function UndoSync(arg1:number):Promise<lyrics.SyncState>;
+
 export function UpdateConfig(arg1:database.PersistentConfig):Promise<void>;
@@ -6,10 +6,34 @@ export function AddTrack(arg1) {
   return window['go']['app']['App']['AddTrack'](arg1);
 }
 
+export function AutoSyncLyrics(arg1, arg2) {
+  return window['go']['app']['App']['AutoSyncLyrics'](arg1, arg2);
+}
+
+export function CancelSyncSession(arg1) {
+  return window['go']['app']['App']['CancelSyncSession'](arg1);
+}
+
+export function ClearLoop() {
+  return window['go']['app']['App']['ClearLoop']();
+}
+
+export function ClearQueue() {
+  return window['go']['app']['App']['ClearQueue']();
+}
+
 export function DownloadLyrics(arg1) {
   return window['go']['app']['App']['DownloadLyrics'](arg1);
 }
 
+export function EnqueueTracks(arg1) {
+  return window['go']['app']['App']['EnqueueTracks'](arg1);
+}
+
+export function ExportLyrics(arg1, arg2) {
+  return window['go']['app']['App']['ExportLyrics'](arg1, arg2);
+}
+
 export function FlagLyrics(arg1, arg2) {
   return window['go']['app']['App']['FlagLyrics'](arg1, arg2);
 }
@@ -38,14 +62,50 @@ export function GetDirectories() {
   return window['go']['app']['App']['GetDirectories']();
 }
 
+export function GetDirectorySettings() {
+  return window['go']['app']['App']['GetDirectorySettings']();
+}
+
 export function GetInit() {
   return window['go']['app']['App']['GetInit']();
 }
 
+export function GetLyricsHealthReport() {
+  return window['go']['app']['App']['GetLyricsHealthReport']();
+}
+
+export function GetLyricsHistory(arg1) {
+  return window['go']['app']['App']['GetLyricsHistory'](arg1);
+}
+
+export function GetLyricsSource(arg1) {
+  return window['go']['app']['App']['GetLyricsSource'](arg1);
+}
+
+export function GetParsedLyrics(arg1) {
+  return window['go']['app']['App']['GetParsedLyrics'](arg1);
+}
+
 export function GetPlayerState() {
   return window['go']['app']['App']['GetPlayerState']();
 }
 
+export function GetQueue() {
+  return window['go']['app']['App']['GetQueue']();
+}
+
+export function GetScanReport(arg1) {
+  return window['go']['app']['App']['GetScanReport'](arg1);
+}
+
+export function GetScanReports() {
+  return window['go']['app']['App']['GetScanReports']();
+}
+
+export function GetSyncSession(arg1) {
+  return window['go']['app']['App']['GetSyncSession'](arg1);
+}
+
 export function GetTrack(arg1) {
   return window['go']['app']['App']['GetTrack'](arg1);
 }
@@ -62,26 +122,86 @@ export function GetTracksByArtist(arg1) {
   return window['go']['app']['App']['GetTracksByArtist'](arg1);
 }
 
+export function GetWaveformPeaks(arg1, arg2) {
+  return window['go']['app']['App']['GetWaveformPeaks'](arg1, arg2);
+}
+
+export function ImportLyrics(arg1, arg2) {
+  return window['go']['app']['App']['ImportLyrics'](arg1, arg2);
+}
+
 export function InitializeLibrary() {
   return window['go']['app']['App']['InitializeLibrary']();
 }
 
+export function InsertSyncBlank(arg1) {
+  return window['go']['app']['App']['InsertSyncBlank'](arg1);
+}
+
+export function NextTrack() {
+  return window['go']['app']['App']['NextTrack']();
+}
+
+export function NudgeSyncLine(arg1, arg2, arg3) {
+  return window['go']['app']['App']['NudgeSyncLine'](arg1, arg2, arg3);
+}
+
 export function PauseTrack() {
   return window['go']['app']['App']['PauseTrack']();
 }
 
+export function PlayAlbum(arg1) {
+  return window['go']['app']['App']['PlayAlbum'](arg1);
+}
+
+export function PlayArtist(arg1) {
+  return window['go']['app']['App']['PlayArtist'](arg1);
+}
+
+export function PlayQueueIndex(arg1) {
+  return window['go']['app']['App']['PlayQueueIndex'](arg1);
+}
+
 export function PlayTrack(arg1) {
   return window['go']['app']['App']['PlayTrack'](arg1);
 }
 
+export function PreviewSyncLyrics(arg1) {
+  return window['go']['app']['App']['PreviewSyncLyrics'](arg1);
+}
+
+export function PreviousTrack() {
+  return window['go']['app']['App']['PreviousTrack']();
+}
+
 export function PublishLyrics(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
   return window['go']['app']['App']['PublishLyrics'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
+export function RedoSync(arg1) {
+  return window['go']['app']['App']['RedoSync'](arg1);
+}
+
+export function RefreshLyrics(arg1) {
+  return window['go']['app']['App']['RefreshLyrics'](arg1);
+}
+
+export function RestoreLyricsVersion(arg1) {
+  return window['go']['app']['App']['RestoreLyricsVersion'](arg1);
+}
+
 export function ResumeTrack() {
   return window['go']['app']['App']['ResumeTrack']();
 }
 
+export function SaveLyrics(arg1, arg2, arg3) {
+  return window['go']['app']['App']['SaveLyrics'](arg1, arg2, arg3);
+}
+
+export function SaveSyncSession(arg1) {
+  return window['go']['app']['App']['SaveSyncSession'](arg1);
+}
+
 export function SearchLyrics(arg1, arg2, arg3, arg4) {
   return window['go']['app']['App']['SearchLyrics'](arg1, arg2, arg3, arg4);
 }
@@ -90,18 +210,62 @@ export function SeekTrack(arg1) {
   return window['go']['app']['App']['SeekTrack'](arg1);
 }
 
+export function SelectSyncLine(arg1, arg2) {
+  return window['go']['app']['App']['SelectSyncLine'](arg1, arg2);
+}
+
 export function SetDirectories(arg1) {
   return window['go']['app']['App']['SetDirectories'](arg1);
 }
 
+export function SetDirectoryPatterns(arg1, arg2, arg3) {
+  return window['go']['app']['App']['SetDirectoryPatterns'](arg1, arg2, arg3);
+}
+
+export function SetLoop(arg1, arg2) {
+  return window['go']['app']['App']['SetLoop'](arg1, arg2);
+}
+
+export function SetPlaybackRate(arg1) {
+  return window['go']['app']['App']['SetPlaybackRate'](arg1);
+}
+
+export function SetRepeatMode(arg1) {
+  return window['go']['app']['App']['SetRepeatMode'](arg1);
+}
+
+export function SetShuffle(arg1) {
+  return window['go']['app']['App']['SetShuffle'](arg1);
+}
+
 export function SetVolume(arg1) {
   return window['go']['app']['App']['SetVolume'](arg1);
 }
 
+export function ShiftAlbumLyrics(arg1, arg2) {
+  return window['go']['app']['App']['ShiftAlbumLyrics'](arg1, arg2);
+}
+
+export function ShiftLyrics(arg1, arg2) {
+  return window['go']['app']['App']['ShiftLyrics'](arg1, arg2);
+}
+
+export function StampSyncLine(arg1) {
+  return window['go']['app']['App']['StampSyncLine'](arg1);
+}
+
+export function StartSyncSession(arg1) {
+  return window['go']['app']['App']['StartSyncSession'](arg1);
+}
+
 export function StopTrack() {
   return window['go']['app']['App']['StopTrack']();
 }
 
+export function UndoSync(arg1) {
+  return window['go']['app']['App']['UndoSync'](arg1);
+}
+
 export function UpdateConfig(arg1) {
   return window['go']['app']['App']['UpdateConfig'](arg1);
 }
@@ -1,11 +1,137 @@
+export namespace app {
+	
+	export class TrackLyricsHealth {
+	    track_id: number;
+	    title: string;
+	    artist_name: string;
+	    album_name: string;
+	    score: number;
+	    issues: lyrics.Issue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TrackLyricsHealth(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.track_id = source["track_id"];
+	        this.title = source["title"];
+	        this.artist_name = source["artist_name"];
+	        this.album_name = source["album_name"];
+	        this.score = source["score"];
+	        this.issues = this.convertValues(source["issues"], lyrics.Issue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LyricsHealthReport {
+	    tracks_checked: number;
+	    tracks_with_issues: number;
+	    average_score: number;
+	    tracks: TrackLyricsHealth[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LyricsHealthReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tracks_checked = source["tracks_checked"];
+	        this.tracks_with_issues = source["tracks_with_issues"];
+	        this.average_score = source["average_score"];
+	        this.tracks = this.convertValues(source["tracks"], TrackLyricsHealth);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RefreshResult {
+	    checked: number;
+	    updated: number;
+	    unchanged: number;
+	    not_found: number;
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RefreshResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.checked = source["checked"];
+	        this.updated = source["updated"];
+	        this.unchanged = source["unchanged"];
+	        this.not_found = source["not_found"];
+	        this.failed = source["failed"];
+	    }
+	}
+
+}
+
 export namespace audio {
 	
+	export class Peaks {
+	    resolution: number;
+	    duration: number;
+	    min: number[];
+	    max: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Peaks(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.resolution = source["resolution"];
+	        this.duration = source["duration"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	    }
+	}
 	export class PlayerState {
 	    status: number;
 	    progress: number;
 	    duration: number;
 	    volume: number;
 	    track?: database.PersistentTrack;
+	    rate: number;
+	    loop_start?: number;
+	    loop_end?: number;
+	    line_index: number;
+	    line_text: string;
+	    word_index: number;
+	    next_line_time?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new PlayerState(source);
@@ -18,6 +144,49 @@ export namespace audio {
 	        this.duration = source["duration"];
 	        this.volume = source["volume"];
 	        this.track = this.convertValues(source["track"], database.PersistentTrack);
+	        this.rate = source["rate"];
+	        this.loop_start = source["loop_start"];
+	        this.loop_end = source["loop_end"];
+	        this.line_index = source["line_index"];
+	        this.line_text = source["line_text"];
+	        this.word_index = source["word_index"];
+	        this.next_line_time = source["next_line_time"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueState {
+	    tracks: database.PersistentTrack[];
+	    current_index: number;
+	    repeat: string;
+	    shuffle: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tracks = this.convertValues(source["tracks"], database.PersistentTrack);
+	        this.current_index = source["current_index"];
+	        this.repeat = source["repeat"];
+	        this.shuffle = source["shuffle"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -143,6 +312,11 @@ export namespace database {
 	    try_embed_lyrics: boolean;
 	    theme_mode: string;
 	    lrclib_instance: string;
+	    skip_hidden_files: boolean;
+	    skip_small_files: boolean;
+	    follow_symlinks: boolean;
+	    cue_lyrics_layout: string;
+	    artist_separators: string[];
 	    // Go type: time
 	    created_at: any;
 	    // Go type: time
@@ -161,6 +335,147 @@ export namespace database {
 	        this.try_embed_lyrics = source["try_embed_lyrics"];
 	        this.theme_mode = source["theme_mode"];
 	        this.lrclib_instance = source["lrclib_instance"];
+	        this.skip_hidden_files = source["skip_hidden_files"];
+	        this.skip_small_files = source["skip_small_files"];
+	        this.follow_symlinks = source["follow_symlinks"];
+	        this.cue_lyrics_layout = source["cue_lyrics_layout"];
+	        this.artist_separators = source["artist_separators"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PersistentDirectory {
+	    id: number;
+	    path: string;
+	    include: string[];
+	    exclude: string[];
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PersistentDirectory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.path = source["path"];
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PersistentLyricsHistory {
+	    id: number;
+	    track_id: number;
+	    lrc_lyrics?: string;
+	    txt_lyrics?: string;
+	    instrumental: boolean;
+	    source: string;
+	    // Go type: time
+	    created_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PersistentLyricsHistory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.track_id = source["track_id"];
+	        this.lrc_lyrics = source["lrc_lyrics"];
+	        this.txt_lyrics = source["txt_lyrics"];
+	        this.instrumental = source["instrumental"];
+	        this.source = source["source"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PersistentLyricsSource {
+	    track_id: number;
+	    lrclib_id: number;
+	    name?: string;
+	    lang?: string;
+	    isrc?: string;
+	    spotify_id?: string;
+	    release_date?: string;
+	    // Go type: time
+	    downloaded_at: any;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PersistentLyricsSource(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.track_id = source["track_id"];
+	        this.lrclib_id = source["lrclib_id"];
+	        this.name = source["name"];
+	        this.lang = source["lang"];
+	        this.isrc = source["isrc"];
+	        this.spotify_id = source["spotify_id"];
+	        this.release_date = source["release_date"];
+	        this.downloaded_at = this.convertValues(source["downloaded_at"], null);
 	        this.created_at = this.convertValues(source["created_at"], null);
 	        this.updated_at = this.convertValues(source["updated_at"], null);
 	    }
@@ -183,6 +498,71 @@ export namespace database {
 		    return a;
 		}
 	}
+	export class PersistentScanReportEntry {
+	    path: string;
+	    kind: string;
+	    reason: string;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PersistentScanReportEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.kind = source["kind"];
+	        this.reason = source["reason"];
+	        this.message = source["message"];
+	    }
+	}
+	export class PersistentScanReport {
+	    id: number;
+	    // Go type: time
+	    started_at: any;
+	    // Go type: time
+	    finished_at: any;
+	    tracks: number;
+	    error?: string;
+	    skipped_count: number;
+	    error_count: number;
+	    entries: PersistentScanReportEntry[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PersistentScanReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.finished_at = this.convertValues(source["finished_at"], null);
+	        this.tracks = source["tracks"];
+	        this.error = source["error"];
+	        this.skipped_count = source["skipped_count"];
+	        this.error_count = source["error_count"];
+	        this.entries = this.convertValues(source["entries"], PersistentScanReportEntry);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
 	export class PersistentTrack {
 	    id: number;
 	    file_path: string;
@@ -200,6 +580,9 @@ export namespace database {
 	    duration: number;
 	    instrumental: boolean;
 	    title_lower?: string;
+	    cue_track: number;
+	    start_offset?: number;
+	    end_offset?: number;
 	    // Go type: time
 	    created_at: any;
 	    // Go type: time
@@ -227,6 +610,9 @@ export namespace database {
 	        this.duration = source["duration"];
 	        this.instrumental = source["instrumental"];
 	        this.title_lower = source["title_lower"];
+	        this.cue_track = source["cue_track"];
+	        this.start_offset = source["start_offset"];
+	        this.end_offset = source["end_offset"];
 	        this.created_at = this.convertValues(source["created_at"], null);
 	        this.updated_at = this.convertValues(source["updated_at"], null);
 	    }
@@ -327,3 +713,158 @@ export namespace lrclib {
 
 }
 
+export namespace lyrics {
+	
+	export class Issue {
+	    line: number;
+	    code: string;
+	    severity: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Issue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.code = source["code"];
+	        this.severity = source["severity"];
+	        this.message = source["message"];
+	    }
+	}
+	export class Word {
+	    time: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Word(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.text = source["text"];
+	    }
+	}
+	export class Line {
+	    time: number;
+	    text: string;
+	    words?: Word[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Line(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.text = source["text"];
+	        this.words = this.convertValues(source["words"], Word);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Lyrics {
+	    tags: Record<string, string>;
+	    lines: Line[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Lyrics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tags = source["tags"];
+	        this.lines = this.convertValues(source["lines"], Line);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SyncLine {
+	    time?: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.text = source["text"];
+	    }
+	}
+	export class SyncState {
+	    lines: SyncLine[];
+	    current: number;
+	    can_undo: boolean;
+	    can_redo: boolean;
+	    auto: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lines = this.convertValues(source["lines"], SyncLine);
+	        this.current = source["current"];
+	        this.can_undo = source["can_undo"];
+	        this.can_redo = source["can_redo"];
+	        this.auto = source["auto"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
@@ -5,12 +5,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"sync"
 
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"lrcget-go/internal/artwork"
 	"lrcget-go/internal/audio"
+	"lrcget-go/internal/constants"
 	"lrcget-go/internal/database"
 	"lrcget-go/internal/filesystem"
 	"lrcget-go/internal/lrclib"
+	"lrcget-go/internal/lyrics"
 )
 
 // App represents the main application
@@ -18,13 +23,22 @@ type App struct {
 	ctx     context.Context
 	db      *database.Connection
 	player  *audio.Player
+	queue   *audio.Queue
+	peaks   *audio.PeaksCache
+	artwork *artwork.Cache
 	scanner *filesystem.Scanner
+	watcher *filesystem.Watcher
 	lrclib  *lrclib.Client
+
+	syncMu       sync.Mutex
+	syncSessions map[int64]*lyrics.SyncSession
 }
 
 // NewApp creates a new application instance
 func NewApp() *App {
-	return &App{}
+	return &App{
+		syncSessions: make(map[int64]*lyrics.SyncSession),
+	}
 }
 
 // OnStartup is called when the application starts
@@ -50,8 +64,23 @@ func (a *App) OnStartup(ctx context.Context) {
 	}
 	a.player = player
 
-	// Initialize scanner
+	// Restore the playback queue
+	a.queue = audio.NewQueue()
+	a.restoreQueue()
+
+	// Waveform peaks are cached next to the database
+	a.peaks = audio.NewPeaksCache(filepath.Join(dataDir, constants.DefaultCacheDir, "peaks"))
+
+	// Album art thumbnails are cached next to the peaks
+	a.artwork = artwork.NewCache(filepath.Join(dataDir, constants.DefaultCacheDir, "artwork"))
+
+	// Initialize scanner, and keep the library in sync with the directories
 	a.scanner = filesystem.NewScanner()
+	a.scanner.SetArtworkCache(a.artwork)
+	a.loadScanRules()
+	a.watcher = filesystem.NewWatcher()
+	a.subscribeLibraryChanges()
+	a.watchLibrary()
 
 	// Initialize LRCLIB client
 	config, err := a.db.GetConfig()
@@ -62,8 +91,8 @@ func (a *App) OnStartup(ctx context.Context) {
 	}
 	a.lrclib = lrclib.NewClient(config.LrclibInstance)
 
-	// Start background tasks
-	go a.startAudioStateUpdater()
+	// Push player events to the frontend
+	a.subscribePlayerEvents()
 }
 
 // OnDomReady is called when the DOM is ready
@@ -73,6 +102,12 @@ func (a *App) OnDomReady(ctx context.Context) {
 
 // OnShutdown is called when the application shuts down
 func (a *App) OnShutdown(ctx context.Context) {
+	if a.watcher != nil {
+		a.watcher.Stop()
+	}
+	if a.player != nil {
+		a.player.Close()
+	}
 	if a.db != nil {
 		a.db.Close()
 	}
@@ -87,18 +122,17 @@ func (a *App) getDataDirectory() string {
 	return filepath.Join(homeDir, ".lrcget")
 }
 
-// startAudioStateUpdater starts the audio state updater goroutine
-func (a *App) startAudioStateUpdater() {
-	ticker := time.NewTicker(40 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			a.player.UpdateState()
-			// In a real implementation, you would emit the state to the frontend
-		case <-a.ctx.Done():
-			return
-		}
-	}
+// subscribePlayerEvents forwards player events to the frontend and advances
+// the queue when a track finishes
+func (a *App) subscribePlayerEvents() {
+	a.player.OnStateChange(func(state audio.PlayerState) {
+		runtime.EventsEmit(a.ctx, constants.PlayerStateEvent, state)
+	})
+	a.player.OnPositionChange(func(position float64) {
+		runtime.EventsEmit(a.ctx, constants.PlayerPositionEvent, position)
+	})
+	a.player.OnVolumeChange(func(volume float64) {
+		runtime.EventsEmit(a.ctx, constants.PlayerVolumeEvent, volume)
+	})
+	a.player.OnTrackFinished(a.advanceQueue)
 }
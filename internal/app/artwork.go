@@ -0,0 +1,35 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"lrcget-go/internal/artwork"
+)
+
+// NewArtworkHandler returns the asset handler serving the album art
+// thumbnails of an application at artwork.URLPrefix. It is a function rather
+// than a method so that it is not bound to the frontend.
+func NewArtworkHandler(a *App) http.Handler {
+	return artwork.NewHandler(func() *artwork.Cache {
+		return a.artwork
+	})
+}
+
+// pruneArtwork removes the thumbnails no longer used by any track or album,
+// such as the art of albums whose art changed since the previous scan
+func (a *App) pruneArtwork() {
+	paths, err := a.db.GetImagePaths()
+	if err != nil {
+		fmt.Printf("Failed to get image paths: %v\n", err)
+		return
+	}
+
+	keep := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		keep[path] = true
+	}
+	if _, err := a.artwork.Prune(keep); err != nil {
+		fmt.Printf("Failed to prune artwork: %v\n", err)
+	}
+}
@@ -2,11 +2,18 @@ package app
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"lrcget-go/internal/audio"
+	"lrcget-go/internal/constants"
 	"lrcget-go/internal/database"
+	"lrcget-go/internal/filesystem"
 	"lrcget-go/internal/lrclib"
 	"lrcget-go/internal/utils"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // Directory management
@@ -21,8 +28,41 @@ func (a *App) SetDirectories(directories []string) error {
 			return utils.HandleErrorWithMessage("SetDirectories", err, "Invalid directory path provided")
 		}
 	}
+
+	directories, err := filesystem.NormalizeDirectories(directories)
+	if err != nil {
+		return utils.HandleErrorWithMessage("SetDirectories", err, "Library directories cannot be inside one another")
+	}
+	
+	if err := a.db.SetDirectories(directories); err != nil {
+		return err
+	}
 	
-	return a.db.SetDirectories(directories)
+	a.loadScanRules()
+	a.watchLibrary()
+	return nil
+}
+
+func (a *App) GetDirectorySettings() ([]database.PersistentDirectory, error) {
+	return a.db.GetDirectorySettings()
+}
+
+// SetDirectoryPatterns sets the glob patterns of the files scanned in a
+// library directory. They apply to the next scan and to watched changes.
+func (a *App) SetDirectoryPatterns(directory string, include, exclude []string) error {
+	if err := filesystem.ValidatePatterns(include); err != nil {
+		return err
+	}
+	if err := filesystem.ValidatePatterns(exclude); err != nil {
+		return err
+	}
+
+	if err := a.db.SetDirectoryPatterns(directory, include, exclude); err != nil {
+		return err
+	}
+
+	a.loadScanRules()
+	return nil
 }
 
 // Library management
@@ -30,6 +70,51 @@ func (a *App) GetInit() (bool, error) {
 	return a.db.GetInit()
 }
 
+// GetScanReports returns the reports of the last library scans, newest first
+func (a *App) GetScanReports() ([]database.PersistentScanReport, error) {
+	return a.db.GetScanReports()
+}
+
+// GetScanReport returns a library scan report with the paths it skipped or
+// failed to read
+func (a *App) GetScanReport(reportID int64) (*database.PersistentScanReport, error) {
+	return a.db.GetScanReport(reportID)
+}
+
+// saveScanReport records the report of a library scan, and the error that
+// stopped it if any
+func (a *App) saveScanReport(report *filesystem.ScanReport, scanErr error) {
+	saved := &database.PersistentScanReport{
+		StartedAt:  report.StartedAt,
+		FinishedAt: report.FinishedAt,
+		Tracks:     report.Tracks,
+	}
+	if scanErr != nil {
+		message := scanErr.Error()
+		saved.Error = &message
+	}
+	for _, skip := range report.Skipped {
+		saved.Entries = append(saved.Entries, database.PersistentScanReportEntry{
+			Path:   skip.Path,
+			Kind:   database.ScanEntrySkipped,
+			Reason: skip.Reason,
+		})
+	}
+	for _, failure := range report.Errors {
+		message := failure.Message
+		saved.Entries = append(saved.Entries, database.PersistentScanReportEntry{
+			Path:    failure.Path,
+			Kind:    database.ScanEntryError,
+			Reason:  failure.Kind,
+			Message: &message,
+		})
+	}
+
+	if err := a.db.SaveScanReport(saved); err != nil {
+		fmt.Printf("Failed to save scan report: %v\n", err)
+	}
+}
+
 func (a *App) InitializeLibrary() error {
 	go func() {
 		// Get directories to scan
@@ -39,19 +124,24 @@ func (a *App) InitializeLibrary() error {
 			return
 		}
 		
-		// Scan directories for tracks
-		tracks, err := a.scanner.ScanDirectories(directories)
+		// Scan directories for tracks, adding them to the database in batches
+		// and sending each batch to the frontend as it is saved
+		report, err := a.scanner.Scan(a.ctx, directories, constants.DefaultMaxWorkers, func(tracks []database.PersistentTrack) error {
+			if err := a.db.BulkUpsertTracks(tracks); err != nil {
+				return err
+			}
+			runtime.EventsEmit(a.ctx, constants.LibraryScanEvent, tracks)
+			return nil
+		})
+		a.saveScanReport(report, err)
 		if err != nil {
 			fmt.Printf("Failed to scan directories: %v\n", err)
 			return
 		}
-		
-		// Add tracks to database
-		for _, track := range tracks {
-			err := a.db.AddTrack(&track)
-			if err != nil {
-				fmt.Printf("Failed to add track %s: %v\n", track.FilePath, err)
-			}
+
+		a.pruneArtwork()
+		if err := a.db.PurgeDetachedTracks(time.Now().Add(-constants.DetachedTrackRetention)); err != nil {
+			fmt.Printf("Failed to purge detached tracks: %v\n", err)
 		}
 		
 		// Mark library as initialized
@@ -59,6 +149,8 @@ func (a *App) InitializeLibrary() error {
 		if err != nil {
 			fmt.Printf("Failed to set init status: %v\n", err)
 		}
+		
+		a.watchLibrary()
 	}()
 	
 	return nil
@@ -118,32 +210,40 @@ func (a *App) DownloadLyrics(trackID int64) (string, error) {
 	// Update LRCLIB client with current instance
 	a.lrclib.SetBaseURL(config.LrclibInstance)
 	
-	// Get lyrics from LRCLIB
-	response, err := a.lrclib.GetLyrics(a.ctx, track.Title, track.AlbumName, track.ArtistName, track.Duration)
+	response, err := a.lookupLyrics(track)
 	if err != nil {
-		return "", fmt.Errorf("failed to get lyrics: %w", err)
+		return "", err
 	}
 	
 	switch resp := response.(type) {
 	case lrclib.SyncedLyrics:
-		err = a.db.UpdateTrackSyncedLyrics(trackID, resp.Synced, resp.Plain)
+		err = a.saveTrackLyrics(track, &resp.Synced, &resp.Plain, false, database.HistorySourceDownload)
 		if err != nil {
 			return "", fmt.Errorf("failed to update synced lyrics: %w", err)
 		}
+		if err := a.saveLyricsSource(trackID, resp.Metadata); err != nil {
+			return "", err
+		}
 		return "Synced lyrics downloaded", nil
 		
 	case lrclib.UnsyncedLyrics:
-		err = a.db.UpdateTrackPlainLyrics(trackID, resp.Plain)
+		err = a.saveTrackLyrics(track, nil, &resp.Plain, false, database.HistorySourceDownload)
 		if err != nil {
 			return "", fmt.Errorf("failed to update plain lyrics: %w", err)
 		}
+		if err := a.saveLyricsSource(trackID, resp.Metadata); err != nil {
+			return "", err
+		}
 		return "Plain lyrics downloaded", nil
 		
 	case lrclib.Instrumental:
-		err = a.db.UpdateTrackInstrumental(trackID)
+		err = a.saveTrackLyrics(track, nil, nil, true, database.HistorySourceDownload)
 		if err != nil {
 			return "", fmt.Errorf("failed to update instrumental: %w", err)
 		}
+		if err := a.saveLyricsSource(trackID, resp.Metadata); err != nil {
+			return "", err
+		}
 		return "Marked track as instrumental", nil
 		
 	case lrclib.None:
@@ -153,6 +253,58 @@ func (a *App) DownloadLyrics(trackID int64) (string, error) {
 	return "", fmt.Errorf("unknown response type")
 }
 
+// lookupLyrics gets a track's lyrics from the LRCLIB record they were last
+// downloaded from, if it still exists. Otherwise the track is looked up by its
+// signature, preferring better synced lyrics published for the same track.
+func (a *App) lookupLyrics(track *database.PersistentTrack) (lrclib.Response, error) {
+	if source, err := a.db.GetLyricsSource(track.ID); err == nil {
+		response, err := a.lrclib.GetLyricsByID(a.ctx, source.LrclibID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lyrics: %w", err)
+		}
+		if _, ok := response.(lrclib.None); !ok {
+			return response, nil
+		}
+	}
+	
+	// LRCLIB matches the artist tag as written rather than the artists split from it
+	response, err := a.lrclib.GetLyrics(a.ctx, track.Title, track.AlbumName, track.ArtistName, track.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lyrics: %w", err)
+	}
+	
+	return a.chooseLyrics(track, response), nil
+}
+
+// saveLyricsSource records the LRCLIB record that lyrics were downloaded from
+func (a *App) saveLyricsSource(trackID int64, metadata lrclib.Metadata) error {
+	if metadata.ID == 0 {
+		return nil
+	}
+	
+	source := &database.PersistentLyricsSource{
+		TrackID:      trackID,
+		LrclibID:     metadata.ID,
+		Name:         metadata.Name,
+		Lang:         metadata.Lang,
+		Isrc:         metadata.Isrc,
+		SpotifyID:    metadata.SpotifyID,
+		ReleaseDate:  metadata.ReleaseDate,
+		DownloadedAt: time.Now(),
+	}
+	
+	if err := a.db.SetLyricsSource(source); err != nil {
+		return fmt.Errorf("failed to save lyrics source: %w", err)
+	}
+	
+	return nil
+}
+
+// GetLyricsSource returns the LRCLIB record a track's lyrics were downloaded from
+func (a *App) GetLyricsSource(trackID int64) (*database.PersistentLyricsSource, error) {
+	return a.db.GetLyricsSource(trackID)
+}
+
 // Search lyrics
 func (a *App) SearchLyrics(title, artist, album, query string) (*lrclib.SearchResponse, error) {
 	// Validate and sanitize inputs
@@ -277,28 +429,37 @@ func (a *App) PlayTrack(trackID int64) error {
 }
 
 func (a *App) PauseTrack() error {
-	a.player.Pause()
-	return nil
+	return a.player.Pause()
 }
 
 func (a *App) ResumeTrack() error {
-	a.player.Resume()
-	return nil
+	return a.player.Resume()
 }
 
 func (a *App) StopTrack() error {
-	a.player.Stop()
-	return nil
+	return a.player.Stop()
 }
 
 func (a *App) SeekTrack(position float64) error {
-	a.player.Seek(position)
-	return nil
+	return a.player.Seek(position)
 }
 
 func (a *App) SetVolume(volume float64) error {
-	a.player.SetVolume(volume)
-	return nil
+	return a.player.SetVolume(volume)
+}
+
+// SetPlaybackRate sets the playback speed, between 0.5 and 1.5, without changing the pitch
+func (a *App) SetPlaybackRate(rate float64) error {
+	return a.player.SetRate(rate)
+}
+
+// SetLoop repeats the section of the current track between start and end, in seconds
+func (a *App) SetLoop(start, end float64) error {
+	return a.player.SetLoop(start, end)
+}
+
+func (a *App) ClearLoop() error {
+	return a.player.ClearLoop()
 }
 
 func (a *App) GetPlayerState() audio.PlayerState {
@@ -311,5 +472,37 @@ func (a *App) GetConfig() (*database.PersistentConfig, error) {
 }
 
 func (a *App) UpdateConfig(config *database.PersistentConfig) error {
-	return a.db.UpdateConfig(config)
+	if config.CueLyricsLayout == "" {
+		config.CueLyricsLayout = constants.DefaultCueLyricsLayout
+	}
+	if err := filesystem.ValidateCueLyricsLayout(config.CueLyricsLayout); err != nil {
+		return utils.HandleErrorWithMessage("UpdateConfig", err, "Invalid CUE lyrics file layout")
+	}
+	if config.ArtistSeparators == nil {
+		config.ArtistSeparators = database.DefaultArtistSeparators
+	}
+	for _, separator := range config.ArtistSeparators {
+		if strings.TrimSpace(separator) == "" {
+			return utils.HandleErrorWithMessage("UpdateConfig", fmt.Errorf("blank artist separator %q", separator), "Invalid artist separators")
+		}
+	}
+
+	previous, err := a.db.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := a.db.UpdateConfig(config); err != nil {
+		return err
+	}
+
+	// Tracks credit the artists split with the new separators without a rescan
+	if !slices.Equal(previous.ArtistSeparators, config.ArtistSeparators) {
+		if err := a.db.RebuildTrackArtists(); err != nil {
+			return utils.HandleErrorWithMessage("UpdateConfig", err, "Failed to split track artists")
+		}
+	}
+
+	a.loadScanRules()
+	return nil
 }
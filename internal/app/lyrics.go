@@ -0,0 +1,340 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"lrcget-go/internal/audio"
+	"lrcget-go/internal/constants"
+	"lrcget-go/internal/database"
+	"lrcget-go/internal/lrclib"
+	"lrcget-go/internal/lyrics"
+)
+
+// candidateDurationTolerance is how many seconds a search result's duration may
+// differ from the track's duration to be considered the same recording
+const candidateDurationTolerance = 2.0
+
+// candidateSearchScore is the validation score below which the synced lyrics
+// of an exact match are compared with search results for the same track
+const candidateSearchScore = 80
+
+// TrackLyricsHealth represents the validation result of a track's synced lyrics
+type TrackLyricsHealth struct {
+	TrackID    int64          `json:"track_id"`
+	Title      string         `json:"title"`
+	ArtistName string         `json:"artist_name"`
+	AlbumName  string         `json:"album_name"`
+	Score      int            `json:"score"`
+	Issues     []lyrics.Issue `json:"issues"`
+}
+
+// LyricsHealthReport represents the validation results of the whole library
+type LyricsHealthReport struct {
+	TracksChecked    int                 `json:"tracks_checked"`
+	TracksWithIssues int                 `json:"tracks_with_issues"`
+	AverageScore     float64             `json:"average_score"`
+	Tracks           []TrackLyricsHealth `json:"tracks"`
+}
+
+// saveTrackLyrics stores a new version of a track's lyrics in the database and
+// its lyrics history, then rewrites the sidecar files next to the audio file
+func (a *App) saveTrackLyrics(track *database.PersistentTrack, lrcLyrics, txtLyrics *string, instrumental bool, source string) error {
+	err := a.db.SaveTrackLyrics(track.ID, lrcLyrics, txtLyrics, instrumental, source)
+	if err != nil {
+		return fmt.Errorf("failed to save lyrics: %w", err)
+	}
+
+	err = a.scanner.SaveLyrics(track, lrcLyrics, txtLyrics, instrumental)
+	if err != nil {
+		return fmt.Errorf("failed to write lyrics files: %w", err)
+	}
+
+	a.refreshPlayerLyrics(track.ID, lrcLyrics, instrumental)
+	return nil
+}
+
+// refreshPlayerLyrics updates the synced lyrics followed by the player if the
+// track is the one playing
+func (a *App) refreshPlayerLyrics(trackID int64, lrcLyrics *string, instrumental bool) {
+	if current := a.player.GetCurrentTrack(); current != nil && current.ID == trackID {
+		lrc := ""
+		if lrcLyrics != nil && !instrumental {
+			lrc = *lrcLyrics
+		}
+		a.player.SetLyrics(lrc)
+	}
+}
+
+// SaveLyrics saves lyrics edited by the user
+func (a *App) SaveLyrics(trackID int64, plainLyrics, syncedLyrics string) error {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return fmt.Errorf("failed to get track: %w", err)
+	}
+
+	return a.saveTrackLyrics(track, optionalLyrics(syncedLyrics), optionalLyrics(plainLyrics), false, database.HistorySourceEdit)
+}
+
+// GetLyricsHistory returns all saved lyrics versions of a track, newest first
+func (a *App) GetLyricsHistory(trackID int64) ([]database.PersistentLyricsHistory, error) {
+	return a.db.GetLyricsHistory(trackID)
+}
+
+// RestoreLyricsVersion restores a track's lyrics to a saved version. The restore
+// is itself recorded in the history, so it can be undone as well.
+func (a *App) RestoreLyricsVersion(historyID int64) error {
+	entry, err := a.db.GetLyricsHistoryByID(historyID)
+	if err != nil {
+		return fmt.Errorf("failed to get lyrics version: %w", err)
+	}
+
+	track, err := a.db.GetTrackByID(entry.TrackID)
+	if err != nil {
+		return fmt.Errorf("failed to get track: %w", err)
+	}
+
+	return a.saveTrackLyrics(track, entry.LrcLyrics, entry.TxtLyrics, entry.Instrumental, database.HistorySourceEdit)
+}
+
+// GetParsedLyrics returns a track's synced lyrics parsed into lines, including
+// the timing of each word for enhanced LRC lyrics
+func (a *App) GetParsedLyrics(trackID int64) (*lyrics.Lyrics, error) {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track: %w", err)
+	}
+
+	if track.LrcLyrics == nil || !lyrics.IsSynced(*track.LrcLyrics) {
+		return nil, fmt.Errorf("track with ID %d has no synced lyrics", trackID)
+	}
+
+	return lyrics.Parse(*track.LrcLyrics), nil
+}
+
+// ShiftLyrics moves every timestamp of a track's synced lyrics by offsetMs
+// milliseconds. A positive offset makes lyrics appear later.
+func (a *App) ShiftLyrics(trackID int64, offsetMs int) error {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return fmt.Errorf("failed to get track: %w", err)
+	}
+
+	if track.LrcLyrics == nil || !lyrics.IsSynced(*track.LrcLyrics) {
+		return fmt.Errorf("track with ID %d has no synced lyrics", trackID)
+	}
+
+	return a.shiftTrackLyrics(track, offsetMs)
+}
+
+// ShiftAlbumLyrics moves the synced lyrics timestamps of every track of an album
+// by offsetMs milliseconds and returns the number of tracks shifted. Tracks
+// without synced lyrics are skipped.
+func (a *App) ShiftAlbumLyrics(albumID int64, offsetMs int) (int, error) {
+	tracks, err := a.db.GetTracksByAlbumID(albumID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get album tracks: %w", err)
+	}
+
+	shifted := 0
+	for i := range tracks {
+		track := &tracks[i]
+		if track.LrcLyrics == nil || !lyrics.IsSynced(*track.LrcLyrics) {
+			continue
+		}
+
+		if err := a.shiftTrackLyrics(track, offsetMs); err != nil {
+			return shifted, fmt.Errorf("failed to shift lyrics of track %d: %w", track.ID, err)
+		}
+		shifted++
+	}
+
+	return shifted, nil
+}
+
+// shiftTrackLyrics saves a shifted copy of a track's synced lyrics
+func (a *App) shiftTrackLyrics(track *database.PersistentTrack, offsetMs int) error {
+	if offsetMs == 0 {
+		return nil
+	}
+
+	shifted := lyrics.Shift(*track.LrcLyrics, offsetMs)
+	return a.saveTrackLyrics(track, &shifted, track.TxtLyrics, false, database.HistorySourceEdit)
+}
+
+// ExportLyrics converts a track's synced lyrics to a subtitle format (srt, vtt
+// or ttml) and returns the result
+func (a *App) ExportLyrics(trackID int64, format string) (string, error) {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get track: %w", err)
+	}
+
+	if track.LrcLyrics == nil || !lyrics.IsSynced(*track.LrcLyrics) {
+		return "", fmt.Errorf("track with ID %d has no synced lyrics", trackID)
+	}
+
+	switch strings.ToLower(format) {
+	case constants.LRCFormat:
+		return *track.LrcLyrics, nil
+	case constants.SRTFormat:
+		return lyrics.ToSRT(*track.LrcLyrics, track.Duration), nil
+	case constants.VTTFormat:
+		return lyrics.ToVTT(*track.LrcLyrics, track.Duration), nil
+	case constants.TTMLFormat:
+		return lyrics.ToTTML(*track.LrcLyrics, track.Duration), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// ImportLyrics converts SRT or WebVTT subtitles to synced lyrics and saves them
+// for a track. The plain lyrics are kept.
+func (a *App) ImportLyrics(trackID int64, subtitles string) error {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return fmt.Errorf("failed to get track: %w", err)
+	}
+
+	lrc, err := lyrics.FromSubtitles(subtitles)
+	if err != nil {
+		return fmt.Errorf("failed to import subtitles: %w", err)
+	}
+
+	return a.saveTrackLyrics(track, &lrc, track.TxtLyrics, false, database.HistorySourceImport)
+}
+
+// AutoSyncLyrics aligns a track's plain lyrics to the vocals detected in its
+// audio and loads the result as a draft into the track's sync session, flagged
+// as auto, replacing any unsaved session. Nothing is saved until the draft is
+// reviewed and saved with SaveSyncSession. A track that already has synced
+// lyrics is refused unless overwrite is set.
+func (a *App) AutoSyncLyrics(trackID int64, overwrite bool) (*lyrics.SyncState, error) {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track: %w", err)
+	}
+
+	if track.TxtLyrics == nil {
+		return nil, fmt.Errorf("track with ID %d has no plain lyrics", trackID)
+	}
+	if !overwrite && track.LrcLyrics != nil && lyrics.IsSynced(*track.LrcLyrics) {
+		return nil, fmt.Errorf("track with ID %d already has synced lyrics", trackID)
+	}
+
+	pcm, err := audio.DecodeTrack(track)
+	if err != nil {
+		return nil, err
+	}
+
+	lrc, err := lyrics.AutoSync(*track.TxtLyrics, audio.DetectVocalSegments(pcm))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync lyrics of %s: %w", track.FilePath, err)
+	}
+
+	session, err := lyrics.NewDraftSyncSession(lrc)
+	if err != nil {
+		return nil, err
+	}
+
+	a.syncMu.Lock()
+	defer a.syncMu.Unlock()
+	a.syncSessions[trackID] = session
+
+	state := session.State()
+	return &state, nil
+}
+
+// chooseLyrics returns the exact match unless it is missing or its synced
+// lyrics score below candidateSearchScore. Only then are search results for
+// the same track validated, and the candidate with the best score returned;
+// the exact match wins ties.
+func (a *App) chooseLyrics(track *database.PersistentTrack, exact lrclib.Response) lrclib.Response {
+	bestScore := -1
+	switch resp := exact.(type) {
+	case lrclib.Instrumental, lrclib.UnsyncedLyrics:
+		return exact
+	case lrclib.SyncedLyrics:
+		bestScore = lyrics.Validate(resp.Synced, track.Duration).Score
+		if bestScore >= candidateSearchScore {
+			return exact
+		}
+	}
+
+	results, err := a.lrclib.SearchLyrics(a.ctx, track.Title, track.ArtistName, track.AlbumName, "")
+	if err != nil {
+		fmt.Printf("Failed to search lyrics candidates for track %d: %v\n", track.ID, err)
+		return exact
+	}
+
+	best := exact
+	for _, result := range results.Data {
+		if result.SyncedLyrics == nil || result.Instrumental {
+			continue
+		}
+		if track.Duration > 0 && math.Abs(result.Duration-track.Duration) > candidateDurationTolerance {
+			continue
+		}
+
+		score := lyrics.Validate(*result.SyncedLyrics, track.Duration).Score
+		if score > bestScore {
+			best = a.lrclib.ConvertSearchResult(result)
+			bestScore = score
+		}
+	}
+
+	return best
+}
+
+// GetLyricsHealthReport validates the synced lyrics of every track in the
+// library. Tracks are sorted by score, worst first.
+func (a *App) GetLyricsHealthReport() (*LyricsHealthReport, error) {
+	tracks, err := a.db.GetTracks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks: %w", err)
+	}
+
+	report := &LyricsHealthReport{Tracks: []TrackLyricsHealth{}}
+	totalScore := 0
+	for _, track := range tracks {
+		if track.Instrumental || track.LrcLyrics == nil || !lyrics.IsSynced(*track.LrcLyrics) {
+			continue
+		}
+
+		result := lyrics.Validate(*track.LrcLyrics, track.Duration)
+		report.TracksChecked++
+		totalScore += result.Score
+		if len(result.Issues) > 0 {
+			report.TracksWithIssues++
+		}
+
+		report.Tracks = append(report.Tracks, TrackLyricsHealth{
+			TrackID:    track.ID,
+			Title:      track.Title,
+			ArtistName: track.ArtistName,
+			AlbumName:  track.AlbumName,
+			Score:      result.Score,
+			Issues:     result.Issues,
+		})
+	}
+
+	if report.TracksChecked > 0 {
+		report.AverageScore = float64(totalScore) / float64(report.TracksChecked)
+	}
+
+	sort.SliceStable(report.Tracks, func(i, j int) bool {
+		return report.Tracks[i].Score < report.Tracks[j].Score
+	})
+
+	return report, nil
+}
+
+// optionalLyrics returns nil for empty lyrics
+func optionalLyrics(text string) *string {
+	if text == "" {
+		return nil
+	}
+	return &text
+}
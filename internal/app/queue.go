@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"lrcget-go/internal/audio"
+	"lrcget-go/internal/constants"
+	"lrcget-go/internal/database"
+)
+
+// GetQueue returns the playback queue in play order
+func (a *App) GetQueue() audio.QueueState {
+	return a.queue.State()
+}
+
+// PlayAlbum replaces the queue with the tracks of an album and plays the first one
+func (a *App) PlayAlbum(albumID int64) error {
+	tracks, err := a.db.GetTracksByAlbumID(albumID)
+	if err != nil {
+		return fmt.Errorf("failed to get album tracks: %w", err)
+	}
+	return a.playTracks(tracks)
+}
+
+// PlayArtist replaces the queue with the tracks of an artist and plays the first one
+func (a *App) PlayArtist(artistID int64) error {
+	tracks, err := a.db.GetTracksByArtistID(artistID)
+	if err != nil {
+		return fmt.Errorf("failed to get artist tracks: %w", err)
+	}
+	return a.playTracks(tracks)
+}
+
+// EnqueueTracks adds tracks to the end of the queue
+func (a *App) EnqueueTracks(trackIDs []int64) error {
+	tracks := make([]*database.PersistentTrack, 0, len(trackIDs))
+	for _, trackID := range trackIDs {
+		track, err := a.db.GetTrackByID(trackID)
+		if err != nil {
+			return fmt.Errorf("failed to get track: %w", err)
+		}
+		tracks = append(tracks, track)
+	}
+
+	a.queue.Enqueue(tracks...)
+	return a.saveQueue()
+}
+
+// PlayQueueIndex plays the track at an index of the queue's play order
+func (a *App) PlayQueueIndex(index int) error {
+	track, err := a.queue.Jump(index)
+	if err != nil {
+		return err
+	}
+	return a.playQueueTrack(track)
+}
+
+// NextTrack plays the next track of the queue
+func (a *App) NextTrack() error {
+	return a.playQueueTrack(a.queue.Next(false))
+}
+
+// PreviousTrack plays the previous track of the queue
+func (a *App) PreviousTrack() error {
+	return a.playQueueTrack(a.queue.Previous())
+}
+
+// SetRepeatMode sets the queue's repeat mode (off, one or all)
+func (a *App) SetRepeatMode(mode string) error {
+	if err := a.queue.SetRepeat(mode); err != nil {
+		return err
+	}
+	return a.saveQueue()
+}
+
+// SetShuffle enables or disables shuffling the queue
+func (a *App) SetShuffle(enabled bool) error {
+	a.queue.SetShuffle(enabled)
+	return a.saveQueue()
+}
+
+// ClearQueue removes all tracks from the queue
+func (a *App) ClearQueue() error {
+	a.queue.Clear()
+	return a.saveQueue()
+}
+
+// playTracks replaces the queue with tracks and plays the first one
+func (a *App) playTracks(tracks []database.PersistentTrack) error {
+	queued := make([]*database.PersistentTrack, len(tracks))
+	for i := range tracks {
+		queued[i] = &tracks[i]
+	}
+
+	return a.playQueueTrack(a.queue.Set(queued, 0))
+}
+
+// playQueueTrack saves the queue and plays its current track. At the end of
+// the queue playback stops.
+func (a *App) playQueueTrack(track *database.PersistentTrack) error {
+	if err := a.saveQueue(); err != nil {
+		return err
+	}
+
+	if track == nil {
+		return a.player.Stop()
+	}
+	return a.player.Play(track)
+}
+
+// advanceQueue plays the next track of the queue when the current one finishes.
+// Tracks played outside of the queue do not advance it.
+func (a *App) advanceQueue(finished *database.PersistentTrack) {
+	current := a.queue.Current()
+	if current == nil || current.ID != finished.ID {
+		return
+	}
+
+	next := a.queue.Next(true)
+	if next == nil {
+		return
+	}
+	if err := a.playQueueTrack(next); err != nil {
+		fmt.Printf("Failed to play next track: %v\n", err)
+	}
+}
+
+// saveQueue persists the queue and sends it to the frontend
+func (a *App) saveQueue() error {
+	if err := a.db.SaveQueue(a.queue.Persistent()); err != nil {
+		return fmt.Errorf("failed to save queue: %w", err)
+	}
+
+	runtime.EventsEmit(a.ctx, constants.QueueEvent, a.queue.State())
+	return nil
+}
+
+// restoreQueue loads the queue saved by the previous session
+func (a *App) restoreQueue() {
+	saved, err := a.db.GetQueue()
+	if err != nil {
+		fmt.Printf("Failed to load queue: %v\n", err)
+		return
+	}
+
+	tracks := make(map[int64]*database.PersistentTrack)
+	for _, entry := range saved.Entries {
+		if track, err := a.db.GetTrackByID(entry.TrackID); err == nil {
+			tracks[entry.TrackID] = track
+		}
+	}
+
+	a.queue.Restore(saved, tracks)
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"lrcget-go/internal/database"
+	"lrcget-go/internal/lrclib"
+)
+
+// RefreshResult summarizes a lyrics refresh run
+type RefreshResult struct {
+	Checked   int `json:"checked"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	NotFound  int `json:"not_found"`
+	Failed    int `json:"failed"`
+}
+
+// refreshOutcome describes what happened to a single track during a refresh
+type refreshOutcome int
+
+const (
+	refreshUnchanged refreshOutcome = iota
+	refreshUpdated
+	refreshNotFound
+)
+
+// RefreshLyrics re-fetches lyrics by their LRCLIB record ID for every track whose
+// lyrics were downloaded more than olderThanDays days ago. Tracks are only updated
+// when the remote lyrics changed; the previous version is kept in the lyrics history.
+func (a *App) RefreshLyrics(olderThanDays int) (*RefreshResult, error) {
+	if olderThanDays < 0 {
+		return nil, fmt.Errorf("invalid number of days: %d", olderThanDays)
+	}
+
+	config, err := a.db.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+
+	// Update LRCLIB client with current instance
+	a.lrclib.SetBaseURL(config.LrclibInstance)
+
+	cutoff := time.Now().AddDate(0, 0, -olderThanDays)
+	sources, err := a.db.GetLyricsSourcesDownloadedBefore(cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lyrics sources: %w", err)
+	}
+
+	result := &RefreshResult{}
+	for _, source := range sources {
+		if err := a.ctx.Err(); err != nil {
+			return result, err
+		}
+
+		result.Checked++
+		outcome, err := a.refreshTrackLyrics(source)
+		if err != nil {
+			fmt.Printf("Failed to refresh lyrics for track %d: %v\n", source.TrackID, err)
+			result.Failed++
+			continue
+		}
+
+		switch outcome {
+		case refreshUpdated:
+			result.Updated++
+		case refreshNotFound:
+			result.NotFound++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	return result, nil
+}
+
+// refreshTrackLyrics re-fetches a single track's lyrics by LRCLIB record ID
+func (a *App) refreshTrackLyrics(source database.PersistentLyricsSource) (refreshOutcome, error) {
+	track, err := a.db.GetTrackByID(source.TrackID)
+	if err != nil {
+		return refreshUnchanged, fmt.Errorf("failed to get track: %w", err)
+	}
+
+	response, err := a.lrclib.GetLyricsByID(a.ctx, source.LrclibID)
+	if err != nil {
+		return refreshUnchanged, fmt.Errorf("failed to get lyrics: %w", err)
+	}
+
+	var lrcLyrics, txtLyrics *string
+	var instrumental bool
+	var metadata lrclib.Metadata
+
+	switch resp := response.(type) {
+	case lrclib.SyncedLyrics:
+		lrcLyrics, txtLyrics, metadata = &resp.Synced, &resp.Plain, resp.Metadata
+	case lrclib.UnsyncedLyrics:
+		txtLyrics, metadata = &resp.Plain, resp.Metadata
+	case lrclib.Instrumental:
+		instrumental, metadata = true, resp.Metadata
+	case lrclib.None:
+		return refreshNotFound, nil
+	default:
+		return refreshUnchanged, fmt.Errorf("unknown response type")
+	}
+
+	changed := !sameLyrics(track.LrcLyrics, lrcLyrics) ||
+		!sameLyrics(track.TxtLyrics, txtLyrics) ||
+		track.Instrumental != instrumental
+
+	if changed {
+		err = a.saveTrackLyrics(track, lrcLyrics, txtLyrics, instrumental, database.HistorySourceDownload)
+		if err != nil {
+			return refreshUnchanged, err
+		}
+	}
+
+	// The record was checked now, even if nothing changed
+	if metadata.ID == 0 {
+		metadata.ID = source.LrclibID
+	}
+	if err := a.saveLyricsSource(track.ID, metadata); err != nil {
+		return refreshUnchanged, err
+	}
+
+	if changed {
+		return refreshUpdated, nil
+	}
+	return refreshUnchanged, nil
+}
+
+// sameLyrics compares two optional lyrics, treating nil and empty as equal
+func sameLyrics(a, b *string) bool {
+	var left, right string
+	if a != nil {
+		left = *a
+	}
+	if b != nil {
+		right = *b
+	}
+	return left == right
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"fmt"
+
+	"lrcget-go/internal/audio"
+	"lrcget-go/internal/database"
+	"lrcget-go/internal/lyrics"
+)
+
+// StartSyncSession starts creating synced lyrics for a track from its plain
+// lyrics, replacing any unsaved session of the track
+func (a *App) StartSyncSession(trackID int64) (*lyrics.SyncState, error) {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track: %w", err)
+	}
+
+	if track.TxtLyrics == nil {
+		return nil, fmt.Errorf("track with ID %d has no plain lyrics", trackID)
+	}
+
+	session, err := lyrics.NewSyncSession(*track.TxtLyrics)
+	if err != nil {
+		return nil, err
+	}
+
+	a.syncMu.Lock()
+	defer a.syncMu.Unlock()
+	a.syncSessions[trackID] = session
+
+	state := session.State()
+	return &state, nil
+}
+
+// GetSyncSession returns the state of a track's sync session
+func (a *App) GetSyncSession(trackID int64) (*lyrics.SyncState, error) {
+	return a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		return nil
+	})
+}
+
+// StampSyncLine stamps the current line of a track's sync session with the
+// playback position and moves to the next line. The track must be loaded in
+// the player.
+func (a *App) StampSyncLine(trackID int64) (*lyrics.SyncState, error) {
+	position, err := a.syncPosition(trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		return session.Stamp(position)
+	})
+}
+
+// InsertSyncBlank inserts an empty line stamped with the playback position
+// before the current line, to mark an instrumental break
+func (a *App) InsertSyncBlank(trackID int64) (*lyrics.SyncState, error) {
+	position, err := a.syncPosition(trackID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		session.InsertBlank(position)
+		return nil
+	})
+}
+
+// NudgeSyncLine moves the time of a stamped line by offsetMs milliseconds
+func (a *App) NudgeSyncLine(trackID int64, index int, offsetMs int) (*lyrics.SyncState, error) {
+	return a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		return session.Nudge(index, offsetMs)
+	})
+}
+
+// SelectSyncLine makes a line the current one, so that it is stamped next
+func (a *App) SelectSyncLine(trackID int64, index int) (*lyrics.SyncState, error) {
+	return a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		return session.Select(index)
+	})
+}
+
+// UndoSync reverts the last change of a track's sync session
+func (a *App) UndoSync(trackID int64) (*lyrics.SyncState, error) {
+	return a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		if !session.Undo() {
+			return fmt.Errorf("nothing to undo")
+		}
+		return nil
+	})
+}
+
+// RedoSync applies the last undone change of a track's sync session again
+func (a *App) RedoSync(trackID int64) (*lyrics.SyncState, error) {
+	return a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		if !session.Redo() {
+			return fmt.Errorf("nothing to redo")
+		}
+		return nil
+	})
+}
+
+// PreviewSyncLyrics returns the synced lyrics of the lines stamped so far
+func (a *App) PreviewSyncLyrics(trackID int64) (string, error) {
+	var lrc string
+	_, err := a.updateSyncSession(trackID, func(session *lyrics.SyncSession) error {
+		lrc = session.LRC()
+		return nil
+	})
+	return lrc, err
+}
+
+// SaveSyncSession saves the synced lyrics of a track's sync session to the
+// database and the sidecar file, and ends the session. Every line must be
+// stamped. Lyrics of a session started from an automatic draft are recorded in
+// the lyrics history as auto-synced.
+func (a *App) SaveSyncSession(trackID int64) error {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return fmt.Errorf("failed to get track: %w", err)
+	}
+
+	a.syncMu.Lock()
+	defer a.syncMu.Unlock()
+
+	session, ok := a.syncSessions[trackID]
+	if !ok {
+		return fmt.Errorf("no sync session for track with ID %d", trackID)
+	}
+	if !session.Complete() {
+		return fmt.Errorf("not every line has been stamped")
+	}
+
+	source := database.HistorySourceEdit
+	if session.Auto() {
+		source = database.HistorySourceAuto
+	}
+
+	lrc := session.LRC()
+	if err := a.saveTrackLyrics(track, &lrc, track.TxtLyrics, false, source); err != nil {
+		return err
+	}
+
+	delete(a.syncSessions, trackID)
+	return nil
+}
+
+// CancelSyncSession discards a track's sync session
+func (a *App) CancelSyncSession(trackID int64) {
+	a.syncMu.Lock()
+	defer a.syncMu.Unlock()
+	delete(a.syncSessions, trackID)
+}
+
+// updateSyncSession applies a change to a track's sync session and returns its new state
+func (a *App) updateSyncSession(trackID int64, change func(session *lyrics.SyncSession) error) (*lyrics.SyncState, error) {
+	a.syncMu.Lock()
+	defer a.syncMu.Unlock()
+
+	session, ok := a.syncSessions[trackID]
+	if !ok {
+		return nil, fmt.Errorf("no sync session for track with ID %d", trackID)
+	}
+
+	if err := change(session); err != nil {
+		return nil, err
+	}
+
+	state := session.State()
+	return &state, nil
+}
+
+// syncPosition returns the playback position of a track being synced
+func (a *App) syncPosition(trackID int64) (float64, error) {
+	state := a.player.GetState()
+	if state.Track == nil || state.Track.ID != trackID || state.Status == audio.Stopped {
+		return 0, fmt.Errorf("track with ID %d is not playing", trackID)
+	}
+	return state.Progress, nil
+}
@@ -0,0 +1,253 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"lrcget-go/internal/constants"
+	"lrcget-go/internal/database"
+	"lrcget-go/internal/filesystem"
+)
+
+// subscribeLibraryChanges indexes the changes reported by the watcher
+func (a *App) subscribeLibraryChanges() {
+	a.watcher.OnFileAdded(a.indexFile)
+	a.watcher.OnFileModified(a.indexFile)
+	a.watcher.OnFileRemoved(a.unindexFile)
+	a.watcher.OnDirectoryRemoved(a.unindexFile)
+	a.watcher.OnFileMoved(a.moveFile)
+	a.watcher.OnDirectoryMoved(a.moveDirectory)
+}
+
+// watchLibrary watches the library directories once the library has been
+// initialized, so that later changes are indexed without a full rescan
+func (a *App) watchLibrary() {
+	initialized, err := a.db.GetInit()
+	if err != nil {
+		fmt.Printf("Failed to get init status: %v\n", err)
+		return
+	}
+	if !initialized {
+		return
+	}
+
+	directories, err := a.db.GetDirectories()
+	if err != nil {
+		fmt.Printf("Failed to get directories: %v\n", err)
+		return
+	}
+
+	if err := a.watcher.Watch(directories); err != nil {
+		fmt.Printf("Failed to watch library directories: %v\n", err)
+	}
+}
+
+// loadScanRules passes the scan patterns of the library directories, the
+// hidden file, small file and symbolic link options and the CUE lyrics layout
+// to the scanner
+func (a *App) loadScanRules() {
+	directories, err := a.db.GetDirectorySettings()
+	if err != nil {
+		fmt.Printf("Failed to get directories: %v\n", err)
+		return
+	}
+
+	config, err := a.db.GetConfig()
+	if err != nil {
+		fmt.Printf("Failed to get config: %v\n", err)
+		return
+	}
+
+	rules := make(map[string]filesystem.ScanRules, len(directories))
+	for _, directory := range directories {
+		r := filesystem.ScanRules{
+			Include:        directory.Include,
+			Exclude:        directory.Exclude,
+			SkipHidden:     config.SkipHiddenFiles,
+			FollowSymlinks: config.FollowSymlinks,
+		}
+		if config.SkipSmallFiles {
+			r.MinSize = constants.MinAudioFileSize
+		}
+		rules[directory.Path] = r
+	}
+	a.scanner.SetRules(rules)
+	a.scanner.SetCueLyricsLayout(config.CueLyricsLayout)
+}
+
+// indexFile adds or updates the tracks of an audio file, or of the audio file
+// a CUE sheet belongs to, or updates the lyrics of the tracks a lyrics file
+// belongs to. Audio files left out by the scan rules are ignored.
+func (a *App) indexFile(path string) {
+	switch {
+	case a.scanner.IsAudioFile(path):
+		if !a.scanner.Includes(path) {
+			return
+		}
+		if err := a.indexTrack(path); err != nil {
+			fmt.Printf("Failed to index %s: %v\n", path, err)
+			return
+		}
+
+	case a.scanner.IsCueFile(path):
+		changed := false
+		for _, audioPath := range a.scanner.FindAudioFiles(path) {
+			if !a.scanner.Includes(audioPath) {
+				continue
+			}
+			if err := a.indexTrack(audioPath); err != nil {
+				fmt.Printf("Failed to index %s: %v\n", audioPath, err)
+				continue
+			}
+			changed = true
+		}
+		if !changed {
+			return
+		}
+
+	case a.scanner.IsLyricsFile(path):
+		changed := false
+		for _, audioPath := range a.scanner.FindAudioFiles(path) {
+			updated, err := a.indexSidecarLyrics(audioPath)
+			if err != nil {
+				fmt.Printf("Failed to update lyrics of %s: %v\n", audioPath, err)
+				continue
+			}
+			changed = changed || updated
+		}
+		if !changed {
+			return
+		}
+
+	default:
+		return
+	}
+
+	runtime.EventsEmit(a.ctx, constants.LibraryEvent, path)
+}
+
+// indexTrack adds the tracks of an audio file, a single one or one per track
+// of its CUE sheet, or updates their tags. Tracks no longer in the file's CUE
+// sheet are removed, keeping their lyrics history. A CUE sheet that cannot be
+// read is logged, and the file indexed as a single track unless it already
+// has CUE tracks, which are kept.
+func (a *App) indexTrack(path string) error {
+	tracks, err := a.scanner.ExtractTracks(path)
+	var cueErr *filesystem.CueError
+	if errors.As(err, &cueErr) {
+		fmt.Printf("%v\n", err)
+	} else if err != nil {
+		return err
+	}
+
+	if err := a.db.BulkUpsertTracks(tracks); err != nil {
+		return err
+	}
+	_, err = a.indexSidecarLyrics(path)
+	return err
+}
+
+// indexSidecarLyrics updates the lyrics of an audio file's tracks from their
+// .lrc and .txt files when they were changed by another program, and reports
+// whether they were. Missing lyrics files are ignored, since saving lyrics
+// removes the sidecar that no longer applies.
+func (a *App) indexSidecarLyrics(audioPath string) (bool, error) {
+	tracks, err := a.db.GetTracksByFilePath(audioPath)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	for i := range tracks {
+		track := &tracks[i]
+		lrcLyrics, txtLyrics, instrumental := track.LrcLyrics, track.TxtLyrics, track.Instrumental
+		if lrc := a.scanner.GetLrcLyrics(track); lrc != nil {
+			if strings.TrimSpace(*lrc) == filesystem.InstrumentalLyrics {
+				lrcLyrics, instrumental = nil, true
+			} else {
+				lrcLyrics, instrumental = lrc, false
+			}
+		}
+		if txt := a.scanner.GetTxtLyrics(track); txt != nil {
+			txtLyrics = txt
+		}
+
+		if sameLyrics(lrcLyrics, track.LrcLyrics) && sameLyrics(txtLyrics, track.TxtLyrics) && instrumental == track.Instrumental {
+			continue
+		}
+
+		if err := a.db.SaveTrackLyrics(track.ID, lrcLyrics, txtLyrics, instrumental, database.HistorySourceScan); err != nil {
+			return changed, err
+		}
+		a.refreshPlayerLyrics(track.ID, lrcLyrics, instrumental)
+		changed = true
+	}
+	return changed, nil
+}
+
+// unindexFile removes the tracks of a deleted audio file, or the tracks of a
+// deleted directory. Their lyrics history is kept in case the file shows up
+// again. The audio file of a deleted CUE sheet is indexed again as a single
+// track.
+func (a *App) unindexFile(path string) {
+	if a.scanner.IsLyricsFile(path) {
+		return
+	}
+	if a.scanner.IsCueFile(path) {
+		a.indexFile(path)
+		return
+	}
+
+	removed, err := a.db.DetachTracksByPath(path)
+	if err != nil {
+		fmt.Printf("Failed to remove %s from the library: %v\n", path, err)
+		return
+	}
+	if removed > 0 {
+		runtime.EventsEmit(a.ctx, constants.LibraryEvent, path)
+	}
+}
+
+// moveFile follows an audio file renamed or moved within the library, keeping
+// its tracks, then indexes it again at its new path. Other files are removed
+// from their old path and indexed at their new one.
+func (a *App) moveFile(from, to string) {
+	if !a.scanner.IsAudioFile(from) || !a.scanner.IsAudioFile(to) {
+		a.unindexFile(from)
+		a.indexFile(to)
+		return
+	}
+
+	if _, err := a.db.MoveTrackPaths(from, to); err != nil {
+		fmt.Printf("Failed to move %s in the library: %v\n", from, err)
+		return
+	}
+	if !a.scanner.Includes(to) {
+		a.unindexFile(to)
+		return
+	}
+	a.indexFile(to)
+}
+
+// moveDirectory follows a directory renamed or moved within the library,
+// keeping the tracks under it. Audio files left out by the scan rules at
+// their new path are removed.
+func (a *App) moveDirectory(from, to string) {
+	paths, err := a.db.MoveTrackPaths(from, to)
+	if err != nil {
+		fmt.Printf("Failed to move %s in the library: %v\n", from, err)
+		return
+	}
+
+	for _, path := range paths {
+		if !a.scanner.Includes(path) {
+			a.unindexFile(path)
+		}
+	}
+	if len(paths) > 0 {
+		runtime.EventsEmit(a.ctx, constants.LibraryEvent, to)
+	}
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"fmt"
+
+	"lrcget-go/internal/audio"
+)
+
+// GetWaveformPeaks returns the min/max peaks of a track's audio at resolution
+// peaks per second, for drawing a waveform under the lyrics editor timeline
+func (a *App) GetWaveformPeaks(trackID int64, resolution int) (*audio.Peaks, error) {
+	track, err := a.db.GetTrackByID(trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get track: %w", err)
+	}
+
+	peaks, err := a.peaks.Get(track, resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate waveform of %s: %w", track.FilePath, err)
+	}
+
+	return peaks, nil
+}
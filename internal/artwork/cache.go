@@ -0,0 +1,152 @@
+// Package artwork stores album art as resized thumbnails and serves them to
+// the frontend.
+package artwork
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	// Decoders of the picture formats found in tags and folders
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Thumbnail settings
+const (
+	ThumbnailSize    = 512 // maximum width and height, in pixels
+	thumbnailQuality = 85
+)
+
+// thumbnailName matches the file names of cached thumbnails
+var thumbnailName = regexp.MustCompile(`^[0-9a-f]{64}\.jpg$`)
+
+// Cache stores thumbnails of album art on disk. Thumbnails are named after
+// the SHA-256 of the original picture, so a picture shared by many tracks is
+// stored once and a changed picture gets a new name.
+type Cache struct {
+	dir string
+}
+
+// NewCache creates an artwork cache in a directory
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Store writes a thumbnail of a picture to the cache, unless it is already
+// there, and returns its path
+func (c *Cache) Store(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:]) + ".jpg"
+	path := filepath.Join(c.dir, name[:2], name)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode picture: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumbnail(img, ThumbnailSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return "", fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so that readers never see a partial thumbnail
+	tmp, err := os.CreateTemp(filepath.Dir(path), name+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	return path, nil
+}
+
+// Prune removes the thumbnails that are not in keep, a set of paths returned
+// by Store, and returns the number of thumbnails removed
+func (c *Cache) Prune(keep map[string]bool) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(c.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == c.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || keep[path] {
+			return nil
+		}
+		if thumbnailName.MatchString(entry.Name()) || strings.HasSuffix(entry.Name(), ".tmp") {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to prune artwork cache: %w", err)
+	}
+	return removed, nil
+}
+
+// thumbnail scales an image down to fit in a size by size square, averaging
+// the pixels covered by each thumbnail pixel. Transparent areas are drawn over
+// white, since JPEG has no alpha channel.
+func thumbnail(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	scale := math.Min(1, float64(size)/float64(max(w, h)))
+	tw := max(1, int(math.Round(float64(w)*scale)))
+	th := max(1, int(math.Round(float64(h)*scale)))
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := range th {
+		y0, y1 := bounds.Min.Y+y*h/th, bounds.Min.Y+max((y+1)*h/th, y*h/th+1)
+		for x := range tw {
+			x0, x1 := bounds.Min.X+x*w/tw, bounds.Min.X+max((x+1)*w/tw, x*w/tw+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			// Colors are premultiplied, so adding the missing alpha composes over white
+			white := 0xffff - a/n
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r/n + white),
+				G: uint16(g/n + white),
+				B: uint16(b/n + white),
+				A: 0xffff,
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,133 @@
+package artwork
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encodePNG returns a PNG of a solid color
+func encodePNG(t *testing.T, width, height int, c color.Color) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStore(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		color         color.Color
+		expectedW     int
+		expectedH     int
+		expectedColor color.Color
+	}{
+		{"small", 100, 50, color.NRGBA{R: 255, A: 255}, 100, 50, color.NRGBA{R: 255, A: 255}},
+		{"wide", 1024, 256, color.NRGBA{B: 255, A: 255}, ThumbnailSize, 128, color.NRGBA{B: 255, A: 255}},
+		{"tall", 300, 1200, color.NRGBA{G: 255, A: 255}, 128, ThumbnailSize, color.NRGBA{G: 255, A: 255}},
+		{"transparent", 10, 10, color.NRGBA{}, 10, 10, color.NRGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+
+	cache := NewCache(t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encodePNG(t, tt.width, tt.height, tt.color)
+			path, err := cache.Store(data)
+			if err != nil {
+				t.Fatalf("Store() error = %v", err)
+			}
+			if again, err := cache.Store(data); err != nil || again != path {
+				t.Errorf("Store() of the same picture = %s, %v, expected %s", again, err, path)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			img, err := jpeg.Decode(file)
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+
+			if size := img.Bounds().Size(); size.X != tt.expectedW || size.Y != tt.expectedH {
+				t.Errorf("thumbnail size = %v, expected %dx%d", size, tt.expectedW, tt.expectedH)
+			}
+			r, g, b, _ := img.At(img.Bounds().Dx()/2, img.Bounds().Dy()/2).RGBA()
+			er, eg, eb, _ := tt.expectedColor.RGBA()
+			for _, d := range []int{int(r) - int(er), int(g) - int(eg), int(b) - int(eb)} {
+				if d < -0x1000 || d > 0x1000 {
+					t.Errorf("thumbnail color = %d %d %d, expected %d %d %d", r, g, b, er, eg, eb)
+					break
+				}
+			}
+		})
+	}
+
+	if _, err := cache.Store([]byte("not a picture")); err == nil {
+		t.Error("Store() of invalid data succeeded")
+	}
+}
+
+func TestPruneAndServe(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	kept, err := cache.Store(encodePNG(t, 8, 8, color.Black))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale, err := cache.Store(encodePNG(t, 8, 8, color.White))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := cache.Prune(map[string]bool{kept: true})
+	if err != nil || removed != 1 {
+		t.Fatalf("Prune() = %d, %v, expected 1 thumbnail removed", removed, err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Prune() kept %s", stale)
+	}
+	if removed, err := NewCache(filepath.Join(t.TempDir(), "missing")).Prune(nil); err != nil || removed != 0 {
+		t.Errorf("Prune() of a missing cache = %d, %v, expected nothing", removed, err)
+	}
+
+	handler := NewHandler(func() *Cache { return cache })
+	tests := []struct {
+		url      string
+		expected int
+	}{
+		{URL(kept), http.StatusOK},
+		{URL(stale), http.StatusNotFound},
+		{URLPrefix + "../db.sqlite3", http.StatusNotFound},
+		{"/index.html", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if recorder.Code != tt.expected {
+				t.Errorf("GET %s = %d, expected %d", tt.url, recorder.Code, tt.expected)
+			}
+			if tt.expected == http.StatusOK && recorder.Header().Get("Content-Type") != "image/jpeg" {
+				t.Errorf("GET %s content type = %q", tt.url, recorder.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package artwork
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// URLPrefix is the path under which thumbnails are served to the frontend
+const URLPrefix = "/artwork/"
+
+// URL returns the URL of a thumbnail stored at imagePath
+func URL(imagePath string) string {
+	return URLPrefix + filepath.Base(imagePath)
+}
+
+// Handler serves the thumbnails of a cache at URL. Since a thumbnail never
+// changes under its name, responses may be cached forever; a rescan that finds
+// new art gives the album a new URL instead.
+type Handler struct {
+	cache func() *Cache
+}
+
+// NewHandler creates a handler serving the thumbnails of the cache returned
+// by cache, which may be nil until the cache is available
+func NewHandler(cache func() *Cache) *Handler {
+	return &Handler{cache: cache}
+}
+
+// ServeHTTP serves a thumbnail, or 404 for any other path
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(path.Clean(r.URL.Path), URLPrefix)
+	cache := h.cache()
+	if !ok || !thumbnailName.MatchString(name) || cache == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeFile(w, r, filepath.Join(cache.dir, name[:2], name))
+}
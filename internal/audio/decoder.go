@@ -0,0 +1,192 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dhowden/tag"
+
+	"lrcget-go/internal/constants"
+	"lrcget-go/internal/database"
+)
+
+// decodeChunkFrames is the number of frames read at a time when decoding a whole file
+const decodeChunkFrames = 4096
+
+// Decoder reads an audio file as interleaved float32 PCM samples in the range [-1, 1]
+type Decoder interface {
+	// SampleRate returns the number of frames per second
+	SampleRate() int
+	// Channels returns the number of samples per frame
+	Channels() int
+	// Length returns the total number of frames, or 0 if it is unknown
+	Length() int64
+	// Read reads interleaved samples into buf and returns the number of samples
+	// read, always a multiple of Channels. It returns io.EOF at the end of the file.
+	Read(buf []float32) (int, error)
+	// SetPosition moves to the given frame
+	SetPosition(frame int64) error
+	// Close closes the underlying file
+	Close() error
+}
+
+// OpenDecoder opens an audio file with the decoder matching its extension
+func OpenDecoder(filePath string) (Decoder, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio file: %w", err)
+	}
+
+	var decoder Decoder
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+	switch ext {
+	case constants.MP3Format:
+		decoder, err = newMP3Decoder(file)
+	case constants.FLACFormat:
+		decoder, err = newFLACDecoder(file)
+	case constants.OGGFormat:
+		decoder, err = newOggDecoder(file)
+	case constants.WAVFormat:
+		decoder, err = newWAVDecoder(file)
+	default:
+		err = fmt.Errorf("unsupported audio format: %s", ext)
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to decode %s: %w", filepath.Base(filePath), err)
+	}
+	return decoder, nil
+}
+
+// PCM represents a fully decoded audio file
+type PCM struct {
+	Samples    []float32
+	SampleRate int
+	Channels   int
+}
+
+// GetSamples returns the interleaved samples
+func (p *PCM) GetSamples() []float32 { return p.Samples }
+
+// GetSampleRate returns the number of frames per second
+func (p *PCM) GetSampleRate() int { return p.SampleRate }
+
+// GetChannels returns the number of channels
+func (p *PCM) GetChannels() int { return p.Channels }
+
+// GetDuration returns the duration in seconds
+func (p *PCM) GetDuration() float64 {
+	if p.SampleRate == 0 || p.Channels == 0 {
+		return 0
+	}
+	return float64(len(p.Samples)/p.Channels) / float64(p.SampleRate)
+}
+
+// Decode decodes a whole audio file into memory
+func Decode(filePath string) (*PCM, error) {
+	decoder, err := OpenDecoder(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	return decodeAll(decoder)
+}
+
+// DecodeTrack decodes the audio of a track into memory
+func DecodeTrack(track *database.PersistentTrack) (*PCM, error) {
+	decoder, err := OpenTrackDecoder(track)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+	return decodeAll(decoder)
+}
+
+// decodeAll reads a decoder to the end
+func decodeAll(decoder Decoder) (*PCM, error) {
+	pcm := &PCM{SampleRate: decoder.SampleRate(), Channels: decoder.Channels()}
+	if length := decoder.Length(); length > 0 {
+		pcm.Samples = make([]float32, 0, length*int64(pcm.Channels))
+	}
+
+	buf := make([]float32, decodeChunkFrames*pcm.Channels)
+	for {
+		n, err := decoder.Read(buf)
+		pcm.Samples = append(pcm.Samples, buf[:n]...)
+		if err == io.EOF {
+			return pcm, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode audio: %w", err)
+		}
+	}
+}
+
+// Metadata represents the tags and stream properties of an audio file
+type Metadata struct {
+	Title      string  `json:"title"`
+	Artist     string  `json:"artist"`
+	Album      string  `json:"album"`
+	Duration   float64 `json:"duration"`
+	Bitrate    int     `json:"bitrate"`
+	SampleRate int     `json:"sample_rate"`
+	Channels   int     `json:"channels"`
+}
+
+// GetTitle returns the track title
+func (m *Metadata) GetTitle() string { return m.Title }
+
+// GetArtist returns the track artist
+func (m *Metadata) GetArtist() string { return m.Artist }
+
+// GetAlbum returns the album name
+func (m *Metadata) GetAlbum() string { return m.Album }
+
+// GetDuration returns the duration in seconds
+func (m *Metadata) GetDuration() float64 { return m.Duration }
+
+// GetBitrate returns the average bitrate in kbit/s
+func (m *Metadata) GetBitrate() int { return m.Bitrate }
+
+// GetSampleRate returns the number of frames per second
+func (m *Metadata) GetSampleRate() int { return m.SampleRate }
+
+// GetChannels returns the number of channels
+func (m *Metadata) GetChannels() int { return m.Channels }
+
+// ReadMetadata reads the tags and stream properties of an audio file. Missing
+// or unreadable tags are left empty.
+func ReadMetadata(filePath string) (*Metadata, error) {
+	decoder, err := OpenDecoder(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	metadata := &Metadata{SampleRate: decoder.SampleRate(), Channels: decoder.Channels()}
+	if decoder.SampleRate() > 0 {
+		metadata.Duration = float64(decoder.Length()) / float64(decoder.SampleRate())
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && metadata.Duration > 0 {
+		metadata.Bitrate = int(float64(info.Size()) * 8 / metadata.Duration / 1000)
+	}
+
+	if tags, err := tag.ReadFrom(file); err == nil {
+		metadata.Title = tags.Title()
+		metadata.Artist = tags.Artist()
+		metadata.Album = tags.Album()
+	}
+
+	return metadata, nil
+}
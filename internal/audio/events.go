@@ -0,0 +1,236 @@
+package audio
+
+import (
+	"sync"
+	"time"
+
+	"lrcget-go/internal/database"
+)
+
+// DefaultPositionInterval is how often position events are delivered during playback
+const DefaultPositionInterval = 40 * time.Millisecond
+
+// playerEvents holds the events caused by one change to the player
+type playerEvents struct {
+	state    *PlayerState
+	position *float64
+	volume   *float64
+	finished *database.PersistentTrack
+}
+
+// playerSnapshot holds the player fields that trigger events when they change
+type playerSnapshot struct {
+	status    PlayerStatus
+	track     *database.PersistentTrack
+	duration  float64
+	progress  float64
+	volume    float64
+	rate      float64
+	loop      *[2]float64
+	lineIndex int
+	wordIndex int
+}
+
+// eventDispatcher delivers player events to subscribers in order on its own
+// goroutine, so that callbacks never run with the player locked and may call
+// back into the player
+type eventDispatcher struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	queue    []playerEvents
+	closed   bool
+	state    []func(PlayerState)
+	position []func(float64)
+	volume   []func(float64)
+	finished []func(*database.PersistentTrack)
+}
+
+func newEventDispatcher() *eventDispatcher {
+	d := &eventDispatcher{}
+	d.cond = sync.NewCond(&d.mu)
+	go d.run()
+	return d
+}
+
+// push queues events for delivery
+func (d *eventDispatcher) push(events playerEvents) {
+	if events.state == nil && events.position == nil && events.volume == nil && events.finished == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closed {
+		d.queue = append(d.queue, events)
+		d.cond.Signal()
+	}
+}
+
+// close stops the dispatcher once the queued events have been delivered
+func (d *eventDispatcher) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	d.cond.Signal()
+}
+
+func (d *eventDispatcher) run() {
+	for {
+		d.mu.Lock()
+		for len(d.queue) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if len(d.queue) == 0 {
+			d.mu.Unlock()
+			return
+		}
+		events := d.queue[0]
+		d.queue = d.queue[1:]
+		state, position, volume, finished := d.state, d.position, d.volume, d.finished
+		d.mu.Unlock()
+
+		if events.state != nil {
+			for _, callback := range state {
+				callback(*events.state)
+			}
+		}
+		if events.position != nil {
+			for _, callback := range position {
+				callback(*events.position)
+			}
+		}
+		if events.volume != nil {
+			for _, callback := range volume {
+				callback(*events.volume)
+			}
+		}
+		if events.finished != nil {
+			for _, callback := range finished {
+				callback(events.finished)
+			}
+		}
+	}
+}
+
+// OnStateChange subscribes to changes of the status, the track, the playback
+// rate, the A-B loop and the current synced lyrics line or word. Finishing a track produces a change to stopped.
+func (p *Player) OnStateChange(callback func(PlayerState)) {
+	p.events.mu.Lock()
+	defer p.events.mu.Unlock()
+	p.events.state = append(p.events.state, callback)
+}
+
+// OnPositionChange subscribes to the playback position, delivered at the
+// position interval during playback and after seeking or stopping
+func (p *Player) OnPositionChange(callback func(float64)) {
+	p.events.mu.Lock()
+	defer p.events.mu.Unlock()
+	p.events.position = append(p.events.position, callback)
+}
+
+// OnVolumeChange subscribes to volume changes
+func (p *Player) OnVolumeChange(callback func(float64)) {
+	p.events.mu.Lock()
+	defer p.events.mu.Unlock()
+	p.events.volume = append(p.events.volume, callback)
+}
+
+// OnTrackFinished subscribes to tracks being played to the end
+func (p *Player) OnTrackFinished(callback func(*database.PersistentTrack)) {
+	p.events.mu.Lock()
+	defer p.events.mu.Unlock()
+	p.events.finished = append(p.events.finished, callback)
+}
+
+// SetPositionInterval sets how often position events are delivered during playback
+func (p *Player) SetPositionInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultPositionInterval
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.positionInterval = interval
+}
+
+// update runs fn with the player locked and queues the events caused by the change
+func (p *Player) update(fn func() error) error {
+	p.mu.Lock()
+	before := p.snapshot()
+	err := fn()
+	events := p.changes(before)
+	p.mu.Unlock()
+
+	p.events.push(events)
+	return err
+}
+
+// snapshot returns the fields that trigger events; the caller must hold p.mu
+func (p *Player) snapshot() playerSnapshot {
+	return playerSnapshot{
+		status:    p.status,
+		track:     p.track,
+		duration:  p.duration,
+		progress:  p.progress,
+		volume:    p.volume,
+		rate:      p.rate,
+		loop:      p.loop,
+		lineIndex: p.lineIndex,
+		wordIndex: p.wordIndex,
+	}
+}
+
+// changes compares the player with a snapshot and returns the resulting
+// events; the caller must hold p.mu
+func (p *Player) changes(before playerSnapshot) playerEvents {
+	var events playerEvents
+	after := p.snapshot()
+
+	if after.status != before.status || after.track != before.track || after.duration != before.duration ||
+		after.rate != before.rate || after.loop != before.loop || after.lineIndex != before.lineIndex || after.wordIndex != before.wordIndex {
+		state := p.stateAt(p.progress)
+		events.state = &state
+	}
+	if after.progress != before.progress {
+		events.position = &after.progress
+	}
+	if after.volume != before.volume {
+		events.volume = &after.volume
+	}
+	if p.finished != nil {
+		events.finished = p.finished
+		p.finished = nil
+	}
+
+	return events
+}
+
+// monitor refreshes the player at the position interval while the stream is
+// loaded, so that position, lyrics and finished events are delivered
+func (p *Player) monitor(stream *Stream, done chan struct{}) {
+	p.mu.RLock()
+	interval := p.positionInterval
+	p.mu.RUnlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		p.update(func() error {
+			if p.stream == stream {
+				p.refresh()
+			}
+			if p.positionInterval != interval {
+				interval = p.positionInterval
+				ticker.Reset(interval)
+			}
+			return nil
+		})
+	}
+}
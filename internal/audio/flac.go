@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mewkiz/flac"
+)
+
+// flacDecoder decodes FLAC files frame by frame
+type flacDecoder struct {
+	stream  *flac.Stream
+	scale   float32
+	samples []float32
+	pending []float32
+}
+
+func newFLACDecoder(file *os.File) (*flacDecoder, error) {
+	stream, err := flac.NewSeek(file)
+	if err != nil {
+		return nil, err
+	}
+	if stream.Info.NChannels == 0 || stream.Info.SampleRate == 0 {
+		stream.Close()
+		return nil, fmt.Errorf("invalid FLAC stream info")
+	}
+
+	return &flacDecoder{
+		stream: stream,
+		scale:  float32(int64(1) << (stream.Info.BitsPerSample - 1)),
+	}, nil
+}
+
+func (d *flacDecoder) SampleRate() int { return int(d.stream.Info.SampleRate) }
+
+func (d *flacDecoder) Channels() int { return int(d.stream.Info.NChannels) }
+
+func (d *flacDecoder) Length() int64 { return int64(d.stream.Info.NSamples) }
+
+func (d *flacDecoder) Read(buf []float32) (int, error) {
+	if len(d.pending) == 0 {
+		if err := d.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	channels := d.Channels()
+	n := copy(buf[:len(buf)/channels*channels], d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// fill decodes the next FLAC frame into the pending samples
+func (d *flacDecoder) fill() error {
+	frame, err := d.stream.ParseNext()
+	if err != nil {
+		return err
+	}
+
+	channels := d.Channels()
+	samples := d.samples[:0]
+	for i := 0; i < int(frame.BlockSize); i++ {
+		for ch := 0; ch < channels; ch++ {
+			samples = append(samples, float32(frame.Subframes[ch].Samples[i])/d.scale)
+		}
+	}
+
+	d.samples = samples
+	d.pending = samples
+	return nil
+}
+
+// SetPosition moves to the FLAC frame containing the given frame, then skips
+// ahead to the exact frame
+func (d *flacDecoder) SetPosition(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	if length := d.Length(); length > 0 && frame >= length {
+		frame = length - 1
+	}
+
+	start, err := d.stream.Seek(uint64(frame))
+	if err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+
+	d.pending = nil
+	skip := int((frame - int64(start)) * int64(d.Channels()))
+	for skip > 0 {
+		if len(d.pending) == 0 {
+			if err := d.fill(); err != nil {
+				return fmt.Errorf("failed to seek: %w", err)
+			}
+		}
+		n := min(skip, len(d.pending))
+		d.pending = d.pending[n:]
+		skip -= n
+	}
+	return nil
+}
+
+func (d *flacDecoder) Close() error {
+	return d.stream.Close()
+}
@@ -0,0 +1,71 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// mp3FrameSize is the size in bytes of a frame decoded by go-mp3, which always
+// produces 16-bit little-endian stereo samples
+const mp3FrameSize = 4
+
+// mp3Decoder decodes MPEG-1/2 Layer III files
+type mp3Decoder struct {
+	file    *os.File
+	decoder *mp3.Decoder
+	buf     []byte
+}
+
+func newMP3Decoder(file *os.File) (*mp3Decoder, error) {
+	decoder, err := mp3.NewDecoder(file)
+	if err != nil {
+		return nil, err
+	}
+	return &mp3Decoder{file: file, decoder: decoder}, nil
+}
+
+func (d *mp3Decoder) SampleRate() int { return d.decoder.SampleRate() }
+
+func (d *mp3Decoder) Channels() int { return 2 }
+
+func (d *mp3Decoder) Length() int64 { return d.decoder.Length() / mp3FrameSize }
+
+func (d *mp3Decoder) Read(buf []float32) (int, error) {
+	size := len(buf) / 2 * mp3FrameSize
+	if cap(d.buf) < size {
+		d.buf = make([]byte, size)
+	}
+	data := d.buf[:size]
+
+	read, err := io.ReadFull(d.decoder, data)
+	read -= read % mp3FrameSize
+	for i := 0; i < read/2; i++ {
+		buf[i] = float32(int16(binary.LittleEndian.Uint16(data[i*2:]))) / 32768
+	}
+
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && read > 0) {
+		err = nil
+	}
+	return read / 2, err
+}
+
+func (d *mp3Decoder) SetPosition(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	if frame > d.Length() {
+		frame = d.Length()
+	}
+	if _, err := d.decoder.Seek(frame*mp3FrameSize, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+	return nil
+}
+
+func (d *mp3Decoder) Close() error {
+	return d.file.Close()
+}
@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// oggDecoder decodes Ogg Vorbis files
+type oggDecoder struct {
+	file   *os.File
+	reader *oggvorbis.Reader
+}
+
+func newOggDecoder(file *os.File) (*oggDecoder, error) {
+	reader, err := oggvorbis.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return &oggDecoder{file: file, reader: reader}, nil
+}
+
+func (d *oggDecoder) SampleRate() int { return d.reader.SampleRate() }
+
+func (d *oggDecoder) Channels() int { return d.reader.Channels() }
+
+func (d *oggDecoder) Length() int64 { return d.reader.Length() }
+
+func (d *oggDecoder) Read(buf []float32) (int, error) {
+	return d.reader.Read(buf[:len(buf)/d.Channels()*d.Channels()])
+}
+
+func (d *oggDecoder) SetPosition(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	if err := d.reader.SetPosition(frame); err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+	return nil
+}
+
+func (d *oggDecoder) Close() error {
+	return d.file.Close()
+}
@@ -0,0 +1,185 @@
+package audio
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"lrcget-go/internal/database"
+)
+
+// Peaks resolution limits, in peaks per second
+const (
+	MinPeaksResolution = 1
+	MaxPeaksResolution = 1000
+)
+
+// Peaks represents the waveform of an audio file as the minimum and maximum
+// sample of each interval, over all channels
+type Peaks struct {
+	Resolution int       `json:"resolution"`
+	Duration   float64   `json:"duration"`
+	Min        []float32 `json:"min"`
+	Max        []float32 `json:"max"`
+}
+
+// GeneratePeaks decodes the audio of a track and computes resolution peaks per second
+func GeneratePeaks(track *database.PersistentTrack, resolution int) (*Peaks, error) {
+	if resolution < MinPeaksResolution || resolution > MaxPeaksResolution {
+		return nil, fmt.Errorf("peaks resolution must be between %d and %d, got %d",
+			MinPeaksResolution, MaxPeaksResolution, resolution)
+	}
+
+	decoder, err := OpenTrackDecoder(track)
+	if err != nil {
+		return nil, err
+	}
+	defer decoder.Close()
+
+	channels := decoder.Channels()
+	framesPerPeak := float64(decoder.SampleRate()) / float64(resolution)
+	peaks := &Peaks{Resolution: resolution}
+	if length := decoder.Length(); length > 0 {
+		capacity := int(math.Ceil(float64(length) / framesPerPeak))
+		peaks.Min = make([]float32, 0, capacity)
+		peaks.Max = make([]float32, 0, capacity)
+	}
+
+	var frame int64
+	var low, high float32
+	inPeak := 0
+	buf := make([]float32, decodeChunkFrames*channels)
+	for {
+		n, err := decoder.Read(buf)
+		for i := 0; i < n; i += channels {
+			if inPeak == 0 {
+				low, high = buf[i], buf[i]
+			}
+			for _, sample := range buf[i : i+channels] {
+				low = min(low, sample)
+				high = max(high, sample)
+			}
+			inPeak++
+			frame++
+
+			// Peaks end on the frame nearest to their exact boundary, so that
+			// rounding errors do not accumulate over long files
+			if float64(frame) >= math.Round(float64(len(peaks.Min)+1)*framesPerPeak) {
+				peaks.Min = append(peaks.Min, low)
+				peaks.Max = append(peaks.Max, high)
+				inPeak = 0
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode audio: %w", err)
+		}
+	}
+
+	if inPeak > 0 {
+		peaks.Min = append(peaks.Min, low)
+		peaks.Max = append(peaks.Max, high)
+	}
+	peaks.Duration = float64(frame) / float64(decoder.SampleRate())
+
+	return peaks, nil
+}
+
+// PeaksCache stores generated peaks on disk. Entries are keyed by file path
+// and CUE track, modification time, offsets and resolution, so that peaks are
+// regenerated when the audio file or the section of a CUE track changes.
+type PeaksCache struct {
+	dir string
+}
+
+// NewPeaksCache creates a peaks cache in a directory
+func NewPeaksCache(dir string) *PeaksCache {
+	return &PeaksCache{dir: dir}
+}
+
+// Get returns the peaks of a track, generating and caching them if needed
+func (c *PeaksCache) Get(track *database.PersistentTrack, resolution int) (*Peaks, error) {
+	info, err := os.Stat(track.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat audio file: %w", err)
+	}
+
+	key := track.FilePath
+	if track.CueTrack > 0 {
+		key = fmt.Sprintf("%s#%d", track.FilePath, track.CueTrack)
+	}
+	sum := sha1.Sum([]byte(key))
+	entryDir := filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+	version := strconv.FormatInt(info.ModTime().UnixNano(), 10) + sectionKey(track)
+	entryPath := filepath.Join(entryDir, fmt.Sprintf("%s-%d.json", version, resolution))
+
+	if data, err := os.ReadFile(entryPath); err == nil {
+		var peaks Peaks
+		if err := json.Unmarshal(data, &peaks); err == nil {
+			return &peaks, nil
+		}
+	}
+
+	peaks, err := GeneratePeaks(track, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store(entryDir, entryPath, version, peaks); err != nil {
+		fmt.Printf("Failed to cache peaks of %s: %v\n", track.FilePath, err)
+	}
+	return peaks, nil
+}
+
+// sectionKey identifies the section of the file a track plays, in
+// milliseconds, or is empty for a whole file
+func sectionKey(track *database.PersistentTrack) string {
+	if track.StartOffset == nil && track.EndOffset == nil {
+		return ""
+	}
+
+	millis := func(offset *float64) int64 {
+		if offset == nil {
+			return 0
+		}
+		return int64(math.Round(*offset * 1000))
+	}
+	return fmt.Sprintf("_%d_%d", millis(track.StartOffset), millis(track.EndOffset))
+}
+
+// store writes peaks to the cache and removes entries of older versions of the file
+func (c *PeaksCache) store(entryDir, entryPath, version string, peaks *Peaks) error {
+	if err := os.MkdirAll(entryDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if entries, err := os.ReadDir(entryDir); err == nil {
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), version+"-") {
+				os.Remove(filepath.Join(entryDir, entry.Name()))
+			}
+		}
+	}
+
+	data, err := json.Marshal(peaks)
+	if err != nil {
+		return fmt.Errorf("failed to encode peaks: %w", err)
+	}
+
+	// Write to a temporary file first so that readers never see a partial entry
+	tmpPath := entryPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write peaks: %w", err)
+	}
+	return os.Rename(tmpPath, entryPath)
+}
@@ -0,0 +1,129 @@
+package audio
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lrcget-go/internal/database"
+)
+
+func TestGeneratePeaks(t *testing.T) {
+	path := writeTestFile(t, 1.5)
+
+	tests := []struct {
+		name       string
+		resolution int
+		expected   int
+		wantErr    bool
+	}{
+		{"one per second", 1, 2, false},
+		{"ten per second", 10, 15, false},
+		{"hundred per second", 100, 150, false},
+		{"zero", 0, 0, true},
+		{"too many", MaxPeaksResolution + 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peaks, err := GeneratePeaks(&database.PersistentTrack{FilePath: path}, tt.resolution)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GeneratePeaks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(peaks.Min) != tt.expected || len(peaks.Max) != tt.expected {
+				t.Fatalf("GeneratePeaks() = %d min, %d max peaks, expected %d",
+					len(peaks.Min), len(peaks.Max), tt.expected)
+			}
+			if peaks.Duration != 1.5 {
+				t.Errorf("GeneratePeaks() duration = %v, expected 1.5", peaks.Duration)
+			}
+
+			// A 440 Hz sine wave reaches its amplitude within every interval longer than its period
+			for i := range peaks.Min {
+				if peaks.Min[i] > -0.4 || peaks.Max[i] < 0.4 {
+					t.Fatalf("GeneratePeaks() peak %d = [%v, %v], expected about [-0.5, 0.5]",
+						i, peaks.Min[i], peaks.Max[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPeaksCache(t *testing.T) {
+	path := writeTestFile(t, 1)
+	track := &database.PersistentTrack{FilePath: path}
+	dir := t.TempDir()
+	cache := NewPeaksCache(dir)
+
+	entries := func(pattern string) []string {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*", pattern))
+		return matches
+	}
+
+	if _, err := cache.Get(track, 10); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := cache.Get(track, 20); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if n := len(entries("*.json")); n != 2 {
+		t.Fatalf("cache has %d entries, expected 2", n)
+	}
+
+	// A cached entry is returned as is
+	if err := os.WriteFile(entries("*-10.json")[0], []byte(`{"resolution":10,"duration":42}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	peaks, err := cache.Get(track, 10)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if peaks.Duration != 42 {
+		t.Errorf("Get() duration = %v, expected the cached 42", peaks.Duration)
+	}
+
+	// Modifying the file invalidates its entries
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	peaks, err = cache.Get(track, 10)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if peaks.Duration != 1 {
+		t.Errorf("Get() duration = %v, expected 1 after modification", peaks.Duration)
+	}
+	if n := len(entries("*.json")); n != 1 {
+		t.Errorf("cache has %d entries after modification, expected 1", n)
+	}
+
+	// CUE tracks of the file get the peaks of their own section, which are
+	// regenerated when the CUE sheet moves it
+	section := func(start, end float64) *database.PersistentTrack {
+		return &database.PersistentTrack{FilePath: path, CueTrack: 1, StartOffset: &start, EndOffset: &end}
+	}
+	for _, tt := range []struct {
+		track    *database.PersistentTrack
+		expected float64
+	}{
+		{section(0.5, 1), 0.5},
+		{section(0.25, 1), 0.75},
+		{section(0.5, 1), 0.5},
+	} {
+		peaks, err := cache.Get(tt.track, 10)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if math.Abs(peaks.Duration-tt.expected) > 0.01 {
+			t.Errorf("Get() of section %v-%v duration = %v, expected %v",
+				*tt.track.StartOffset, *tt.track.EndOffset, peaks.Duration, tt.expected)
+		}
+	}
+}
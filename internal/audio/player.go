@@ -1,10 +1,13 @@
 package audio
 
 import (
+	"fmt"
+	"math"
 	"sync"
 	"time"
 
 	"lrcget-go/internal/database"
+	"lrcget-go/internal/lyrics"
 )
 
 // PlayerStatus represents the current player status
@@ -23,162 +26,371 @@ type PlayerState struct {
 	Duration float64      `json:"duration"`
 	Volume   float64      `json:"volume"`
 	Track    *database.PersistentTrack `json:"track,omitempty"`
+
+	// Playback rate, and the A-B loop section in seconds when one is set
+	Rate      float64  `json:"rate"`
+	LoopStart *float64 `json:"loop_start,omitempty"`
+	LoopEnd   *float64 `json:"loop_end,omitempty"`
+
+	// Synced lyrics position; indexes are -1 when there is no current line or word
+	LineIndex    int      `json:"line_index"`
+	LineText     string   `json:"line_text"`
+	WordIndex    int      `json:"word_index"`
+	NextLineTime *float64 `json:"next_line_time,omitempty"`
 }
 
 // Player represents an audio player
 type Player struct {
-	mu       sync.RWMutex
-	status   PlayerStatus
-	progress float64
-	duration float64
-	volume   float64
-	track    *database.PersistentTrack
-	startTime time.Time
-	pausedTime time.Duration
+	mu        sync.RWMutex
+	status    PlayerStatus
+	progress  float64
+	duration  float64
+	volume    float64
+	track     *database.PersistentTrack
+	sink      Sink
+	stream    *Stream
+	lyrics    *lyrics.Lyrics
+	lineIndex int
+	wordIndex int
+	rate      float64
+	loop      *[2]float64
+
+	events           *eventDispatcher
+	positionInterval time.Duration
+	monitorDone      chan struct{}
+	finished         *database.PersistentTrack
 }
 
-// NewPlayer creates a new audio player
+// NewPlayer creates a new audio player using the default audio output
 func NewPlayer() (*Player, error) {
-	// Note: In a real implementation, you would initialize the audio system here
-	// For now, we'll create a mock player that tracks state
-	
+	sink, err := newDefaultSink()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize audio output: %w", err)
+	}
+
+	return NewPlayerWithSink(sink)
+}
+
+// NewPlayerWithSink creates a new audio player playing into the given sink
+func NewPlayerWithSink(sink Sink) (*Player, error) {
 	return &Player{
-		status:   Stopped,
-		progress: 0,
-		duration: 0,
-		volume:   1.0,
+		status:    Stopped,
+		progress:  0,
+		duration:  0,
+		volume:    1.0,
+		rate:      1.0,
+		sink:      sink,
+		lineIndex: -1,
+		wordIndex: -1,
+
+		events:           newEventDispatcher(),
+		positionInterval: DefaultPositionInterval,
 	}, nil
 }
 
 // Play starts playing a track
 func (p *Player) Play(track *database.PersistentTrack) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	
+	decoder, err := OpenTrackDecoder(track)
+	if err != nil {
+		return err
+	}
+
+	return p.update(func() error {
+		return p.play(track, decoder)
+	})
+}
+
+// play replaces the current stream with a new one; the caller must hold p.mu
+func (p *Player) play(track *database.PersistentTrack, decoder Decoder) error {
 	// Stop current playback if any
-	p.status = Stopped
-	
+	p.closeStream()
+
+	stream, err := NewStream(decoder, p.sink)
+	if err != nil {
+		decoder.Close()
+		return fmt.Errorf("failed to open audio output: %w", err)
+	}
+	stream.SetVolume(p.volume)
+	if err := stream.SetRate(p.rate); err != nil {
+		stream.Close()
+		return err
+	}
+	if err := stream.Start(); err != nil {
+		stream.Close()
+		return err
+	}
+
 	// Set new track
+	p.stream = stream
 	p.track = track
-	p.duration = track.Duration
+	p.duration = stream.GetDuration()
+	if p.duration == 0 {
+		p.duration = track.Duration
+	}
 	p.progress = 0
-	p.startTime = time.Now()
-	p.pausedTime = 0
 	p.status = Playing
-	
-	// In a real implementation, you would start audio playback here
-	// For now, we'll just update the state
-	
+	p.loop = nil
+	p.lyrics = nil
+	if track.LrcLyrics != nil && lyrics.IsSynced(*track.LrcLyrics) {
+		p.lyrics = lyrics.Parse(*track.LrcLyrics)
+	}
+	p.lineIndex, p.wordIndex = p.lyricsPosition(0)
+
+	p.monitorDone = make(chan struct{})
+	go p.monitor(stream, p.monitorDone)
+
 	return nil
 }
 
 // Pause pauses the current track
-func (p *Player) Pause() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	
-	if p.status == Playing {
-		p.pausedTime += time.Since(p.startTime)
-		p.status = Paused
-	}
+func (p *Player) Pause() error {
+	return p.update(func() error {
+		if p.status == Playing {
+			if err := p.stream.Pause(); err != nil {
+				return err
+			}
+			p.refresh()
+			p.status = Paused
+		}
+		return nil
+	})
 }
 
 // Resume resumes the paused track
-func (p *Player) Resume() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	
-	if p.status == Paused {
-		p.startTime = time.Now()
-		p.status = Playing
-	}
+func (p *Player) Resume() error {
+	return p.update(func() error {
+		if p.status == Paused {
+			if err := p.stream.Resume(); err != nil {
+				return err
+			}
+			p.status = Playing
+		}
+		return nil
+	})
 }
 
 // Stop stops the current track
-func (p *Player) Stop() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	
-	p.status = Stopped
-	p.progress = 0
-	p.pausedTime = 0
+func (p *Player) Stop() error {
+	return p.update(func() error {
+		if p.stream != nil {
+			if err := p.stream.Stop(); err != nil {
+				return err
+			}
+		}
+		p.status = Stopped
+		p.progress = 0
+		p.lineIndex, p.wordIndex = p.lyricsPosition(0)
+		return nil
+	})
 }
 
 // Seek seeks to a specific position in the track
-func (p *Player) Seek(position float64) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	
-	if position < 0 {
-		position = 0
-	}
-	if position > p.duration {
-		position = p.duration
-	}
-	
-	p.progress = position
-	p.startTime = time.Now().Add(-time.Duration(position) * time.Second)
-	p.pausedTime = 0
+func (p *Player) Seek(position float64) error {
+	return p.update(func() error {
+		if position < 0 {
+			position = 0
+		}
+		if position > p.duration {
+			position = p.duration
+		}
+
+		if p.stream != nil {
+			if err := p.stream.Seek(position); err != nil {
+				return err
+			}
+		}
+		p.progress = position
+		p.lineIndex, p.wordIndex = p.lyricsPosition(position)
+		return nil
+	})
+}
+
+// SetLyrics replaces the synced lyrics of the current track, for example after
+// they have been edited during playback
+func (p *Player) SetLyrics(lrc string) {
+	p.update(func() error {
+		p.lyrics = nil
+		if lyrics.IsSynced(lrc) {
+			p.lyrics = lyrics.Parse(lrc)
+		}
+		p.lineIndex, p.wordIndex = p.lyricsPosition(p.progress)
+		return nil
+	})
 }
 
 // SetVolume sets the player volume
-func (p *Player) SetVolume(volume float64) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	
-	if volume < 0 {
-		volume = 0
-	}
-	if volume > 1 {
-		volume = 1
-	}
-	
-	p.volume = volume
+func (p *Player) SetVolume(volume float64) error {
+	return p.update(func() error {
+		if volume < 0 {
+			volume = 0
+		}
+		if volume > 1 {
+			volume = 1
+		}
+
+		p.volume = volume
+		if p.stream != nil {
+			return p.stream.SetVolume(volume)
+		}
+		return nil
+	})
+}
+
+// SetRate sets the playback rate, clamped between MinPlaybackRate and
+// MaxPlaybackRate. The pitch is preserved, and the rate is kept for the next tracks.
+func (p *Player) SetRate(rate float64) error {
+	return p.update(func() error {
+		p.rate = math.Max(MinPlaybackRate, math.Min(MaxPlaybackRate, rate))
+		if p.stream != nil {
+			return p.stream.SetRate(p.rate)
+		}
+		return nil
+	})
+}
+
+// GetRate returns the playback rate
+func (p *Player) GetRate() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.rate
+}
+
+// SetLoop repeats the section of the current track between start and end, in
+// seconds, until the loop is cleared or another track is played
+func (p *Player) SetLoop(start, end float64) error {
+	return p.update(func() error {
+		if p.stream == nil {
+			return fmt.Errorf("no track loaded")
+		}
+		if err := p.stream.SetLoop(start, end); err != nil {
+			return err
+		}
+
+		start, end, _ = p.stream.GetLoop()
+		p.loop = &[2]float64{start, end}
+		p.refresh()
+		return nil
+	})
+}
+
+// ClearLoop stops repeating the A-B loop section
+func (p *Player) ClearLoop() error {
+	return p.update(func() error {
+		p.loop = nil
+		if p.stream != nil {
+			return p.stream.ClearLoop()
+		}
+		return nil
+	})
 }
 
 // GetState returns the current player state
 func (p *Player) GetState() PlayerState {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	
+
 	// Calculate current progress
-	var currentProgress float64
+	currentProgress := p.progress
 	if p.status == Playing {
-		elapsed := time.Since(p.startTime) + p.pausedTime
-		currentProgress = elapsed.Seconds()
-		if currentProgress > p.duration {
-			currentProgress = p.duration
-		}
-	} else {
-		currentProgress = p.progress
+		currentProgress = math.Min(p.stream.GetPosition(), p.duration)
 	}
-	
-	return PlayerState{
+
+	return p.stateAt(currentProgress)
+}
+
+// stateAt returns the player state at a playback position; the caller must hold p.mu
+func (p *Player) stateAt(currentProgress float64) PlayerState {
+	state := PlayerState{
 		Status:   p.status,
 		Progress: currentProgress,
 		Duration: p.duration,
 		Volume:   p.volume,
 		Track:    p.track,
+		Rate:     p.rate,
+	}
+	if p.loop != nil {
+		state.LoopStart, state.LoopEnd = &p.loop[0], &p.loop[1]
+	}
+
+	state.LineIndex, state.WordIndex = p.lyricsPosition(currentProgress)
+	if p.lyrics != nil {
+		if state.LineIndex >= 0 {
+			state.LineText = p.lyrics.Lines[state.LineIndex].Text
+		}
+		if next := state.LineIndex + 1; next < len(p.lyrics.Lines) {
+			state.NextLineTime = &p.lyrics.Lines[next].Time
+		}
 	}
+
+	return state
 }
 
-// UpdateState updates the player state (called periodically)
+// UpdateState updates the player state. It is called at the position interval
+// during playback, so calling it is only needed to refresh the state immediately.
 func (p *Player) UpdateState() {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	
+	p.update(func() error {
+		p.refresh()
+		return nil
+	})
+}
+
+// refresh updates the position and detects the end of the track; the caller must hold p.mu
+func (p *Player) refresh() {
 	if p.status == Playing {
-		elapsed := time.Since(p.startTime) + p.pausedTime
-		p.progress = elapsed.Seconds()
-		
-		// Check if track has finished
-		if p.progress >= p.duration {
+		p.progress = math.Min(p.stream.GetPosition(), p.duration)
+
+		// Check if track has finished or playback failed
+		if p.stream.Finished() || p.stream.Err() != nil {
+			if err := p.stream.Err(); err != nil {
+				fmt.Printf("Playback of %s stopped: %v\n", p.track.FilePath, err)
+			} else {
+				p.finished = p.track
+			}
 			p.status = Stopped
 			p.progress = 0
 		}
+
+		p.lineIndex, p.wordIndex = p.lyricsPosition(p.progress)
+	}
+}
+
+// Close stops playback and releases the audio output
+func (p *Player) Close() error {
+	err := p.update(func() error {
+		p.closeStream()
+		p.status = Stopped
+		return p.sink.Close()
+	})
+
+	p.events.close()
+	return err
+}
+
+// GetLyricsPosition returns the indexes of the current synced lyrics line and
+// word as of the last state update, or -1 when there is none
+func (p *Player) GetLyricsPosition() (int, int) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.lineIndex, p.wordIndex
+}
+
+// closeStream stops and closes the current stream; the caller must hold p.mu
+func (p *Player) closeStream() {
+	if p.stream != nil {
+		close(p.monitorDone)
+		p.stream.Close()
+		p.stream = nil
 	}
 }
 
+// lyricsPosition finds the synced lyrics line and word at a position in seconds
+func (p *Player) lyricsPosition(position float64) (int, int) {
+	if p.lyrics == nil {
+		return -1, -1
+	}
+	return p.lyrics.Position(position)
+}
+
 // IsPlaying returns true if the player is currently playing
 func (p *Player) IsPlaying() bool {
 	p.mu.RLock()
@@ -214,6 +426,11 @@ func (p *Player) GetProgress() float64 {
 	return p.progress
 }
 
+// GetPosition returns the position being heard in seconds
+func (p *Player) GetPosition() float64 {
+	return p.GetState().Progress
+}
+
 // GetDuration returns the duration of the current track
 func (p *Player) GetDuration() float64 {
 	p.mu.RLock()
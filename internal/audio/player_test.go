@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"lrcget-go/internal/database"
+)
+
+func TestPlayerEvents(t *testing.T) {
+	player, err := NewPlayerWithSink(NewNullSink(true))
+	if err != nil {
+		t.Fatalf("NewPlayerWithSink() error = %v", err)
+	}
+	defer player.Close()
+
+	states := make(chan PlayerState, 100)
+	positions := make(chan float64, 1000)
+	volumes := make(chan float64, 10)
+	finished := make(chan *database.PersistentTrack, 1)
+
+	player.SetPositionInterval(20 * time.Millisecond)
+	player.OnStateChange(func(state PlayerState) { states <- state })
+	player.OnPositionChange(func(position float64) { positions <- position })
+	player.OnVolumeChange(func(volume float64) { volumes <- volume })
+	player.OnTrackFinished(func(track *database.PersistentTrack) { finished <- track })
+
+	track := &database.PersistentTrack{FilePath: writeTestFile(t, 0.3)}
+	if err := player.Play(track); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if err := player.SetVolume(0.5); err != nil {
+		t.Fatalf("SetVolume() error = %v", err)
+	}
+
+	select {
+	case done := <-finished:
+		if done != track {
+			t.Errorf("OnTrackFinished() track = %v, expected %v", done, track)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnTrackFinished() was not called")
+	}
+
+	// The finished event is delivered after the state and position events of the same change
+	var statuses []PlayerStatus
+	for len(states) > 0 {
+		statuses = append(statuses, (<-states).Status)
+	}
+	if len(statuses) != 2 || statuses[0] != Playing || statuses[1] != Stopped {
+		t.Errorf("OnStateChange() statuses = %v, expected [playing stopped]", statuses)
+	}
+
+	if len(positions) < 5 {
+		t.Errorf("OnPositionChange() called %d times, expected at least 5", len(positions))
+	}
+
+	if len(volumes) != 1 || <-volumes != 0.5 {
+		t.Errorf("OnVolumeChange() expected a single change to 0.5")
+	}
+}
+
+func TestPlayerRateAndLoop(t *testing.T) {
+	player, err := NewPlayerWithSink(NewNullSink(true))
+	if err != nil {
+		t.Fatalf("NewPlayerWithSink() error = %v", err)
+	}
+	defer player.Close()
+
+	if err := player.SetLoop(0, 1); err == nil {
+		t.Error("SetLoop() without a track succeeded, expected an error")
+	}
+
+	// The rate is kept for the next track
+	player.SetRate(2)
+	if err := player.Play(&database.PersistentTrack{FilePath: writeTestFile(t, 2)}); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if state := player.GetState(); state.Rate != MaxPlaybackRate {
+		t.Errorf("GetState() rate = %v, expected %v", state.Rate, MaxPlaybackRate)
+	}
+
+	if err := player.SetLoop(0.5, 0.8); err != nil {
+		t.Fatalf("SetLoop() error = %v", err)
+	}
+	state := player.GetState()
+	if state.LoopStart == nil || state.LoopEnd == nil || *state.LoopStart != 0.5 || *state.LoopEnd != 0.8 {
+		t.Fatalf("GetState() loop = %v-%v, expected 0.5-0.8", state.LoopStart, state.LoopEnd)
+	}
+	if state.Progress < 0.5 || state.Progress > 0.8 {
+		t.Errorf("GetState() progress = %v, expected within the loop", state.Progress)
+	}
+
+	player.ClearLoop()
+	if state := player.GetState(); state.LoopStart != nil || state.LoopEnd != nil {
+		t.Errorf("GetState() loop after ClearLoop() = %v-%v, expected none", state.LoopStart, state.LoopEnd)
+	}
+}
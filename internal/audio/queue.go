@@ -0,0 +1,294 @@
+package audio
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"lrcget-go/internal/database"
+)
+
+// Repeat modes
+const (
+	RepeatOff = "off"
+	RepeatOne = "one"
+	RepeatAll = "all"
+)
+
+// QueueState represents the playback queue in play order
+type QueueState struct {
+	Tracks       []*database.PersistentTrack `json:"tracks"`
+	CurrentIndex int                         `json:"current_index"`
+	Repeat       string                      `json:"repeat"`
+	Shuffle      bool                        `json:"shuffle"`
+}
+
+// Queue is an ordered list of tracks to play. Tracks keep the order they were
+// queued in, and a separate play order is shuffled when shuffle is enabled, so
+// that disabling shuffle restores the original order.
+type Queue struct {
+	mu      sync.Mutex
+	tracks  []*database.PersistentTrack
+	order   []int
+	current int
+	repeat  string
+	shuffle bool
+	rand    *rand.Rand
+}
+
+// NewQueue creates an empty queue
+func NewQueue() *Queue {
+	return &Queue{current: -1, repeat: RepeatOff, rand: rand.New(rand.NewSource(rand.Int63()))}
+}
+
+// Set replaces the queue with tracks and makes the track at index start
+// current. It returns the current track, or nil if tracks is empty.
+func (q *Queue) Set(tracks []*database.PersistentTrack, start int) *database.PersistentTrack {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tracks = append([]*database.PersistentTrack(nil), tracks...)
+	q.order = make([]int, len(tracks))
+	for i := range q.order {
+		q.order[i] = i
+	}
+
+	q.current = -1
+	if start >= 0 && start < len(tracks) {
+		q.current = start
+	}
+	if q.shuffle {
+		q.shuffleOrder()
+	}
+	return q.currentTrack()
+}
+
+// Enqueue adds tracks to the end of the queue
+func (q *Queue) Enqueue(tracks ...*database.PersistentTrack) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, track := range tracks {
+		q.order = append(q.order, len(q.tracks))
+		q.tracks = append(q.tracks, track)
+	}
+}
+
+// Clear removes all tracks from the queue
+func (q *Queue) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tracks = nil
+	q.order = nil
+	q.current = -1
+}
+
+// Current returns the current track, or nil if there is none
+func (q *Queue) Current() *database.PersistentTrack {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.currentTrack()
+}
+
+// Next moves to the next track and returns it, or returns nil at the end of the
+// queue. When auto is true the move is caused by the current track finishing,
+// so repeat one plays the same track again.
+func (q *Queue) Next(auto bool) *database.PersistentTrack {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.order) == 0 {
+		return nil
+	}
+
+	switch {
+	case auto && q.repeat == RepeatOne && q.current >= 0:
+	case q.current+1 < len(q.order):
+		q.current++
+	case q.repeat != RepeatOff:
+		q.current = 0
+	default:
+		return nil
+	}
+	return q.currentTrack()
+}
+
+// Previous moves to the previous track and returns it. At the start of the
+// queue it wraps around when repeat is enabled, and stays on the first track
+// otherwise.
+func (q *Queue) Previous() *database.PersistentTrack {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.order) == 0 {
+		return nil
+	}
+
+	switch {
+	case q.current > 0:
+		q.current--
+	case q.repeat != RepeatOff:
+		q.current = len(q.order) - 1
+	default:
+		q.current = 0
+	}
+	return q.currentTrack()
+}
+
+// Jump makes the track at an index of the play order current and returns it
+func (q *Queue) Jump(index int) (*database.PersistentTrack, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if index < 0 || index >= len(q.order) {
+		return nil, fmt.Errorf("queue index %d out of range", index)
+	}
+	q.current = index
+	return q.currentTrack(), nil
+}
+
+// SetRepeat sets the repeat mode
+func (q *Queue) SetRepeat(mode string) error {
+	switch mode {
+	case RepeatOff, RepeatOne, RepeatAll:
+	default:
+		return fmt.Errorf("invalid repeat mode: %s", mode)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.repeat = mode
+	return nil
+}
+
+// SetShuffle enables or disables shuffle. Enabling it keeps the current track
+// and shuffles the others after it; disabling it restores the queued order.
+func (q *Queue) SetShuffle(enabled bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if enabled == q.shuffle {
+		return
+	}
+	q.shuffle = enabled
+
+	if enabled {
+		q.shuffleOrder()
+		return
+	}
+
+	if q.current >= 0 {
+		q.current = q.order[q.current]
+	}
+	for i := range q.order {
+		q.order[i] = i
+	}
+}
+
+// State returns the queue in play order
+func (q *Queue) State() QueueState {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tracks := make([]*database.PersistentTrack, len(q.order))
+	for i, index := range q.order {
+		tracks[i] = q.tracks[index]
+	}
+
+	return QueueState{
+		Tracks:       tracks,
+		CurrentIndex: q.current,
+		Repeat:       q.repeat,
+		Shuffle:      q.shuffle,
+	}
+}
+
+// Persistent returns the queue in its saved form
+func (q *Queue) Persistent() *database.PersistentQueue {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	saved := &database.PersistentQueue{
+		Entries:    make([]database.PersistentQueueEntry, len(q.tracks)),
+		RepeatMode: q.repeat,
+		Shuffle:    q.shuffle,
+	}
+	for playOrder, index := range q.order {
+		saved.Entries[index] = database.PersistentQueueEntry{
+			Position:  index,
+			TrackID:   q.tracks[index].ID,
+			PlayOrder: playOrder,
+		}
+	}
+	if q.current >= 0 {
+		position := q.order[q.current]
+		saved.CurrentPosition = &position
+	}
+
+	return saved
+}
+
+// Restore replaces the queue with a saved one. Entries whose track is missing
+// from tracks, for example because it has been removed from the library, are
+// dropped.
+func (q *Queue) Restore(saved *database.PersistentQueue, tracks map[int64]*database.PersistentTrack) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]database.PersistentQueueEntry, 0, len(saved.Entries))
+	for _, entry := range saved.Entries {
+		if tracks[entry.TrackID] != nil {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Position < entries[j].Position })
+
+	q.tracks = make([]*database.PersistentTrack, len(entries))
+	q.order = make([]int, len(entries))
+	q.current = -1
+	for i, entry := range entries {
+		q.tracks[i] = tracks[entry.TrackID]
+		q.order[i] = i
+	}
+	sort.SliceStable(q.order, func(i, j int) bool {
+		return entries[q.order[i]].PlayOrder < entries[q.order[j]].PlayOrder
+	})
+
+	for i, index := range q.order {
+		if saved.CurrentPosition != nil && entries[index].Position == *saved.CurrentPosition {
+			q.current = i
+		}
+	}
+
+	q.repeat = RepeatOff
+	if saved.RepeatMode == RepeatOne || saved.RepeatMode == RepeatAll {
+		q.repeat = saved.RepeatMode
+	}
+	q.shuffle = saved.Shuffle
+}
+
+// shuffleOrder shuffles the play order, moving the current track to the front;
+// the caller must hold q.mu
+func (q *Queue) shuffleOrder() {
+	start := 0
+	if q.current >= 0 {
+		q.order[0], q.order[q.current] = q.order[q.current], q.order[0]
+		q.current = 0
+		start = 1
+	}
+
+	rest := q.order[start:]
+	q.rand.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+}
+
+// currentTrack returns the current track; the caller must hold q.mu
+func (q *Queue) currentTrack() *database.PersistentTrack {
+	if q.current < 0 || q.current >= len(q.order) {
+		return nil
+	}
+	return q.tracks[q.order[q.current]]
+}
@@ -0,0 +1,161 @@
+package audio
+
+import (
+	"testing"
+
+	"lrcget-go/internal/database"
+)
+
+// testTracks returns tracks with IDs from 1 to n
+func testTracks(n int) []*database.PersistentTrack {
+	tracks := make([]*database.PersistentTrack, n)
+	for i := range tracks {
+		tracks[i] = &database.PersistentTrack{ID: int64(i + 1)}
+	}
+	return tracks
+}
+
+// trackID returns the ID of a track, or 0 for nil
+func trackID(track *database.PersistentTrack) int64 {
+	if track == nil {
+		return 0
+	}
+	return track.ID
+}
+
+func TestQueueNavigation(t *testing.T) {
+	tests := []struct {
+		name     string
+		repeat   string
+		steps    []string
+		expected []int64
+	}{
+		{
+			name:     "stops at the end",
+			repeat:   RepeatOff,
+			steps:    []string{"auto", "auto", "auto"},
+			expected: []int64{2, 3, 0},
+		},
+		{
+			name:     "repeat all wraps around",
+			repeat:   RepeatAll,
+			steps:    []string{"auto", "auto", "auto", "previous"},
+			expected: []int64{2, 3, 1, 3},
+		},
+		{
+			name:     "repeat one replays on auto advance only",
+			repeat:   RepeatOne,
+			steps:    []string{"auto", "next", "auto"},
+			expected: []int64{1, 2, 2},
+		},
+		{
+			name:     "previous stays on the first track",
+			repeat:   RepeatOff,
+			steps:    []string{"previous", "next", "previous"},
+			expected: []int64{1, 2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := NewQueue()
+			if err := queue.SetRepeat(tt.repeat); err != nil {
+				t.Fatalf("SetRepeat() error = %v", err)
+			}
+			queue.Set(testTracks(3), 0)
+
+			for i, step := range tt.steps {
+				var track *database.PersistentTrack
+				switch step {
+				case "auto":
+					track = queue.Next(true)
+				case "next":
+					track = queue.Next(false)
+				case "previous":
+					track = queue.Previous()
+				}
+				if trackID(track) != tt.expected[i] {
+					t.Errorf("step %d (%s) = track %d, expected %d", i, step, trackID(track), tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQueueShuffle(t *testing.T) {
+	queue := NewQueue()
+	queue.Set(testTracks(20), 5)
+
+	queue.SetShuffle(true)
+	state := queue.State()
+	if state.CurrentIndex != 0 || trackID(state.Tracks[0]) != 6 {
+		t.Errorf("SetShuffle(true) current = %d (track %d), expected the current track first", state.CurrentIndex, trackID(state.Tracks[0]))
+	}
+
+	seen := make(map[int64]bool)
+	for _, track := range state.Tracks {
+		seen[track.ID] = true
+	}
+	if len(seen) != 20 {
+		t.Errorf("SetShuffle(true) kept %d distinct tracks, expected 20", len(seen))
+	}
+
+	queue.Next(false)
+	current := queue.Current()
+	queue.SetShuffle(false)
+	state = queue.State()
+	for i, track := range state.Tracks {
+		if track.ID != int64(i+1) {
+			t.Fatalf("SetShuffle(false) track %d = %d, expected the queued order", i, track.ID)
+		}
+	}
+	if queue.Current() != current {
+		t.Errorf("SetShuffle(false) changed the current track")
+	}
+}
+
+func TestQueuePersistent(t *testing.T) {
+	tracks := testTracks(5)
+	queue := NewQueue()
+	queue.SetRepeat(RepeatAll)
+	queue.SetShuffle(true)
+	queue.Set(tracks, 2)
+
+	saved := queue.Persistent()
+
+	// Track 4 has been removed from the library since
+	library := make(map[int64]*database.PersistentTrack)
+	for _, track := range tracks {
+		if track.ID != 4 {
+			library[track.ID] = track
+		}
+	}
+
+	expected := queue.State()
+	restored := NewQueue()
+	restored.Restore(saved, library)
+	state := restored.State()
+
+	if state.Repeat != RepeatAll || !state.Shuffle {
+		t.Errorf("Restore() repeat = %s, shuffle = %v, expected all and true", state.Repeat, state.Shuffle)
+	}
+
+	var order []int64
+	for _, track := range expected.Tracks {
+		if track.ID != 4 {
+			order = append(order, track.ID)
+		}
+	}
+	if len(state.Tracks) != len(order) {
+		t.Fatalf("Restore() returned %d tracks, expected %d", len(state.Tracks), len(order))
+	}
+	for i, track := range state.Tracks {
+		if track.ID != order[i] {
+			t.Errorf("Restore() track %d = %d, expected %d", i, track.ID, order[i])
+		}
+	}
+
+	if current := trackID(restored.Current()); current != 3 {
+		t.Errorf("Restore() current = %d, expected 3", current)
+	}
+}
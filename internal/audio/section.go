@@ -0,0 +1,105 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"lrcget-go/internal/database"
+)
+
+// sectionDecoder reads a section of another decoder, such as one track of an
+// album stored as a single file with a CUE sheet. Frames are counted from the
+// start of the section.
+type sectionDecoder struct {
+	Decoder
+	start int64 // first frame of the section
+	end   int64 // frame after the section, or 0 to read to the end of the file
+	pos   int64 // current frame, relative to the start of the file
+}
+
+// newSectionDecoder restricts decoder to the frames between start and end
+// seconds. A zero end reads to the end of the file.
+func newSectionDecoder(decoder Decoder, start, end float64) (*sectionDecoder, error) {
+	rate := float64(decoder.SampleRate())
+	s := &sectionDecoder{
+		Decoder: decoder,
+		start:   int64(math.Round(start * rate)),
+		end:     int64(math.Round(end * rate)),
+	}
+	if s.end > 0 && s.end <= s.start {
+		return nil, fmt.Errorf("invalid section from %.3fs to %.3fs", start, end)
+	}
+	if err := s.SetPosition(0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Length returns the number of frames of the section, or 0 if it is unknown
+func (s *sectionDecoder) Length() int64 {
+	end := s.end
+	if length := s.Decoder.Length(); end == 0 || (length > 0 && length < end) {
+		end = length
+	}
+	if end <= s.start {
+		return 0
+	}
+	return end - s.start
+}
+
+// Read reads samples up to the end of the section
+func (s *sectionDecoder) Read(buf []float32) (int, error) {
+	channels := s.Channels()
+	if s.end > 0 {
+		left := s.end - s.pos
+		if left <= 0 {
+			return 0, io.EOF
+		}
+		if int64(len(buf)/channels) > left {
+			buf = buf[:left*int64(channels)]
+		}
+	}
+
+	n, err := s.Decoder.Read(buf)
+	s.pos += int64(n / channels)
+	if err == nil && s.end > 0 && s.pos >= s.end {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// SetPosition moves to the given frame of the section
+func (s *sectionDecoder) SetPosition(frame int64) error {
+	if err := s.Decoder.SetPosition(s.start + frame); err != nil {
+		return err
+	}
+	s.pos = s.start + frame
+	return nil
+}
+
+// OpenTrackDecoder opens the audio of a track. Tracks of a CUE sheet are
+// restricted to their section of the file.
+func OpenTrackDecoder(track *database.PersistentTrack) (Decoder, error) {
+	decoder, err := OpenDecoder(track.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if track.StartOffset == nil && track.EndOffset == nil {
+		return decoder, nil
+	}
+
+	var start, end float64
+	if track.StartOffset != nil {
+		start = *track.StartOffset
+	}
+	if track.EndOffset != nil {
+		end = *track.EndOffset
+	}
+	section, err := newSectionDecoder(decoder, start, end)
+	if err != nil {
+		decoder.Close()
+		return nil, fmt.Errorf("failed to seek in %s: %w", track.FilePath, err)
+	}
+	return section, nil
+}
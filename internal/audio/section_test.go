@@ -0,0 +1,57 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"lrcget-go/internal/database"
+)
+
+func TestDecodeTrack(t *testing.T) {
+	path := writeTestFile(t, 2)
+	offset := func(seconds float64) *float64 { return &seconds }
+
+	tests := []struct {
+		name     string
+		start    *float64
+		end      *float64
+		first    int
+		expected int
+		wantErr  bool
+	}{
+		{"whole file", nil, nil, 0, 16000, false},
+		{"middle track", offset(0.5), offset(1.25), 4000, 6000, false},
+		{"last track", offset(1.5), nil, 12000, 4000, false},
+		{"empty section", offset(1), offset(1), 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			track := &database.PersistentTrack{FilePath: path, StartOffset: tt.start, EndOffset: tt.end}
+			pcm, err := DecodeTrack(track)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(pcm.Samples) != tt.expected {
+				t.Fatalf("DecodeTrack() = %d samples, expected %d", len(pcm.Samples), tt.expected)
+			}
+			expected := 0.5 * math.Sin(2*math.Pi*440*float64(tt.first)/8000)
+			if math.Abs(float64(pcm.Samples[0])-expected) > 1.0/16384 {
+				t.Errorf("DecodeTrack() first sample = %v, expected %v", pcm.Samples[0], expected)
+			}
+
+			decoder, err := OpenTrackDecoder(track)
+			if err != nil {
+				t.Fatalf("OpenTrackDecoder() error = %v", err)
+			}
+			defer decoder.Close()
+			if decoder.Length() != int64(tt.expected) {
+				t.Errorf("Length() = %d, expected %d", decoder.Length(), tt.expected)
+			}
+		})
+	}
+}
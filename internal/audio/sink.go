@@ -0,0 +1,362 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// nullSinkBuffer is how far ahead of real time a realtime null sink accepts samples
+const nullSinkBuffer = 50 * time.Millisecond
+
+// Format describes interleaved PCM audio
+type Format struct {
+	SampleRate int `json:"sample_rate"`
+	Channels   int `json:"channels"`
+}
+
+// Sink is an audio output consuming interleaved float32 samples. Implementations
+// must allow Flush, Pause, Resume and Buffered to be called while Write blocks.
+type Sink interface {
+	// Open prepares the sink for audio in the given format and returns the
+	// format the sink actually accepts
+	Open(format Format) (Format, error)
+	// Write queues samples for output, blocking while the sink's buffer is full
+	Write(samples []float32) error
+	// Flush drops samples that have been written but not played yet
+	Flush()
+	// Pause suspends output without dropping buffered samples
+	Pause()
+	// Resume continues output after Pause
+	Resume()
+	// Buffered returns how much written audio has not been played yet
+	Buffered() time.Duration
+	// Close releases the output
+	Close() error
+}
+
+// NullSink discards audio. A realtime null sink consumes samples at the speed
+// they would be played, so that playback timing behaves as with a sound card.
+type NullSink struct {
+	mu       sync.Mutex
+	realtime bool
+	format   Format
+	frames   int64
+	playedAt time.Time
+	pausedAt time.Time
+}
+
+// NewNullSink creates a sink that discards audio
+func NewNullSink(realtime bool) *NullSink {
+	return &NullSink{realtime: realtime}
+}
+
+func (s *NullSink) Open(format Format) (Format, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.format = format
+	s.frames = 0
+	s.playedAt = time.Time{}
+	return format, nil
+}
+
+func (s *NullSink) Write(samples []float32) error {
+	s.mu.Lock()
+	s.frames += int64(len(samples) / s.format.Channels)
+	if !s.realtime {
+		s.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	if s.playedAt.Before(now) && s.pausedAt.IsZero() {
+		s.playedAt = now
+	}
+	s.playedAt = s.playedAt.Add(durationOf(len(samples), s.format))
+	s.mu.Unlock()
+
+	for s.Buffered() > nullSinkBuffer {
+		time.Sleep(s.Buffered() - nullSinkBuffer)
+	}
+	return nil
+}
+
+func (s *NullSink) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playedAt = time.Time{}
+	if !s.pausedAt.IsZero() {
+		s.pausedAt = time.Now()
+	}
+}
+
+func (s *NullSink) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pausedAt.IsZero() {
+		s.pausedAt = time.Now()
+	}
+}
+
+func (s *NullSink) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.pausedAt.IsZero() {
+		if s.playedAt.After(s.pausedAt) {
+			s.playedAt = s.playedAt.Add(time.Since(s.pausedAt))
+		}
+		s.pausedAt = time.Time{}
+	}
+}
+
+func (s *NullSink) Buffered() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if !s.pausedAt.IsZero() {
+		now = s.pausedAt
+	}
+	if buffered := s.playedAt.Sub(now); buffered > 0 {
+		return buffered
+	}
+	return 0
+}
+
+// Frames returns the number of frames written since the sink was opened
+func (s *NullSink) Frames() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.frames
+}
+
+func (s *NullSink) Close() error {
+	return nil
+}
+
+// FileSink writes audio to a 16-bit PCM WAVE file as fast as it is produced
+type FileSink struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	format Format
+	size   int64
+	buf    []byte
+}
+
+// NewFileSink creates a sink that writes audio to a WAVE file
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Open(format Format) (Format, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		if err := s.finish(); err != nil {
+			return Format{}, err
+		}
+	}
+
+	file, err := os.Create(s.path)
+	if err != nil {
+		return Format{}, fmt.Errorf("failed to create audio file: %w", err)
+	}
+	s.file = file
+	s.format = format
+	s.size = 0
+
+	if err := s.writeHeader(); err != nil {
+		return Format{}, err
+	}
+	return format, nil
+}
+
+func (s *FileSink) Write(samples []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("audio file is not open")
+	}
+
+	size := len(samples) * 2
+	if cap(s.buf) < size {
+		s.buf = make([]byte, size)
+	}
+	data := s.buf[:size]
+	for i, sample := range samples {
+		v := math.Max(-1, math.Min(1, float64(sample)))
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(int16(math.Round(v*32767))))
+	}
+
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write audio file: %w", err)
+	}
+	s.size += int64(size)
+	return nil
+}
+
+func (s *FileSink) Flush() {}
+
+func (s *FileSink) Pause() {}
+
+func (s *FileSink) Resume() {}
+
+func (s *FileSink) Buffered() time.Duration {
+	return 0
+}
+
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	return s.finish()
+}
+
+// finish writes the final sizes to the header and closes the file
+func (s *FileSink) finish() error {
+	defer func() {
+		s.file.Close()
+		s.file = nil
+	}()
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to finish audio file: %w", err)
+	}
+	return s.writeHeader()
+}
+
+// writeHeader writes a WAVE header for the current format and data size
+func (s *FileSink) writeHeader() error {
+	blockAlign := s.format.Channels * 2
+
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+s.size))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], wavFormatPCM)
+	binary.LittleEndian.PutUint16(header[22:], uint16(s.format.Channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(s.format.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(s.format.SampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(header[32:], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(s.size))
+
+	if _, err := s.file.Write(header); err != nil {
+		return fmt.Errorf("failed to write audio file header: %w", err)
+	}
+	return nil
+}
+
+// durationOf returns how long it takes to play a number of interleaved samples
+func durationOf(samples int, format Format) time.Duration {
+	frames := samples / format.Channels
+	return time.Duration(frames) * time.Second / time.Duration(format.SampleRate)
+}
+
+// converter converts interleaved samples between channel counts and sample rates,
+// using linear interpolation for resampling
+type converter struct {
+	from, to Format
+	pos      float64
+	prev     []float32
+	mapped   []float32
+	out      []float32
+}
+
+func newConverter(from, to Format) *converter {
+	return &converter{from: from, to: to}
+}
+
+// reset forgets the previous samples, for example after a seek
+func (c *converter) reset() {
+	c.pos = 0
+	c.prev = nil
+}
+
+// convert converts samples; the result is only valid until the next call
+func (c *converter) convert(samples []float32) []float32 {
+	if c.from == c.to {
+		return samples
+	}
+
+	mapped := c.mapChannels(samples)
+	if c.from.SampleRate == c.to.SampleRate {
+		return mapped
+	}
+
+	channels := c.to.Channels
+	frames := len(mapped) / channels
+	step := float64(c.from.SampleRate) / float64(c.to.SampleRate)
+	frame := func(i int) []float32 {
+		if i < 0 {
+			return c.prev
+		}
+		return mapped[i*channels : (i+1)*channels]
+	}
+
+	c.out = c.out[:0]
+	if c.prev == nil && c.pos < 0 {
+		c.pos = 0
+	}
+	for c.pos < float64(frames-1) {
+		i := int(math.Floor(c.pos))
+		frac := float32(c.pos - float64(i))
+		a, b := frame(i), frame(i+1)
+		for ch := 0; ch < channels; ch++ {
+			c.out = append(c.out, a[ch]+(b[ch]-a[ch])*frac)
+		}
+		c.pos += step
+	}
+
+	if frames > 0 {
+		c.pos -= float64(frames)
+		c.prev = append(c.prev[:0], frame(frames-1)...)
+	}
+	return c.out
+}
+
+// mapChannels converts samples to the target channel count. Mono is duplicated
+// to every channel, mixing down to mono averages all channels, and otherwise
+// the first channels are kept.
+func (c *converter) mapChannels(samples []float32) []float32 {
+	in, out := c.from.Channels, c.to.Channels
+	if in == out {
+		return samples
+	}
+
+	frames := len(samples) / in
+	c.mapped = c.mapped[:0]
+	for f := 0; f < frames; f++ {
+		frame := samples[f*in : (f+1)*in]
+		switch {
+		case in == 1:
+			for ch := 0; ch < out; ch++ {
+				c.mapped = append(c.mapped, frame[0])
+			}
+		case out == 1:
+			var sum float32
+			for _, v := range frame {
+				sum += v
+			}
+			c.mapped = append(c.mapped, sum/float32(in))
+		default:
+			for ch := 0; ch < out; ch++ {
+				c.mapped = append(c.mapped, frame[ch%in])
+			}
+		}
+	}
+	return c.mapped
+}
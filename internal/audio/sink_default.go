@@ -0,0 +1,9 @@
+//go:build !desktop
+
+package audio
+
+// newDefaultSink returns the sink used by NewPlayer. Builds without the desktop
+// tag have no sound card access, so audio is played into a realtime null sink.
+func newDefaultSink() (Sink, error) {
+	return NewNullSink(true), nil
+}
@@ -0,0 +1,164 @@
+//go:build desktop
+
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// deviceSinkBuffer is how much audio the device sink queues ahead of the sound card
+const deviceSinkBuffer = 100 * time.Millisecond
+
+var (
+	// The sound card context can only be created once per process, so its
+	// format is fixed by the first track played
+	deviceOnce    sync.Once
+	deviceContext *oto.Context
+	deviceFormat  Format
+	deviceErr     error
+)
+
+// deviceSink plays audio on the default sound card
+type deviceSink struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	format   Format
+	queue    []float32
+	capacity int
+	player   *oto.Player
+	closed   bool
+}
+
+// newDefaultSink returns the sink used by NewPlayer
+func newDefaultSink() (Sink, error) {
+	s := &deviceSink{}
+	s.cond = sync.NewCond(&s.mu)
+	return s, nil
+}
+
+func (s *deviceSink) Open(format Format) (Format, error) {
+	deviceOnce.Do(func() {
+		deviceFormat = Format{SampleRate: format.SampleRate, Channels: min(format.Channels, 2)}
+
+		var ready chan struct{}
+		deviceContext, ready, deviceErr = oto.NewContext(&oto.NewContextOptions{
+			SampleRate:   deviceFormat.SampleRate,
+			ChannelCount: deviceFormat.Channels,
+			Format:       oto.FormatFloat32LE,
+		})
+		if deviceErr == nil {
+			<-ready
+		}
+	})
+	if deviceErr != nil {
+		return Format{}, fmt.Errorf("failed to open audio device: %w", deviceErr)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.format = deviceFormat
+	s.capacity = int(deviceSinkBuffer.Seconds()*float64(s.format.SampleRate)) * s.format.Channels
+	s.queue = s.queue[:0]
+	if s.player == nil {
+		s.player = deviceContext.NewPlayer(s)
+		s.player.Play()
+	}
+	return s.format, nil
+}
+
+// Read is called by the sound card to pull samples. It plays silence when no
+// samples are queued, so the device keeps running between tracks.
+func (s *deviceSink) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(p) / 4
+	available := min(n, len(s.queue))
+	for i := 0; i < available; i++ {
+		binary.LittleEndian.PutUint32(p[i*4:], math.Float32bits(s.queue[i]))
+	}
+	for i := available * 4; i < n*4; i++ {
+		p[i] = 0
+	}
+
+	s.queue = append(s.queue[:0], s.queue[available:]...)
+	s.cond.Broadcast()
+	return n * 4, nil
+}
+
+func (s *deviceSink) Write(samples []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(samples) > 0 {
+		for len(s.queue) >= s.capacity && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			return fmt.Errorf("audio device is closed")
+		}
+
+		n := min(len(samples), s.capacity-len(s.queue))
+		s.queue = append(s.queue, samples[:n]...)
+		samples = samples[n:]
+	}
+	return nil
+}
+
+func (s *deviceSink) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = s.queue[:0]
+	s.cond.Broadcast()
+}
+
+func (s *deviceSink) Pause() {
+	if player := s.getPlayer(); player != nil {
+		player.Pause()
+	}
+}
+
+func (s *deviceSink) Resume() {
+	if player := s.getPlayer(); player != nil {
+		player.Play()
+	}
+}
+
+func (s *deviceSink) Buffered() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.player == nil || s.format.SampleRate == 0 {
+		return 0
+	}
+	samples := len(s.queue) + s.player.BufferedSize()/4
+	return durationOf(samples, s.format)
+}
+
+func (s *deviceSink) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	player := s.player
+	s.player = nil
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	if player != nil {
+		return player.Close()
+	}
+	return nil
+}
+
+// getPlayer returns the sound card player, if the sink has been opened
+func (s *deviceSink) getPlayer() *oto.Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player
+}
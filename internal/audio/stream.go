@@ -0,0 +1,466 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// streamChunksPerSecond sets how much audio is decoded and written to the
+	// sink at a time, which bounds the error of the playback position
+	streamChunksPerSecond = 100
+
+	// drainPollInterval is how often the end of a stream checks whether the sink has played everything
+	drainPollInterval = 10 * time.Millisecond
+)
+
+// Stream plays an audio file from a decoder into a sink. The playback position
+// is the audio written to the sink minus what the sink has not played yet.
+// Positions are frames of the audio file, whatever the playback rate.
+type Stream struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	decoder  Decoder
+	sink     Sink
+	conv     *converter
+	status   PlayerStatus
+	volume   float64
+	position int64
+	seekTo   int64
+	seekGen  int
+	finished bool
+	err      error
+	running  chan struct{}
+
+	// Playback rate, and the stretcher changing the tempo when it is not 1
+	rate        float64
+	stretch     *stretcher
+	stretchBase int64
+
+	// readPos is the next frame to decode; the A-B loop is active when
+	// loopEnd > loopStart, and wrapped is set once playback jumped back to its start
+	readPos   int64
+	loopStart int64
+	loopEnd   int64
+	wrapped   bool
+}
+
+// NewStream opens a sink for the decoder's format and returns a stopped stream
+func NewStream(decoder Decoder, sink Sink) (*Stream, error) {
+	from := Format{SampleRate: decoder.SampleRate(), Channels: decoder.Channels()}
+	to, err := sink.Open(from)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Stream{
+		decoder: decoder,
+		sink:    sink,
+		conv:    newConverter(from, to),
+		status:  Stopped,
+		volume:  1.0,
+		seekTo:  -1,
+		rate:    1.0,
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s, nil
+}
+
+// Start starts playback from the current position
+func (s *Stream) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.status {
+	case Playing:
+		return nil
+	case Paused:
+		s.status = Playing
+		s.sink.Resume()
+		s.cond.Broadcast()
+		return nil
+	}
+
+	if s.finished {
+		s.finished = false
+		s.position = 0
+		s.seekTo = 0
+		s.seekGen++
+	}
+
+	s.status = Playing
+	s.err = nil
+	s.running = make(chan struct{})
+	go s.run(s.running)
+	return nil
+}
+
+// Stop stops playback and rewinds to the beginning
+func (s *Stream) Stop() error {
+	s.mu.Lock()
+	running := s.running
+	s.status = Stopped
+	s.position = 0
+	s.seekTo = 0
+	s.seekGen++
+	s.finished = false
+	s.wrapped = false
+	s.running = nil
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	s.sink.Flush()
+	s.sink.Resume()
+	if running != nil {
+		<-running
+	}
+	return nil
+}
+
+// Pause pauses playback
+func (s *Stream) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == Playing {
+		s.status = Paused
+		s.sink.Pause()
+	}
+	return nil
+}
+
+// Resume resumes paused playback
+func (s *Stream) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status == Paused {
+		s.status = Playing
+		s.sink.Resume()
+		s.cond.Broadcast()
+	}
+	return nil
+}
+
+// Seek moves playback to a position in seconds
+func (s *Stream) Seek(position float64) error {
+	frame := int64(position * float64(s.decoder.SampleRate()))
+	if frame < 0 {
+		frame = 0
+	}
+	if length := s.decoder.Length(); length > 0 && frame > length {
+		frame = length
+	}
+
+	s.mu.Lock()
+	s.position = frame
+	s.seekTo = frame
+	s.seekGen++
+	s.finished = false
+	s.wrapped = false
+	s.mu.Unlock()
+
+	s.sink.Flush()
+	return nil
+}
+
+// SetVolume sets the playback volume, clamped between 0 and 1
+func (s *Stream) SetVolume(volume float64) error {
+	volume = math.Max(0, math.Min(1, volume))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.volume = volume
+	return nil
+}
+
+// SetRate sets the playback rate, clamped between MinPlaybackRate and
+// MaxPlaybackRate. The pitch is preserved.
+func (s *Stream) SetRate(rate float64) error {
+	rate = math.Max(MinPlaybackRate, math.Min(MaxPlaybackRate, rate))
+	position := s.GetPosition()
+
+	s.mu.Lock()
+	if rate == s.rate {
+		s.mu.Unlock()
+		return nil
+	}
+	s.rate = rate
+	s.mu.Unlock()
+
+	// Audio already buffered at the previous rate is replaced
+	return s.Seek(position)
+}
+
+// GetRate returns the playback rate
+func (s *Stream) GetRate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rate
+}
+
+// SetLoop makes playback repeat the section between start and end, in
+// seconds. Playback moves to start if it is outside of the section.
+func (s *Stream) SetLoop(start, end float64) error {
+	sampleRate := float64(s.decoder.SampleRate())
+	if length := s.GetDuration(); length > 0 {
+		end = math.Min(end, length)
+	}
+	if start < 0 || end-start < 1/sampleRate {
+		return fmt.Errorf("invalid loop from %.3fs to %.3fs", start, end)
+	}
+
+	position := s.GetPosition()
+
+	s.mu.Lock()
+	s.loopStart = int64(start * sampleRate)
+	s.loopEnd = int64(end * sampleRate)
+	s.mu.Unlock()
+
+	if position < start || position >= end {
+		return s.Seek(start)
+	}
+	return nil
+}
+
+// ClearLoop stops repeating the A-B loop section
+func (s *Stream) ClearLoop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loopStart, s.loopEnd = 0, 0
+	return nil
+}
+
+// GetLoop returns the A-B loop section in seconds, and whether it is active
+func (s *Stream) GetLoop() (float64, float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sampleRate := float64(s.decoder.SampleRate())
+	return float64(s.loopStart) / sampleRate, float64(s.loopEnd) / sampleRate, s.loopEnd > s.loopStart
+}
+
+// GetState returns the current state of the stream
+func (s *Stream) GetState() PlayerState {
+	s.mu.Lock()
+	status, volume := s.status, s.volume
+	s.mu.Unlock()
+
+	return PlayerState{
+		Status:   status,
+		Progress: s.GetPosition(),
+		Duration: s.GetDuration(),
+		Volume:   volume,
+	}
+}
+
+// GetPosition returns the position that is being heard, in seconds
+func (s *Stream) GetPosition() float64 {
+	s.mu.Lock()
+	sampleRate := float64(s.decoder.SampleRate())
+	position := float64(s.position) / sampleRate
+	rate := s.rate
+	loopStart, loopEnd, wrapped := float64(s.loopStart)/sampleRate, float64(s.loopEnd)/sampleRate, s.wrapped
+	s.mu.Unlock()
+
+	position -= s.sink.Buffered().Seconds() * rate
+
+	// Just after the A-B loop wrapped around, the end of the loop is still playing
+	if wrapped && loopEnd > loopStart && position < loopStart {
+		position += loopEnd - loopStart
+	}
+	if position < 0 {
+		return 0
+	}
+	return position
+}
+
+// GetDuration returns the duration of the audio file in seconds
+func (s *Stream) GetDuration() float64 {
+	return float64(s.decoder.Length()) / float64(s.decoder.SampleRate())
+}
+
+// Finished reports whether the stream has played the audio file to the end
+func (s *Stream) Finished() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.finished
+}
+
+// Err returns the error that stopped playback, if any
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close stops playback and closes the decoder. The sink is left open.
+func (s *Stream) Close() error {
+	s.Stop()
+	return s.decoder.Close()
+}
+
+// run decodes audio and writes it to the sink until the stream is stopped or
+// reaches the end of the file
+func (s *Stream) run(running chan struct{}) {
+	defer close(running)
+
+	channels := s.decoder.Channels()
+	chunkFrames := max(s.decoder.SampleRate()/streamChunksPerSecond, 1)
+	buf := make([]float32, chunkFrames*channels)
+
+	for {
+		s.mu.Lock()
+		for s.status == Paused {
+			s.cond.Wait()
+		}
+		if s.status == Stopped || s.running != running {
+			s.mu.Unlock()
+			return
+		}
+		if s.seekTo >= 0 {
+			if err := s.moveTo(s.seekTo); err != nil {
+				s.fail(err)
+				s.mu.Unlock()
+				return
+			}
+			s.seekTo = -1
+		}
+
+		// At the end of the A-B loop, playback continues from its start
+		// without flushing the sink, so that the loop is seamless
+		frames := chunkFrames
+		if s.loopEnd > s.loopStart {
+			if s.readPos >= s.loopEnd {
+				if err := s.moveTo(s.loopStart); err != nil {
+					s.fail(err)
+					s.mu.Unlock()
+					return
+				}
+				s.position = s.loopStart
+				s.wrapped = true
+			}
+			frames = int(min(int64(frames), s.loopEnd-s.readPos))
+		}
+		gen, volume, stretch := s.seekGen, float32(s.volume), s.stretch
+		s.mu.Unlock()
+
+		n, err := s.decoder.Read(buf[:frames*channels])
+		if n > 0 {
+			samples := buf[:n]
+			for i := range samples {
+				samples[i] *= volume
+			}
+			if stretch != nil {
+				samples = stretch.process(samples)
+			}
+
+			// Count the samples before writing them, since a realtime sink
+			// buffers them before the write returns
+			s.mu.Lock()
+			if gen == s.seekGen {
+				s.readPos += int64(n / channels)
+				if stretch != nil {
+					s.position = s.stretchBase + int64(stretch.source)
+				} else {
+					s.position += int64(n / channels)
+				}
+			}
+			s.mu.Unlock()
+
+			if !s.write(samples) {
+				return
+			}
+		}
+
+		if err == io.EOF {
+			s.mu.Lock()
+			looping := s.loopEnd > s.loopStart && gen == s.seekGen
+			if looping {
+				s.readPos = s.loopEnd
+			}
+			s.mu.Unlock()
+			if looping {
+				continue
+			}
+
+			if stretch != nil && !s.write(stretch.flush()) {
+				return
+			}
+			if s.drain(gen) {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			s.mu.Lock()
+			s.fail(err)
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+// moveTo moves decoding to a frame and restarts the conversion; the caller must hold s.mu
+func (s *Stream) moveTo(frame int64) error {
+	if err := s.decoder.SetPosition(frame); err != nil {
+		return err
+	}
+
+	s.readPos = frame
+	s.conv.reset()
+	s.stretch = nil
+	s.stretchBase = frame
+	if s.rate != 1 {
+		s.stretch = newStretcher(s.decoder.SampleRate(), s.decoder.Channels(), s.rate)
+	}
+	return nil
+}
+
+// write converts samples to the sink format and writes them, and stops
+// playback if the sink fails. It reports whether playback can go on.
+func (s *Stream) write(samples []float32) bool {
+	if len(samples) == 0 {
+		return true
+	}
+
+	if err := s.sink.Write(s.conv.convert(samples)); err != nil {
+		s.mu.Lock()
+		s.fail(err)
+		s.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// drain waits for the sink to play the end of the file and marks the stream as
+// finished. It returns false if playback was moved by a seek in the meantime.
+func (s *Stream) drain(gen int) bool {
+	for s.sink.Buffered() > 0 {
+		s.mu.Lock()
+		moved := gen != s.seekGen
+		stopped := s.status == Stopped
+		s.mu.Unlock()
+		if moved || stopped {
+			return stopped
+		}
+		time.Sleep(drainPollInterval)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen != s.seekGen {
+		return s.status == Stopped
+	}
+	s.status = Stopped
+	s.finished = true
+	return true
+}
+
+// fail stops playback because of an error; the caller must hold s.mu
+func (s *Stream) fail(err error) {
+	s.status = Stopped
+	s.err = fmt.Errorf("playback failed: %w", err)
+}
@@ -0,0 +1,272 @@
+package audio
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// positionTolerance is how far a realtime position may be from the expected one, in seconds
+const positionTolerance = 0.1
+
+// writeTestFile writes a mono sine wave WAVE file and returns its path
+func writeTestFile(t *testing.T, seconds float64) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.wav")
+	format := Format{SampleRate: 8000, Channels: 1}
+	samples := make([]float32, int(seconds*float64(format.SampleRate)))
+	for i := range samples {
+		samples[i] = float32(0.5 * math.Sin(2*math.Pi*440*float64(i)/float64(format.SampleRate)))
+	}
+
+	sink := NewFileSink(path)
+	if _, err := sink.Open(format); err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := sink.Write(samples); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return path
+}
+
+func TestDecodeWAV(t *testing.T) {
+	pcm, err := Decode(writeTestFile(t, 0.5))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	if pcm.SampleRate != 8000 || pcm.Channels != 1 || len(pcm.Samples) != 4000 {
+		t.Fatalf("Decode() = %d Hz, %d channels, %d samples, expected 8000 Hz, 1 channel, 4000 samples",
+			pcm.SampleRate, pcm.Channels, len(pcm.Samples))
+	}
+
+	for i, sample := range pcm.Samples {
+		expected := 0.5 * math.Sin(2*math.Pi*440*float64(i)/8000)
+		if math.Abs(float64(sample)-expected) > 1.0/16384 {
+			t.Fatalf("Decode() sample %d = %v, expected %v", i, sample, expected)
+		}
+	}
+}
+
+func TestConverter(t *testing.T) {
+	c := newConverter(Format{SampleRate: 8000, Channels: 1}, Format{SampleRate: 16000, Channels: 2})
+
+	in := make([]float32, 800)
+	for i := range in {
+		in[i] = float32(i)
+	}
+
+	var out []float32
+	for i := 0; i < len(in); i += 100 {
+		out = append(out, c.convert(in[i:i+100])...)
+	}
+
+	// Every input frame but the last yields two output frames of two channels
+	if len(out) != (len(in)-1)*4 {
+		t.Fatalf("convert() returned %d samples, expected %d", len(out), (len(in)-1)*4)
+	}
+	for i := 0; i < len(out); i += 2 {
+		expected := float32(i/2) / 2
+		if out[i] != expected || out[i+1] != expected {
+			t.Fatalf("convert() frame %d = (%v, %v), expected %v", i/2, out[i], out[i+1], expected)
+		}
+	}
+}
+
+func TestStreamPlayback(t *testing.T) {
+	decoder, err := OpenDecoder(writeTestFile(t, 1.5))
+	if err != nil {
+		t.Fatalf("OpenDecoder() error = %v", err)
+	}
+
+	stream, err := NewStream(decoder, NewNullSink(true))
+	if err != nil {
+		t.Fatalf("NewStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if duration := stream.GetDuration(); duration != 1.5 {
+		t.Errorf("GetDuration() = %v, expected 1.5", duration)
+	}
+
+	stream.Start()
+	time.Sleep(300 * time.Millisecond)
+	if position := stream.GetPosition(); math.Abs(position-0.3) > positionTolerance {
+		t.Errorf("GetPosition() while playing = %v, expected about 0.3", position)
+	}
+
+	// Position must not move while paused
+	stream.Pause()
+	paused := stream.GetPosition()
+	time.Sleep(200 * time.Millisecond)
+	if position := stream.GetPosition(); position != paused {
+		t.Errorf("GetPosition() while paused = %v, expected %v", position, paused)
+	}
+
+	// Seeking while paused is exact
+	stream.Seek(1.0)
+	if position := stream.GetPosition(); position != 1.0 {
+		t.Errorf("GetPosition() after seek = %v, expected 1.0", position)
+	}
+
+	stream.Resume()
+	time.Sleep(200 * time.Millisecond)
+	if position := stream.GetPosition(); math.Abs(position-1.2) > positionTolerance {
+		t.Errorf("GetPosition() after resume = %v, expected about 1.2", position)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !stream.Finished() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !stream.Finished() {
+		t.Fatalf("Finished() = false, expected stream to reach the end")
+	}
+	if state := stream.GetState(); state.Status != Stopped {
+		t.Errorf("GetState() status = %v, expected stopped", state.Status)
+	}
+}
+
+func TestStreamFileSink(t *testing.T) {
+	decoder, err := OpenDecoder(writeTestFile(t, 2))
+	if err != nil {
+		t.Fatalf("OpenDecoder() error = %v", err)
+	}
+
+	output := filepath.Join(t.TempDir(), "output.wav")
+	stream, err := NewStream(decoder, NewFileSink(output))
+	if err != nil {
+		t.Fatalf("NewStream() error = %v", err)
+	}
+
+	stream.SetVolume(0.5)
+	stream.Seek(1.0)
+	stream.Start()
+
+	deadline := time.Now().Add(time.Second)
+	for !stream.Finished() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !stream.Finished() {
+		t.Fatalf("Finished() = false, expected stream to reach the end")
+	}
+	stream.Close()
+	stream.sink.Close()
+
+	pcm, err := Decode(output)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(pcm.Samples) != 8000 {
+		t.Fatalf("Decode() returned %d samples, expected 8000", len(pcm.Samples))
+	}
+
+	for i, sample := range pcm.Samples {
+		expected := 0.25 * math.Sin(2*math.Pi*440*float64(i+8000)/8000)
+		if math.Abs(float64(sample)-expected) > 1.0/8192 {
+			t.Fatalf("sample %d = %v, expected %v", i, sample, expected)
+		}
+	}
+}
+
+func TestStretcher(t *testing.T) {
+	pcm, err := Decode(writeTestFile(t, 2))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	for _, rate := range []float64{0.5, 0.75, 1.25, 1.5} {
+		s := newStretcher(pcm.SampleRate, pcm.Channels, rate)
+		var out []float32
+		for i := 0; i < len(pcm.Samples); i += 80 {
+			out = append(out, s.process(pcm.Samples[i:min(i+80, len(pcm.Samples))])...)
+		}
+		stretched := len(out)
+		out = append(out, s.flush()...)
+
+		// The tempo changes by the rate, within a window
+		expected := float64(len(pcm.Samples)) / rate
+		if math.Abs(float64(stretched)-expected) > stretchWindow*float64(pcm.SampleRate)/rate*2 {
+			t.Errorf("rate %v: stretched %d samples, expected about %.0f", rate, stretched, expected)
+		}
+		if math.Abs(s.source-float64(len(pcm.Samples))) > 1 {
+			t.Errorf("rate %v: output stands for %.0f input frames, expected %d", rate, s.source, len(pcm.Samples))
+		}
+
+		// The pitch does not change: a 440 Hz sine crosses zero 880 times per second
+		crossings := 0
+		for i := 1; i < len(out); i++ {
+			if (out[i-1] < 0) != (out[i] < 0) {
+				crossings++
+			}
+		}
+		frequency := float64(crossings) / 2 / (float64(len(out)) / float64(pcm.SampleRate))
+		if math.Abs(frequency-440) > 10 {
+			t.Errorf("rate %v: frequency = %.0f Hz, expected 440 Hz", rate, frequency)
+		}
+	}
+}
+
+func TestStreamRateAndLoop(t *testing.T) {
+	decoder, err := OpenDecoder(writeTestFile(t, 3))
+	if err != nil {
+		t.Fatalf("OpenDecoder() error = %v", err)
+	}
+
+	stream, err := NewStream(decoder, NewNullSink(true))
+	if err != nil {
+		t.Fatalf("NewStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	stream.SetRate(1.5)
+	stream.Start()
+	time.Sleep(400 * time.Millisecond)
+	if position := stream.GetPosition(); math.Abs(position-0.6) > positionTolerance {
+		t.Errorf("GetPosition() at 1.5x = %v, expected about 0.6", position)
+	}
+
+	// Changing the rate keeps the position
+	before := stream.GetPosition()
+	stream.SetRate(0.5)
+	if position := stream.GetPosition(); math.Abs(position-before) > positionTolerance {
+		t.Errorf("GetPosition() after rate change = %v, expected about %v", position, before)
+	}
+	if rate := stream.GetRate(); rate != 0.5 {
+		t.Errorf("GetRate() = %v, expected 0.5", rate)
+	}
+	stream.SetRate(1)
+
+	if err := stream.SetLoop(2, 1); err == nil {
+		t.Error("SetLoop() with end before start succeeded, expected an error")
+	}
+	if err := stream.SetLoop(2, 2.3); err != nil {
+		t.Fatalf("SetLoop() error = %v", err)
+	}
+
+	// Playback moves into the loop and stays there
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if position := stream.GetPosition(); position < 2-positionTolerance || position > 2.3+positionTolerance {
+			t.Fatalf("GetPosition() while looping = %v, expected between 2 and 2.3", position)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stream.Finished() {
+		t.Error("Finished() = true while looping")
+	}
+
+	stream.ClearLoop()
+	deadline = time.Now().Add(2 * time.Second)
+	for !stream.Finished() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !stream.Finished() {
+		t.Error("Finished() = false, expected stream to reach the end after clearing the loop")
+	}
+}
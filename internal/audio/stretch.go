@@ -0,0 +1,133 @@
+package audio
+
+import "math"
+
+const (
+	// MinPlaybackRate and MaxPlaybackRate bound the playback rate
+	MinPlaybackRate = 0.5
+	MaxPlaybackRate = 1.5
+
+	// stretchWindow is the length in seconds of the segments overlapped by the stretcher
+	stretchWindow = 0.04
+
+	// stretchTolerance is how far in seconds the stretcher searches for the
+	// segment that continues the previous one best
+	stretchTolerance = 0.01
+)
+
+// stretcher changes the tempo of interleaved audio without changing its pitch,
+// using waveform similarity overlap-add (WSOLA). Segments of the input are
+// taken every rate half-windows and cross-faded every half-window, each one
+// shifted slightly to line up with the waveform of the previous one.
+type stretcher struct {
+	channels  int
+	rate      float64
+	half      int
+	tolerance int
+	window    []float32
+
+	buf     []float32
+	prev    int
+	ideal   float64
+	started bool
+
+	// source is the number of input frames that the output so far stands for,
+	// out of the received ones
+	source   float64
+	received int64
+}
+
+func newStretcher(sampleRate, channels int, rate float64) *stretcher {
+	half := max(int(stretchWindow*float64(sampleRate))/2, 1)
+	window := make([]float32, 2*half)
+	for i := range window {
+		window[i] = float32(0.5 - 0.5*math.Cos(math.Pi*float64(i)/float64(half)))
+	}
+
+	return &stretcher{
+		channels:  channels,
+		rate:      rate,
+		half:      half,
+		tolerance: int(stretchTolerance * float64(sampleRate)),
+		window:    window,
+	}
+}
+
+// process adds input samples and returns the stretched samples that are ready
+func (s *stretcher) process(samples []float32) []float32 {
+	s.buf = append(s.buf, samples...)
+	s.received += int64(len(samples) / s.channels)
+	c, half := s.channels, s.half
+	frames := len(s.buf) / c
+
+	var out []float32
+	if !s.started {
+		if frames < 2*half {
+			return nil
+		}
+		// The first segment starts the output as is
+		out = append(out, s.buf[:half*c]...)
+		s.started = true
+		s.ideal = s.rate * float64(half)
+		s.source = s.ideal
+	}
+
+	for {
+		ideal := int(s.ideal)
+		if ideal+s.tolerance+half > frames || s.prev+2*half > frames {
+			break
+		}
+
+		pos := s.bestMatch(ideal)
+		for i := range half {
+			fadeOut, fadeIn := s.window[half+i], s.window[i]
+			for ch := range c {
+				out = append(out, s.buf[(s.prev+half+i)*c+ch]*fadeOut+s.buf[(pos+i)*c+ch]*fadeIn)
+			}
+		}
+		s.prev = pos
+		s.ideal += s.rate * float64(half)
+		s.source += s.rate * float64(half)
+	}
+
+	// Drop the input that no later segment can use
+	if drop := min(s.prev, int(s.ideal)-s.tolerance); drop > 0 {
+		s.buf = append(s.buf[:0], s.buf[drop*c:]...)
+		s.prev -= drop
+		s.ideal -= float64(drop)
+	}
+	return out
+}
+
+// flush returns the input left after the last segment, at its original tempo
+func (s *stretcher) flush() []float32 {
+	var out []float32
+	if !s.started {
+		out = s.buf
+	} else if start := (s.prev + s.half) * s.channels; start < len(s.buf) {
+		out = s.buf[start:]
+	}
+	s.buf = nil
+	s.source = float64(s.received)
+	return out
+}
+
+// bestMatch returns the segment start near ideal whose beginning is the most
+// similar to the natural continuation of the previous segment
+func (s *stretcher) bestMatch(ideal int) int {
+	c, half := s.channels, s.half
+	target := s.buf[(s.prev+half)*c : (s.prev+2*half)*c]
+
+	best, bestScore := ideal, math.Inf(-1)
+	for pos := max(ideal-s.tolerance, 0); pos <= ideal+s.tolerance; pos++ {
+		var score float64
+		candidate := s.buf[pos*c : (pos+half)*c]
+		for i := 0; i < len(target); i += c {
+			score += float64(target[i] * candidate[i])
+		}
+		if score > bestScore {
+			best, bestScore = pos, score
+		}
+	}
+	return best
+}
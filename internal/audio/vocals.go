@@ -0,0 +1,211 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+
+	"lrcget-go/internal/lyrics"
+)
+
+const (
+	// Vocal frequency band in Hz used for energy and spectral flux
+	vocalBandLow  = 150
+	vocalBandHigh = 4000
+
+	// minSegmentGap is the shortest silence in seconds that separates two vocal segments
+	minSegmentGap = 0.35
+
+	// minSegmentLength is the shortest vocal segment in seconds
+	minSegmentLength = 0.25
+
+	// minOnsetSpacing is the shortest time in seconds between two onsets
+	minOnsetSpacing = 0.3
+)
+
+// DetectVocalSegments finds the stretches of audio where vocals are likely,
+// from the energy of the vocal frequency band, and the note onsets in them
+// from spectral flux peaks. It is a best-effort analysis meant to give
+// auto-synced lyrics a starting point.
+func DetectVocalSegments(pcm *PCM) []lyrics.Segment {
+	mono := mixDown(pcm)
+
+	// Analysis windows of about 50 ms with a 75% overlap
+	size := 1
+	for size < pcm.SampleRate/20 {
+		size *= 2
+	}
+	hop := size / 4
+	if len(mono) < size {
+		return nil
+	}
+
+	low := vocalBandLow * size / pcm.SampleRate
+	high := min(vocalBandHigh*size/pcm.SampleRate, size/2)
+	window := make([]float64, size)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size-1))
+	}
+
+	frames := (len(mono)-size)/hop + 1
+	energy := make([]float64, frames)
+	flux := make([]float64, frames)
+	spectrum := make([]complex128, size)
+	previous := make([]float64, high-low)
+	for f := range frames {
+		for i := range spectrum {
+			spectrum[i] = complex(float64(mono[f*hop+i])*window[i], 0)
+		}
+		fft(spectrum)
+
+		var sum, rise float64
+		for k := low; k < high; k++ {
+			magnitude := cmplx.Abs(spectrum[k])
+			sum += magnitude * magnitude
+
+			// Flux of log magnitudes, so that quiet notes count as well
+			level := math.Log1p(magnitude)
+			if f > 0 && level > previous[k-low] {
+				rise += level - previous[k-low]
+			}
+			previous[k-low] = level
+		}
+		energy[f] = 10 * math.Log10(sum/float64(size)+1e-12)
+		flux[f] = rise
+	}
+
+	frameTime := func(f int) float64 {
+		return float64(f*hop+size/2) / float64(pcm.SampleRate)
+	}
+
+	// Frames are active when their energy is well above the noise floor
+	floor, peak := percentile(energy, 0.1), percentile(energy, 0.95)
+	threshold := floor + 0.35*(peak-floor)
+	if peak-floor < 6 {
+		threshold = -60
+	}
+	active := make([]bool, frames)
+	for f, e := range energy {
+		active[f] = e > threshold && e > -60
+	}
+
+	var segments []lyrics.Segment
+	for f := 0; f < frames; f++ {
+		if !active[f] {
+			continue
+		}
+		start := f
+		for f < frames && active[f] {
+			f++
+		}
+		segment := lyrics.Segment{Start: frameTime(start), End: frameTime(f - 1)}
+
+		if n := len(segments); n > 0 && segment.Start-segments[n-1].End < minSegmentGap {
+			segments[n-1].End = segment.End
+		} else {
+			segments = append(segments, segment)
+		}
+	}
+
+	kept := segments[:0]
+	for _, segment := range segments {
+		if segment.End-segment.Start >= minSegmentLength {
+			segment.Onsets = detectOnsets(flux, frameTime, segment)
+			kept = append(kept, segment)
+		}
+	}
+	return kept
+}
+
+// detectOnsets returns the times of the spectral flux peaks within a segment
+func detectOnsets(flux []float64, frameTime func(int) float64, segment lyrics.Segment) []float64 {
+	var first, last int
+	for f := range flux {
+		if frameTime(f) < segment.Start {
+			first = f + 1
+		}
+		if frameTime(f) <= segment.End {
+			last = f
+		}
+	}
+	if last-first < 2 {
+		return nil
+	}
+
+	var mean, deviation float64
+	for _, value := range flux[first : last+1] {
+		mean += value
+	}
+	mean /= float64(last - first + 1)
+	for _, value := range flux[first : last+1] {
+		deviation += (value - mean) * (value - mean)
+	}
+	deviation = math.Sqrt(deviation / float64(last-first+1))
+
+	var onsets []float64
+	for f := first + 1; f < last; f++ {
+		if flux[f] <= mean+1.5*deviation || flux[f] < flux[f-1] || flux[f] < flux[f+1] {
+			continue
+		}
+		t := frameTime(f)
+		if t-segment.Start < minOnsetSpacing {
+			continue
+		}
+		if n := len(onsets); n > 0 && t-onsets[n-1] < minOnsetSpacing {
+			continue
+		}
+		onsets = append(onsets, t)
+	}
+	return onsets
+}
+
+// mixDown averages the channels of decoded audio
+func mixDown(pcm *PCM) []float32 {
+	if pcm.Channels == 1 {
+		return pcm.Samples
+	}
+
+	mono := make([]float32, len(pcm.Samples)/pcm.Channels)
+	for i := range mono {
+		var sum float32
+		for _, sample := range pcm.Samples[i*pcm.Channels : (i+1)*pcm.Channels] {
+			sum += sample
+		}
+		mono[i] = sum / float32(pcm.Channels)
+	}
+	return mono
+}
+
+// percentile returns the value below which the fraction p of values fall
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// fft computes the discrete Fourier transform in place; len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for length := 2; length <= n; length <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(length)))
+		for start := 0; start < n; start += length {
+			w := complex(1, 0)
+			for k := range length / 2 {
+				even, odd := x[start+k], x[start+k+length/2]*w
+				x[start+k], x[start+k+length/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+}
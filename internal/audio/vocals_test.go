@@ -0,0 +1,72 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"lrcget-go/internal/lyrics"
+)
+
+// synthesizeSong returns mono audio of sung notes over quiet noise. Each note
+// is a harmonic tone given as start, end and fundamental frequency.
+func synthesizeSong(seconds float64, notes [][3]float64) *PCM {
+	const sampleRate = 16000
+	random := rand.New(rand.NewSource(1))
+	samples := make([]float32, int(seconds*sampleRate))
+	for i := range samples {
+		samples[i] = float32(0.002 * random.NormFloat64())
+	}
+
+	for _, note := range notes {
+		for i := int(note[0] * sampleRate); i < int(note[1]*sampleRate); i++ {
+			t := float64(i) / sampleRate
+			for harmonic := 1.0; harmonic <= 4; harmonic++ {
+				samples[i] += float32(0.3 / harmonic * math.Sin(2*math.Pi*note[2]*harmonic*t))
+			}
+		}
+	}
+
+	return &PCM{Samples: samples, SampleRate: sampleRate, Channels: 1}
+}
+
+func TestDetectVocalSegments(t *testing.T) {
+	pcm := synthesizeSong(12, [][3]float64{
+		{1, 2, 220}, {2, 3, 330},
+		{4, 6, 262},
+		{9, 11, 196},
+	})
+
+	segments := DetectVocalSegments(pcm)
+	expected := []lyrics.Segment{{Start: 1, End: 3}, {Start: 4, End: 6}, {Start: 9, End: 11}}
+	if len(segments) != len(expected) {
+		t.Fatalf("DetectVocalSegments() = %+v, expected %d segments", segments, len(expected))
+	}
+	for i, segment := range segments {
+		if math.Abs(segment.Start-expected[i].Start) > positionTolerance ||
+			math.Abs(segment.End-expected[i].End) > positionTolerance {
+			t.Errorf("DetectVocalSegments() segment %d = %.2f-%.2f, expected %.2f-%.2f",
+				i, segment.Start, segment.End, expected[i].Start, expected[i].End)
+		}
+	}
+
+	// The note change in the first segment is an onset
+	found := false
+	for _, onset := range segments[0].Onsets {
+		found = found || math.Abs(onset-2) <= positionTolerance
+	}
+	if !found {
+		t.Errorf("DetectVocalSegments() onsets = %v, expected one at 2.00", segments[0].Onsets)
+	}
+
+	lrc, err := lyrics.AutoSync("First line\nSecond line\nThird line", segments)
+	if err != nil {
+		t.Fatalf("AutoSync() error = %v", err)
+	}
+	parsed := lyrics.Parse(lrc)
+	for i, start := range []float64{1, 4, 9} {
+		if math.Abs(parsed.Lines[i].Time-start) > positionTolerance {
+			t.Errorf("AutoSync() line %d at %.2f, expected %.2f\n%s", i, parsed.Lines[i].Time, start, lrc)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// WAVE format codes
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavDecoder decodes RIFF WAVE files with integer PCM or float samples
+type wavDecoder struct {
+	file          *os.File
+	format        int
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+	dataStart     int64
+	dataSize      int64
+	remaining     int64
+	buf           []byte
+}
+
+// newWAVDecoder reads the header of a WAVE file and positions it at the first sample
+func newWAVDecoder(file *os.File) (*wavDecoder, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(file, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF WAVE file")
+	}
+
+	d := &wavDecoder{file: file}
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(file, chunk[:]); err != nil {
+			return nil, errors.New("missing data chunk")
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			body := make([]byte, size)
+			if _, err := io.ReadFull(file, body); err != nil || size < 16 {
+				return nil, errors.New("invalid fmt chunk")
+			}
+			d.format = int(binary.LittleEndian.Uint16(body[0:2]))
+			d.channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			d.sampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			d.bitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+			if d.format == wavFormatExtensible && size >= 26 {
+				d.format = int(binary.LittleEndian.Uint16(body[24:26]))
+			}
+			if size%2 == 1 {
+				file.Seek(1, io.SeekCurrent)
+			}
+
+		case "data":
+			if d.channels == 0 {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			if err := d.validate(); err != nil {
+				return nil, err
+			}
+			start, err := file.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			d.dataStart = start
+			d.dataSize = size - size%int64(d.frameSize())
+			d.remaining = d.dataSize
+			return d, nil
+
+		default:
+			if _, err := file.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// validate checks that the sample format is supported
+func (d *wavDecoder) validate() error {
+	switch {
+	case d.format == wavFormatPCM && (d.bitsPerSample == 8 || d.bitsPerSample == 16 || d.bitsPerSample == 24 || d.bitsPerSample == 32):
+	case d.format == wavFormatFloat && (d.bitsPerSample == 32 || d.bitsPerSample == 64):
+	default:
+		return fmt.Errorf("unsupported WAVE format %d with %d bits per sample", d.format, d.bitsPerSample)
+	}
+	if d.channels <= 0 || d.sampleRate <= 0 {
+		return errors.New("invalid WAVE format")
+	}
+	return nil
+}
+
+// frameSize returns the size of a frame in bytes
+func (d *wavDecoder) frameSize() int {
+	return d.channels * d.bitsPerSample / 8
+}
+
+func (d *wavDecoder) SampleRate() int { return d.sampleRate }
+
+func (d *wavDecoder) Channels() int { return d.channels }
+
+func (d *wavDecoder) Length() int64 { return d.dataSize / int64(d.frameSize()) }
+
+func (d *wavDecoder) Read(buf []float32) (int, error) {
+	if d.remaining == 0 {
+		return 0, io.EOF
+	}
+
+	frames := int64(len(buf) / d.channels)
+	if max := d.remaining / int64(d.frameSize()); frames > max {
+		frames = max
+	}
+
+	size := int(frames) * d.frameSize()
+	if cap(d.buf) < size {
+		d.buf = make([]byte, size)
+	}
+	data := d.buf[:size]
+
+	read, err := io.ReadFull(d.file, data)
+	read -= read % d.frameSize()
+	d.remaining -= int64(read)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		d.remaining = 0
+	} else if err != nil {
+		return 0, err
+	}
+
+	bytesPerSample := d.bitsPerSample / 8
+	n := read / bytesPerSample
+	for i := 0; i < n; i++ {
+		buf[i] = d.sample(data[i*bytesPerSample : (i+1)*bytesPerSample])
+	}
+	return n, nil
+}
+
+// sample converts one encoded sample to a float
+func (d *wavDecoder) sample(b []byte) float32 {
+	if d.format == wavFormatFloat {
+		if len(b) == 8 {
+			return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	}
+
+	switch len(b) {
+	case 1:
+		return float32(int(b[0])-128) / 128
+	case 2:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case 3:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float32(v) / 8388608
+	default:
+		return float32(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+	}
+}
+
+func (d *wavDecoder) SetPosition(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	offset := frame * int64(d.frameSize())
+	if offset > d.dataSize {
+		offset = d.dataSize
+	}
+
+	if _, err := d.file.Seek(d.dataStart+offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+	d.remaining = d.dataSize - offset
+	return nil
+}
+
+func (d *wavDecoder) Close() error {
+	return d.file.Close()
+}
@@ -13,13 +13,17 @@ const (
 
 // Database constants
 const (
-	DatabaseVersion  = 7
+	DatabaseVersion  = 17
 	DatabaseFileName = "db.sqlite3"
 	DefaultDataDir   = "~/.lrcget"
 	MaxDatabaseSize  = 100 * 1024 * 1024 // 100MB
 	DatabaseTimeout  = 30 * time.Second
 	MaxRetries       = 3
 	RetryDelay       = 1 * time.Second
+
+	// DetachedTrackRetention is how long the lyrics history of a track whose
+	// file disappeared is kept in case the file shows up again
+	DetachedTrackRetention = 30 * 24 * time.Hour
 )
 
 // HTTP client constants
@@ -70,6 +74,10 @@ const (
 	DefaultSearchLimit   = 20
 	MaxSearchQueryLength = 1000
 	MinSearchQueryLength = 1
+
+	// DefaultCueLyricsLayout names the lyrics files of CUE sheet tracks after
+	// the audio file and the track number, such as album.03.lrc
+	DefaultCueLyricsLayout = "{file}.{track}"
 )
 
 // Cache constants
@@ -133,6 +141,16 @@ const (
 	StatusErrorState
 )
 
+// Event names emitted to the frontend
+const (
+	PlayerStateEvent    = "player:state"
+	PlayerPositionEvent = "player:position"
+	PlayerVolumeEvent   = "player:volume"
+	QueueEvent          = "queue:changed"
+	LibraryEvent        = "library:changed"
+	LibraryScanEvent    = "library:scanned"
+)
+
 // File type constants
 const (
 	AudioFileType  = "audio"
@@ -156,10 +174,11 @@ const (
 
 // Lyrics format constants
 const (
-	LRCFormat = "lrc"
-	TXTFormat = "txt"
-	SRTFormat = "srt"
-	VTTFormat = "vtt"
+	LRCFormat  = "lrc"
+	TXTFormat  = "txt"
+	SRTFormat  = "srt"
+	VTTFormat  = "vtt"
+	TTMLFormat = "ttml"
 )
 
 // Error codes
@@ -86,7 +86,7 @@ func (c *Connection) GetTracksByAlbumID(albumID int64) ([]PersistentTrack, error
 		SELECT id, file_path, file_name, title, album_name, album_artist_name, 
 		       album_id, artist_name, artist_id, image_path, track_number, 
 		       txt_lyrics, lrc_lyrics, duration, instrumental, title_lower,
-		       created_at, updated_at
+		       cue_track, start_offset, end_offset, created_at, updated_at
 		FROM tracks
 		WHERE album_id = ?
 		ORDER BY track_number, title
@@ -107,6 +107,7 @@ func (c *Connection) GetTracksByAlbumID(albumID int64) ([]PersistentTrack, error
 			&track.ArtistName, &track.ArtistID, &track.ImagePath,
 			&track.TrackNumber, &track.TxtLyrics, &track.LrcLyrics,
 			&track.Duration, &track.Instrumental, &track.TitleLower,
+			&track.CueTrack, &track.StartOffset, &track.EndOffset,
 			&track.CreatedAt, &track.UpdatedAt,
 		)
 		if err != nil {
@@ -5,16 +5,17 @@ import (
 	"fmt"
 )
 
-// GetArtists retrieves all artists from the database
+// GetArtists retrieves all artists from the database. Tracks are counted for
+// every artist they credit, whatever the role.
 func (c *Connection) GetArtists() ([]PersistentArtist, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	query := `
 		SELECT a.id, a.name, a.name_lower, a.created_at, a.updated_at,
-		       COUNT(t.id) as tracks_count
+		       COUNT(DISTINCT ta.track_id) as tracks_count
 		FROM artists a
-		LEFT JOIN tracks t ON a.id = t.artist_id
+		LEFT JOIN track_artists ta ON a.id = ta.artist_id
 		GROUP BY a.id, a.name, a.name_lower, a.created_at, a.updated_at
 		ORDER BY a.name
 	`
@@ -48,9 +49,9 @@ func (c *Connection) GetArtistByID(id int64) (*PersistentArtist, error) {
 
 	query := `
 		SELECT a.id, a.name, a.name_lower, a.created_at, a.updated_at,
-		       COUNT(t.id) as tracks_count
+		       COUNT(DISTINCT ta.track_id) as tracks_count
 		FROM artists a
-		LEFT JOIN tracks t ON a.id = t.artist_id
+		LEFT JOIN track_artists ta ON a.id = ta.artist_id
 		WHERE a.id = ?
 		GROUP BY a.id, a.name, a.name_lower, a.created_at, a.updated_at
 	`
@@ -71,7 +72,8 @@ func (c *Connection) GetArtistByID(id int64) (*PersistentArtist, error) {
 	return &artist, nil
 }
 
-// GetTracksByArtistID retrieves all tracks for a specific artist
+// GetTracksByArtistID retrieves all tracks crediting a specific artist, as a
+// primary, featured or album artist
 func (c *Connection) GetTracksByArtistID(artistID int64) ([]PersistentTrack, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -80,9 +82,9 @@ func (c *Connection) GetTracksByArtistID(artistID int64) ([]PersistentTrack, err
 		SELECT id, file_path, file_name, title, album_name, album_artist_name, 
 		       album_id, artist_name, artist_id, image_path, track_number, 
 		       txt_lyrics, lrc_lyrics, duration, instrumental, title_lower,
-		       created_at, updated_at
+		       cue_track, start_offset, end_offset, created_at, updated_at
 		FROM tracks
-		WHERE artist_id = ?
+		WHERE id IN (SELECT track_id FROM track_artists WHERE artist_id = ?)
 		ORDER BY album_name, track_number, title
 	`
 
@@ -101,6 +103,7 @@ func (c *Connection) GetTracksByArtistID(artistID int64) ([]PersistentTrack, err
 			&track.ArtistName, &track.ArtistID, &track.ImagePath,
 			&track.TrackNumber, &track.TxtLyrics, &track.LrcLyrics,
 			&track.Duration, &track.Instrumental, &track.TitleLower,
+			&track.CueTrack, &track.StartOffset, &track.EndOffset,
 			&track.CreatedAt, &track.UpdatedAt,
 		)
 		if err != nil {
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -14,16 +15,19 @@ func (c *Connection) GetConfig() (*PersistentConfig, error) {
 	query := `
 		SELECT id, skip_tracks_with_synced_lyrics, skip_tracks_with_plain_lyrics,
 		       show_line_count, try_embed_lyrics, theme_mode, lrclib_instance,
-		       created_at, updated_at
+		       skip_hidden_files, skip_small_files, follow_symlinks, cue_lyrics_layout,
+		       artist_separators, created_at, updated_at
 		FROM config_data
 		WHERE id = 1
 	`
 
 	var config PersistentConfig
+	var separators string
 	err := c.db.QueryRow(query).Scan(
 		&config.ID, &config.SkipTracksWithSyncedLyrics, &config.SkipTracksWithPlainLyrics,
 		&config.ShowLineCount, &config.TryEmbedLyrics, &config.ThemeMode, &config.LrclibInstance,
-		&config.CreatedAt, &config.UpdatedAt,
+		&config.SkipHiddenFiles, &config.SkipSmallFiles, &config.FollowSymlinks, &config.CueLyricsLayout,
+		&separators, &config.CreatedAt, &config.UpdatedAt,
 	)
 
 	if err != nil {
@@ -37,6 +41,10 @@ func (c *Connection) GetConfig() (*PersistentConfig, error) {
 				TryEmbedLyrics:               false,
 				ThemeMode:                    "system",
 				LrclibInstance:               "https://lrclib.net",
+				SkipHiddenFiles:              true,
+				SkipSmallFiles:               true,
+				CueLyricsLayout:              "{file}.{track}",
+				ArtistSeparators:             DefaultArtistSeparators,
 				CreatedAt:                    time.Now(),
 				UpdatedAt:                    time.Now(),
 			}, nil
@@ -44,6 +52,10 @@ func (c *Connection) GetConfig() (*PersistentConfig, error) {
 		return nil, fmt.Errorf("failed to get config: %w", err)
 	}
 
+	if err := json.Unmarshal([]byte(separators), &config.ArtistSeparators); err != nil {
+		return nil, fmt.Errorf("failed to decode artist separators: %w", err)
+	}
+
 	return &config, nil
 }
 
@@ -52,18 +64,29 @@ func (c *Connection) UpdateConfig(config *PersistentConfig) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	separators := config.ArtistSeparators
+	if separators == nil {
+		separators = []string{}
+	}
+	separatorsData, err := json.Marshal(separators)
+	if err != nil {
+		return fmt.Errorf("failed to encode artist separators: %w", err)
+	}
+
 	query := `
 		UPDATE config_data 
 		SET skip_tracks_with_synced_lyrics = ?, skip_tracks_with_plain_lyrics = ?,
 		    show_line_count = ?, try_embed_lyrics = ?, theme_mode = ?, 
-		    lrclib_instance = ?, updated_at = ?
+		    lrclib_instance = ?, skip_hidden_files = ?, skip_small_files = ?, follow_symlinks = ?,
+		    cue_lyrics_layout = ?, artist_separators = ?, updated_at = ?
 		WHERE id = ?
 	`
 
-	_, err := c.db.Exec(query,
+	_, err = c.db.Exec(query,
 		config.SkipTracksWithSyncedLyrics, config.SkipTracksWithPlainLyrics,
 		config.ShowLineCount, config.TryEmbedLyrics, config.ThemeMode,
-		config.LrclibInstance, time.Now(), config.ID,
+		config.LrclibInstance, config.SkipHiddenFiles, config.SkipSmallFiles, config.FollowSymlinks,
+		config.CueLyricsLayout, string(separatorsData), time.Now(), config.ID,
 	)
 
 	if err != nil {
@@ -134,7 +157,8 @@ func (c *Connection) GetDirectories() ([]string, error) {
 	return directories, nil
 }
 
-// SetDirectories sets the directories in the database
+// SetDirectories sets the directories in the database. Directories that are
+// kept keep their scan patterns.
 func (c *Connection) SetDirectories(directories []string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -146,15 +170,41 @@ func (c *Connection) SetDirectories(directories []string) error {
 	}
 	defer tx.Rollback()
 
-	// Clear existing directories
-	_, err = tx.Exec("DELETE FROM directories")
+	// Remove directories that are no longer in the list
+	existing := make(map[string]bool)
+	rows, err := tx.Query("SELECT path FROM directories")
 	if err != nil {
-		return fmt.Errorf("failed to clear directories: %w", err)
+		return fmt.Errorf("failed to query directories: %w", err)
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan directory: %w", err)
+		}
+		existing[path] = true
+	}
+	rows.Close()
+
+	keep := make(map[string]bool, len(directories))
+	for _, path := range directories {
+		keep[path] = true
+	}
+	for path := range existing {
+		if keep[path] {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM directories WHERE path = ?", path); err != nil {
+			return fmt.Errorf("failed to delete directory: %w", err)
+		}
 	}
 
 	// Insert new directories
 	now := time.Now()
 	for _, path := range directories {
+		if existing[path] {
+			continue
+		}
 		_, err = tx.Exec(
 			"INSERT INTO directories (path, created_at, updated_at) VALUES (?, ?, ?)",
 			path, now, now,
@@ -162,7 +212,79 @@ func (c *Connection) SetDirectories(directories []string) error {
 		if err != nil {
 			return fmt.Errorf("failed to insert directory: %w", err)
 		}
+		existing[path] = true
 	}
 
 	return tx.Commit()
 }
+
+// scanPatterns is the JSON stored in the scan_patterns column of directories
+type scanPatterns struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// GetDirectorySettings retrieves all directories with their scan patterns
+func (c *Connection) GetDirectorySettings() ([]PersistentDirectory, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rows, err := c.db.Query("SELECT id, path, scan_patterns, created_at, updated_at FROM directories ORDER BY path")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query directories: %w", err)
+	}
+	defer rows.Close()
+
+	var directories []PersistentDirectory
+	for rows.Next() {
+		var directory PersistentDirectory
+		var patterns sql.NullString
+		err := rows.Scan(&directory.ID, &directory.Path, &patterns, &directory.CreatedAt, &directory.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan directory: %w", err)
+		}
+
+		if patterns.Valid && patterns.String != "" {
+			var p scanPatterns
+			if err := json.Unmarshal([]byte(patterns.String), &p); err != nil {
+				return nil, fmt.Errorf("failed to decode scan patterns of %s: %w", directory.Path, err)
+			}
+			directory.Include = p.Include
+			directory.Exclude = p.Exclude
+		}
+		directories = append(directories, directory)
+	}
+
+	return directories, nil
+}
+
+// SetDirectoryPatterns sets the include and exclude glob patterns of a directory
+func (c *Connection) SetDirectoryPatterns(path string, include, exclude []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var patterns *string
+	if len(include) > 0 || len(exclude) > 0 {
+		data, err := json.Marshal(scanPatterns{Include: include, Exclude: exclude})
+		if err != nil {
+			return fmt.Errorf("failed to encode scan patterns: %w", err)
+		}
+		encoded := string(data)
+		patterns = &encoded
+	}
+
+	result, err := c.db.Exec("UPDATE directories SET scan_patterns = ?, updated_at = ? WHERE path = ?", patterns, time.Now(), path)
+	if err != nil {
+		return fmt.Errorf("failed to update scan patterns: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update scan patterns: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("directory not found: %s", path)
+	}
+
+	return nil
+}
@@ -11,7 +11,7 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-const CurrentDBVersion = 7
+const CurrentDBVersion = 17
 
 // Connection represents a database connection
 type Connection struct {
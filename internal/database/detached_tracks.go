@@ -0,0 +1,138 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Columns of the lyrics history and source tables copied to and from their
+// detached tables, after the ID of their track
+const (
+	historyColumns = "lrc_lyrics, txt_lyrics, instrumental, source, created_at"
+	sourceColumns  = "lrclib_id, name, lang, isrc, spotify_id, release_date, downloaded_at, created_at, updated_at"
+)
+
+// detachTrackRows keeps the lyrics history and source of the tracks matching
+// a condition before they are removed. Track IDs can be reused, so the rows
+// are copied under a detached_tracks row recording the tags of their track.
+func detachTrackRows(tx *sql.Tx, where string, args ...any) error {
+	// Read the tracks first, since the transaction cannot run statements while rows are open
+	rows, err := tx.Query(`
+		SELECT id, file_name, title, album_name, artist_name, cue_track FROM tracks
+		WHERE (`+where+`)
+		  AND (id IN (SELECT track_id FROM lyrics_history) OR id IN (SELECT track_id FROM track_lyrics_source))
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query tracks: %w", err)
+	}
+	var tracks []PersistentTrack
+	for rows.Next() {
+		var track PersistentTrack
+		if err := rows.Scan(&track.ID, &track.FileName, &track.Title, &track.AlbumName, &track.ArtistName, &track.CueTrack); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan track: %w", err)
+		}
+		tracks = append(tracks, track)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query tracks: %w", err)
+	}
+
+	now := time.Now()
+	for _, track := range tracks {
+		result, err := tx.Exec(
+			"INSERT INTO detached_tracks (file_name, title, album_name, artist_name, cue_track, detached_at) VALUES (?, ?, ?, ?, ?, ?)",
+			track.FileName, track.Title, track.AlbumName, track.ArtistName, track.CueTrack, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert detached track: %w", err)
+		}
+		detachedID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get detached track ID: %w", err)
+		}
+
+		copies := []struct{ table, query string }{
+			{"lyrics_history", "INSERT INTO detached_lyrics_history (detached_track_id, " + historyColumns + ") SELECT ?, " + historyColumns + " FROM lyrics_history WHERE track_id = ? ORDER BY id"},
+			{"track_lyrics_source", "INSERT INTO detached_lyrics_source (detached_track_id, " + sourceColumns + ") SELECT ?, " + sourceColumns + " FROM track_lyrics_source WHERE track_id = ?"},
+		}
+		for _, statement := range copies {
+			if _, err := tx.Exec(statement.query, detachedID, track.ID); err != nil {
+				return fmt.Errorf("failed to detach %s: %w", statement.table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// relinkDetachedTrack gives a new track the lyrics history and source of the
+// most recently detached track with the same tags, preferring one with the
+// same file name, and reports whether there was one
+func relinkDetachedTrack(q queryer, track *PersistentTrack, trackID int64) (bool, error) {
+	var detachedID int64
+	err := q.QueryRow(`
+		SELECT id FROM detached_tracks
+		WHERE title = ? AND album_name = ? AND artist_name = ? AND cue_track = ?
+		ORDER BY file_name = ? DESC, id DESC
+		LIMIT 1
+	`, track.Title, track.AlbumName, track.ArtistName, track.CueTrack, track.FileName).Scan(&detachedID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to query detached track: %w", err)
+	}
+
+	copies := []struct{ table, query string }{
+		{"lyrics_history", "INSERT INTO lyrics_history (track_id, " + historyColumns + ") SELECT ?, " + historyColumns + " FROM detached_lyrics_history WHERE detached_track_id = ? ORDER BY id"},
+		{"track_lyrics_source", "INSERT OR REPLACE INTO track_lyrics_source (track_id, " + sourceColumns + ") SELECT ?, " + sourceColumns + " FROM detached_lyrics_source WHERE detached_track_id = ?"},
+	}
+	for _, statement := range copies {
+		if _, err := q.Exec(statement.query, trackID, detachedID); err != nil {
+			return false, fmt.Errorf("failed to relink %s: %w", statement.table, err)
+		}
+	}
+
+	if err := deleteDetachedRows(q, "id = ?", detachedID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteDetachedRows removes the detached tracks matching a condition with
+// their lyrics history and source
+func deleteDetachedRows(q queryer, where string, args ...any) error {
+	for _, table := range []string{"detached_lyrics_history", "detached_lyrics_source"} {
+		_, err := q.Exec("DELETE FROM "+table+" WHERE detached_track_id IN (SELECT id FROM detached_tracks WHERE "+where+")", args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	_, err := q.Exec("DELETE FROM detached_tracks WHERE "+where, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete detached tracks: %w", err)
+	}
+	return nil
+}
+
+// PurgeDetachedTracks removes the lyrics history and source of the tracks
+// detached before a time, which are not expected to show up again
+func (c *Connection) PurgeDetachedTracks(before time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteDetachedRows(tx, "detached_at < ?", before); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SaveTrackLyrics replaces a track's lyrics and records the new version in the
+// lyrics history. If the track has no history yet, its current lyrics are saved
+// first so that the previous version is never lost.
+func (c *Connection) SaveTrackLyrics(trackID int64, lrcLyrics, txtLyrics *string, instrumental bool, source string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := snapshotTrackLyrics(tx, trackID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result, err := tx.Exec(
+		"UPDATE tracks SET lrc_lyrics = ?, txt_lyrics = ?, instrumental = ?, updated_at = ? WHERE id = ?",
+		lrcLyrics, txtLyrics, instrumental, now, trackID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update track lyrics: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("track with ID %d not found", trackID)
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO lyrics_history (track_id, lrc_lyrics, txt_lyrics, instrumental, source, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		trackID, lrcLyrics, txtLyrics, instrumental, source, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert lyrics history: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// snapshotTrackLyrics records a track's current lyrics as its first history entry
+// if it has no history yet
+func snapshotTrackLyrics(tx *sql.Tx, trackID int64) error {
+	var count int
+	err := tx.QueryRow("SELECT COUNT(*) FROM lyrics_history WHERE track_id = ?", trackID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to count lyrics history: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var lrcLyrics, txtLyrics sql.NullString
+	var instrumental sql.NullBool
+	var updatedAt sql.NullTime
+	err = tx.QueryRow(
+		"SELECT lrc_lyrics, txt_lyrics, instrumental, updated_at FROM tracks WHERE id = ?", trackID,
+	).Scan(&lrcLyrics, &txtLyrics, &instrumental, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("track with ID %d not found", trackID)
+		}
+		return fmt.Errorf("failed to get track lyrics: %w", err)
+	}
+
+	// Nothing worth keeping
+	if !lrcLyrics.Valid && !txtLyrics.Valid && !instrumental.Bool {
+		return nil
+	}
+
+	createdAt := time.Now()
+	if updatedAt.Valid {
+		createdAt = updatedAt.Time
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO lyrics_history (track_id, lrc_lyrics, txt_lyrics, instrumental, source, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		trackID, lrcLyrics, txtLyrics, instrumental.Bool, HistorySourceScan, createdAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert lyrics history: %w", err)
+	}
+
+	return nil
+}
+
+// GetLyricsHistory retrieves all saved lyrics versions of a track, newest first
+func (c *Connection) GetLyricsHistory(trackID int64) ([]PersistentLyricsHistory, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := `
+		SELECT id, track_id, lrc_lyrics, txt_lyrics, instrumental, source, created_at
+		FROM lyrics_history
+		WHERE track_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := c.db.Query(query, trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lyrics history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []PersistentLyricsHistory
+	for rows.Next() {
+		var entry PersistentLyricsHistory
+		err := rows.Scan(
+			&entry.ID, &entry.TrackID, &entry.LrcLyrics, &entry.TxtLyrics,
+			&entry.Instrumental, &entry.Source, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lyrics history: %w", err)
+		}
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+// GetLyricsHistoryByID retrieves a single saved lyrics version
+func (c *Connection) GetLyricsHistoryByID(id int64) (*PersistentLyricsHistory, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := `
+		SELECT id, track_id, lrc_lyrics, txt_lyrics, instrumental, source, created_at
+		FROM lyrics_history
+		WHERE id = ?
+	`
+
+	var entry PersistentLyricsHistory
+	err := c.db.QueryRow(query, id).Scan(
+		&entry.ID, &entry.TrackID, &entry.LrcLyrics, &entry.TxtLyrics,
+		&entry.Instrumental, &entry.Source, &entry.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("lyrics history with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get lyrics history: %w", err)
+	}
+
+	return &entry, nil
+}
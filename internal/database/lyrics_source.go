@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetLyricsSource retrieves the LRCLIB record a track's lyrics were downloaded from
+func (c *Connection) GetLyricsSource(trackID int64) (*PersistentLyricsSource, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := `
+		SELECT track_id, lrclib_id, name, lang, isrc, spotify_id, release_date,
+		       downloaded_at, created_at, updated_at
+		FROM track_lyrics_source
+		WHERE track_id = ?
+	`
+
+	var source PersistentLyricsSource
+	err := c.db.QueryRow(query, trackID).Scan(
+		&source.TrackID, &source.LrclibID, &source.Name, &source.Lang,
+		&source.Isrc, &source.SpotifyID, &source.ReleaseDate,
+		&source.DownloadedAt, &source.CreatedAt, &source.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("lyrics source for track %d not found", trackID)
+		}
+		return nil, fmt.Errorf("failed to get lyrics source: %w", err)
+	}
+
+	return &source, nil
+}
+
+// SetLyricsSource records the LRCLIB record a track's lyrics were downloaded from,
+// replacing any previously recorded source
+func (c *Connection) SetLyricsSource(source *PersistentLyricsSource) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	query := `
+		INSERT INTO track_lyrics_source (track_id, lrclib_id, name, lang, isrc, spotify_id,
+		                                 release_date, downloaded_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(track_id) DO UPDATE SET
+			lrclib_id = excluded.lrclib_id,
+			name = excluded.name,
+			lang = excluded.lang,
+			isrc = excluded.isrc,
+			spotify_id = excluded.spotify_id,
+			release_date = excluded.release_date,
+			downloaded_at = excluded.downloaded_at,
+			updated_at = excluded.updated_at
+	`
+
+	now := time.Now()
+	if source.DownloadedAt.IsZero() {
+		source.DownloadedAt = now
+	}
+
+	_, err := c.db.Exec(query,
+		source.TrackID, source.LrclibID, source.Name, source.Lang, source.Isrc,
+		source.SpotifyID, source.ReleaseDate, source.DownloadedAt, now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set lyrics source: %w", err)
+	}
+
+	source.UpdatedAt = now
+	return nil
+}
+
+// GetLyricsSourcesDownloadedBefore retrieves all lyrics sources downloaded before the given time
+func (c *Connection) GetLyricsSourcesDownloadedBefore(cutoff time.Time) ([]PersistentLyricsSource, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := `
+		SELECT track_id, lrclib_id, name, lang, isrc, spotify_id, release_date,
+		       downloaded_at, created_at, updated_at
+		FROM track_lyrics_source
+		WHERE downloaded_at < ?
+		ORDER BY downloaded_at
+	`
+
+	rows, err := c.db.Query(query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lyrics sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []PersistentLyricsSource
+	for rows.Next() {
+		var source PersistentLyricsSource
+		err := rows.Scan(
+			&source.TrackID, &source.LrclibID, &source.Name, &source.Lang,
+			&source.Isrc, &source.SpotifyID, &source.ReleaseDate,
+			&source.DownloadedAt, &source.CreatedAt, &source.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lyrics source: %w", err)
+		}
+		sources = append(sources, source)
+	}
+
+	return sources, nil
+}
@@ -26,37 +26,126 @@ func (c *Connection) upgradeDatabase(fromVersion int) error {
 
 	if fromVersion <= 0 {
 		fmt.Println("Migrate database version 1...")
-		return c.migrateToVersion1()
+		if err := c.migrateToVersion1(); err != nil {
+			return err
+		}
+
+		// The version 1 schema already has every column added by versions 2-7,
+		// 12, 13, 15 and 16, so new installations only need the remaining tables and indexes
+		fmt.Println("Create initial schema...")
+		return c.createInitialSchema()
 	}
 
 	if fromVersion <= 1 {
 		fmt.Println("Migrate database version 2...")
-		return c.migrateToVersion2()
+		if err := c.migrateToVersion2(); err != nil {
+			return err
+		}
 	}
 
 	if fromVersion <= 2 {
 		fmt.Println("Migrate database version 3...")
-		return c.migrateToVersion3()
+		if err := c.migrateToVersion3(); err != nil {
+			return err
+		}
 	}
 
 	if fromVersion <= 3 {
 		fmt.Println("Migrate database version 4...")
-		return c.migrateToVersion4()
+		if err := c.migrateToVersion4(); err != nil {
+			return err
+		}
 	}
 
 	if fromVersion <= 4 {
 		fmt.Println("Migrate database version 5...")
-		return c.migrateToVersion5()
+		if err := c.migrateToVersion5(); err != nil {
+			return err
+		}
 	}
 
 	if fromVersion <= 5 {
 		fmt.Println("Migrate database version 6...")
-		return c.migrateToVersion6()
+		if err := c.migrateToVersion6(); err != nil {
+			return err
+		}
 	}
 
 	if fromVersion <= 6 {
 		fmt.Println("Migrate database version 7...")
-		return c.migrateToVersion7()
+		if err := c.migrateToVersion7(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 7 {
+		fmt.Println("Migrate database version 8...")
+		if err := c.migrateToVersion8(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 8 {
+		fmt.Println("Migrate database version 9...")
+		if err := c.migrateToVersion9(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 9 {
+		fmt.Println("Migrate database version 10...")
+		if err := c.migrateToVersion10(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 10 {
+		fmt.Println("Migrate database version 11...")
+		if err := c.migrateToVersion11(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 11 {
+		fmt.Println("Migrate database version 12...")
+		if err := c.migrateToVersion12(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 12 {
+		fmt.Println("Migrate database version 13...")
+		if err := c.migrateToVersion13(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 13 {
+		fmt.Println("Migrate database version 14...")
+		if err := c.migrateToVersion14(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 14 {
+		fmt.Println("Migrate database version 15...")
+		if err := c.migrateToVersion15(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 15 {
+		fmt.Println("Migrate database version 16...")
+		if err := c.migrateToVersion16(); err != nil {
+			return err
+		}
+	}
+
+	if fromVersion <= 16 {
+		fmt.Println("Migrate database version 17...")
+		if err := c.migrateToVersion17(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -87,6 +176,7 @@ func (c *Connection) migrateToVersion1() error {
 	CREATE TABLE directories (
 		id INTEGER PRIMARY KEY,
 		path TEXT,
+		scan_patterns TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -107,6 +197,11 @@ func (c *Connection) migrateToVersion1() error {
 		show_line_count BOOLEAN DEFAULT 1,
 		theme_mode TEXT DEFAULT 'system',
 		lrclib_instance TEXT DEFAULT 'https://lrclib.net',
+		skip_hidden_files BOOLEAN NOT NULL DEFAULT 1,
+		skip_small_files BOOLEAN NOT NULL DEFAULT 1,
+		follow_symlinks BOOLEAN NOT NULL DEFAULT 0,
+		cue_lyrics_layout TEXT NOT NULL DEFAULT '{file}.{track}',
+		artist_separators TEXT NOT NULL DEFAULT '[]',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -150,6 +245,9 @@ func (c *Connection) migrateToVersion1() error {
 		lrc_lyrics TEXT,
 		instrumental BOOLEAN,
 		title_lower TEXT,
+		cue_track INTEGER NOT NULL DEFAULT 0,
+		start_offset REAL,
+		end_offset REAL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY(artist_id) REFERENCES artists(id),
@@ -522,6 +620,456 @@ func (c *Connection) migrateToVersion7() error {
 	return tx.Commit()
 }
 
+// migrateToVersion8 adds the track_lyrics_source table
+func (c *Connection) migrateToVersion8() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 8")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE track_lyrics_source (
+		track_id INTEGER PRIMARY KEY,
+		lrclib_id INTEGER NOT NULL,
+		name TEXT,
+		lang TEXT,
+		isrc TEXT,
+		spotify_id TEXT,
+		release_date TEXT,
+		downloaded_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(track_id) REFERENCES tracks(id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create track_lyrics_source table: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE INDEX idx_track_lyrics_source_lrclib_id ON track_lyrics_source(lrclib_id)")
+	if err != nil {
+		return fmt.Errorf("failed to create track_lyrics_source lrclib_id index: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE INDEX idx_track_lyrics_source_lang ON track_lyrics_source(lang)")
+	if err != nil {
+		return fmt.Errorf("failed to create track_lyrics_source lang index: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion9 adds the lyrics_history table
+func (c *Connection) migrateToVersion9() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 9")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE lyrics_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		track_id INTEGER NOT NULL,
+		lrc_lyrics TEXT,
+		txt_lyrics TEXT,
+		instrumental BOOLEAN NOT NULL DEFAULT FALSE,
+		source TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(track_id) REFERENCES tracks(id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create lyrics_history table: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE INDEX idx_lyrics_history_track_id ON lyrics_history(track_id)")
+	if err != nil {
+		return fmt.Errorf("failed to create lyrics_history track_id index: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion10 adds the playback_queue and playback_queue_state tables
+func (c *Connection) migrateToVersion10() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 10")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE playback_queue (
+		position INTEGER PRIMARY KEY,
+		track_id INTEGER NOT NULL,
+		play_order INTEGER NOT NULL,
+		FOREIGN KEY(track_id) REFERENCES tracks(id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create playback_queue table: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE playback_queue_state (
+		id INTEGER PRIMARY KEY,
+		current_position INTEGER,
+		repeat_mode TEXT NOT NULL DEFAULT 'off',
+		shuffle BOOLEAN NOT NULL DEFAULT FALSE,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create playback_queue_state table: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion11 removes duplicate tracks of the same file and adds a
+// unique index on tracks.file_path. The history and queue entries of a
+// duplicate are moved to the track that is kept.
+func (c *Connection) migrateToVersion11() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 11")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TEMP TABLE duplicate_tracks AS
+	SELECT t.id AS id, k.keep_id AS keep_id
+	FROM tracks t
+	JOIN (SELECT file_path, MIN(id) AS keep_id FROM tracks GROUP BY file_path HAVING COUNT(*) > 1) k
+	ON t.file_path = k.file_path
+	WHERE t.id <> k.keep_id`)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicate tracks: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	UPDATE lyrics_history
+	SET track_id = (SELECT keep_id FROM duplicate_tracks WHERE id = lyrics_history.track_id)
+	WHERE track_id IN (SELECT id FROM duplicate_tracks)`)
+	if err != nil {
+		return fmt.Errorf("failed to move lyrics history: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	UPDATE playback_queue
+	SET track_id = (SELECT keep_id FROM duplicate_tracks WHERE id = playback_queue.track_id)
+	WHERE track_id IN (SELECT id FROM duplicate_tracks)`)
+	if err != nil {
+		return fmt.Errorf("failed to move queue entries: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM track_lyrics_source WHERE track_id IN (SELECT id FROM duplicate_tracks)")
+	if err != nil {
+		return fmt.Errorf("failed to delete lyrics sources: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM tracks WHERE id IN (SELECT id FROM duplicate_tracks)")
+	if err != nil {
+		return fmt.Errorf("failed to delete duplicate tracks: %w", err)
+	}
+
+	_, err = tx.Exec("DROP TABLE duplicate_tracks")
+	if err != nil {
+		return fmt.Errorf("failed to drop duplicate_tracks table: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE UNIQUE INDEX idx_tracks_file_path ON tracks(file_path)")
+	if err != nil {
+		return fmt.Errorf("failed to create tracks file_path index: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion12 adds the scan patterns of directories and the hidden and
+// small file options
+func (c *Connection) migrateToVersion12() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 12")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec("ALTER TABLE directories ADD COLUMN scan_patterns TEXT")
+	if err != nil {
+		return fmt.Errorf("failed to add scan_patterns column: %w", err)
+	}
+
+	_, err = tx.Exec("ALTER TABLE config_data ADD COLUMN skip_hidden_files BOOLEAN NOT NULL DEFAULT 1")
+	if err != nil {
+		return fmt.Errorf("failed to add skip_hidden_files column: %w", err)
+	}
+
+	_, err = tx.Exec("ALTER TABLE config_data ADD COLUMN skip_small_files BOOLEAN NOT NULL DEFAULT 1")
+	if err != nil {
+		return fmt.Errorf("failed to add skip_small_files column: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion13 adds the option to follow symbolic links to directories
+func (c *Connection) migrateToVersion13() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 13")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec("ALTER TABLE config_data ADD COLUMN follow_symlinks BOOLEAN NOT NULL DEFAULT 0")
+	if err != nil {
+		return fmt.Errorf("failed to add follow_symlinks column: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion14 adds the scan_reports and scan_report_entries tables
+func (c *Connection) migrateToVersion14() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 14")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE scan_reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		tracks INTEGER NOT NULL DEFAULT 0,
+		error TEXT
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create scan_reports table: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE scan_report_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		message TEXT,
+		FOREIGN KEY(report_id) REFERENCES scan_reports(id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create scan_report_entries table: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE INDEX idx_scan_report_entries_report_id ON scan_report_entries(report_id)")
+	if err != nil {
+		return fmt.Errorf("failed to create scan_report_entries report_id index: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion15 adds the CUE track and offsets of tracks, so that a file
+// split by a CUE sheet is indexed as several tracks, and the layout of their
+// lyrics files
+func (c *Connection) migrateToVersion15() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 15")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	columns := []string{
+		"ALTER TABLE tracks ADD COLUMN cue_track INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE tracks ADD COLUMN start_offset REAL",
+		"ALTER TABLE tracks ADD COLUMN end_offset REAL",
+		"ALTER TABLE config_data ADD COLUMN cue_lyrics_layout TEXT NOT NULL DEFAULT '{file}.{track}'",
+	}
+	for _, column := range columns {
+		if _, err := tx.Exec(column); err != nil {
+			return fmt.Errorf("failed to add column: %w", err)
+		}
+	}
+
+	// A file now holds one track per CUE track
+	_, err = tx.Exec("DROP INDEX idx_tracks_file_path")
+	if err != nil {
+		return fmt.Errorf("failed to drop tracks file_path index: %w", err)
+	}
+	_, err = tx.Exec("CREATE UNIQUE INDEX idx_tracks_file_path ON tracks(file_path, cue_track)")
+	if err != nil {
+		return fmt.Errorf("failed to create tracks file_path index: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion16 adds the artists credited on each track with their role,
+// so that a tag such as "A feat. B" credits both artists, and the separators
+// used to split artist tags, none by default
+func (c *Connection) migrateToVersion16() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 16")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec(`ALTER TABLE config_data ADD COLUMN artist_separators TEXT NOT NULL DEFAULT '[]'`)
+	if err != nil {
+		return fmt.Errorf("failed to add column: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE track_artists (
+		track_id INTEGER NOT NULL,
+		artist_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY(track_id, artist_id, role),
+		FOREIGN KEY(track_id) REFERENCES tracks(id),
+		FOREIGN KEY(artist_id) REFERENCES artists(id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create track_artists table: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE INDEX idx_track_artists_artist_id ON track_artists(artist_id)")
+	if err != nil {
+		return fmt.Errorf("failed to create track_artists artist_id index: %w", err)
+	}
+
+	// Existing tracks credit the artist they are grouped under, and keep
+	// their album until RebuildTrackArtists splits their tags
+	_, err = tx.Exec(`
+	INSERT INTO track_artists (track_id, artist_id, role, position)
+	SELECT id, artist_id, 'primary', 0 FROM tracks WHERE artist_id IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to insert track artists: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// migrateToVersion17 adds the detached_tracks table and the tables keeping
+// the lyrics history and source of tracks whose file disappeared, until they
+// show up again
+func (c *Connection) migrateToVersion17() error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("PRAGMA user_version = 17")
+	if err != nil {
+		return fmt.Errorf("failed to set user version: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE detached_tracks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_name TEXT NOT NULL,
+		title TEXT NOT NULL,
+		album_name TEXT NOT NULL,
+		artist_name TEXT NOT NULL,
+		cue_track INTEGER NOT NULL DEFAULT 0,
+		detached_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create detached_tracks table: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE detached_lyrics_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		detached_track_id INTEGER NOT NULL,
+		lrc_lyrics TEXT,
+		txt_lyrics TEXT,
+		instrumental BOOLEAN NOT NULL DEFAULT FALSE,
+		source TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(detached_track_id) REFERENCES detached_tracks(id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create detached_lyrics_history table: %w", err)
+	}
+
+	_, err = tx.Exec(`
+	CREATE TABLE detached_lyrics_source (
+		detached_track_id INTEGER PRIMARY KEY,
+		lrclib_id INTEGER NOT NULL,
+		name TEXT,
+		lang TEXT,
+		isrc TEXT,
+		spotify_id TEXT,
+		release_date TEXT,
+		downloaded_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(detached_track_id) REFERENCES detached_tracks(id)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create detached_lyrics_source table: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE INDEX idx_detached_tracks_title ON detached_tracks(title, artist_name, album_name)")
+	if err != nil {
+		return fmt.Errorf("failed to create detached_tracks title index: %w", err)
+	}
+
+	_, err = tx.Exec("CREATE INDEX idx_detached_lyrics_history_detached_track_id ON detached_lyrics_history(detached_track_id)")
+	if err != nil {
+		return fmt.Errorf("failed to create detached_lyrics_history detached_track_id index: %w", err)
+	}
+
+	return tx.Commit()
+}
+
 // createInitialSchema creates the complete current schema (for new installations)
 func (c *Connection) createInitialSchema() error {
 	schema := `
@@ -544,6 +1092,9 @@ func (c *Connection) createInitialSchema() error {
 		duration REAL NOT NULL,
 		instrumental BOOLEAN NOT NULL DEFAULT FALSE,
 		title_lower TEXT,
+		cue_track INTEGER NOT NULL DEFAULT 0,
+		start_offset REAL,
+		end_offset REAL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (album_id) REFERENCES albums(id),
@@ -573,6 +1124,7 @@ func (c *Connection) createInitialSchema() error {
 	CREATE TABLE IF NOT EXISTS directories (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		path TEXT NOT NULL UNIQUE,
+		scan_patterns TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -585,6 +1137,11 @@ func (c *Connection) createInitialSchema() error {
 		try_embed_lyrics BOOLEAN NOT NULL DEFAULT FALSE,
 		theme_mode TEXT NOT NULL DEFAULT 'system',
 		lrclib_instance TEXT NOT NULL DEFAULT 'https://lrclib.net',
+		skip_hidden_files BOOLEAN NOT NULL DEFAULT TRUE,
+		skip_small_files BOOLEAN NOT NULL DEFAULT TRUE,
+		follow_symlinks BOOLEAN NOT NULL DEFAULT FALSE,
+		cue_lyrics_layout TEXT NOT NULL DEFAULT '{file}.{track}',
+		artist_separators TEXT NOT NULL DEFAULT '[]',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -596,7 +1153,111 @@ func (c *Connection) createInitialSchema() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 	
+	CREATE TABLE IF NOT EXISTS track_lyrics_source (
+		track_id INTEGER PRIMARY KEY,
+		lrclib_id INTEGER NOT NULL,
+		name TEXT,
+		lang TEXT,
+		isrc TEXT,
+		spotify_id TEXT,
+		release_date TEXT,
+		downloaded_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (track_id) REFERENCES tracks(id)
+	);
+	
+	CREATE TABLE IF NOT EXISTS lyrics_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		track_id INTEGER NOT NULL,
+		lrc_lyrics TEXT,
+		txt_lyrics TEXT,
+		instrumental BOOLEAN NOT NULL DEFAULT FALSE,
+		source TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (track_id) REFERENCES tracks(id)
+	);
+	
+	CREATE TABLE IF NOT EXISTS playback_queue (
+		position INTEGER PRIMARY KEY,
+		track_id INTEGER NOT NULL,
+		play_order INTEGER NOT NULL,
+		FOREIGN KEY (track_id) REFERENCES tracks(id)
+	);
+	
+	CREATE TABLE IF NOT EXISTS playback_queue_state (
+		id INTEGER PRIMARY KEY,
+		current_position INTEGER,
+		repeat_mode TEXT NOT NULL DEFAULT 'off',
+		shuffle BOOLEAN NOT NULL DEFAULT FALSE,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	
+	CREATE TABLE IF NOT EXISTS scan_reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME NOT NULL,
+		tracks INTEGER NOT NULL DEFAULT 0,
+		error TEXT
+	);
+	
+	CREATE TABLE IF NOT EXISTS scan_report_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		report_id INTEGER NOT NULL,
+		path TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		message TEXT,
+		FOREIGN KEY (report_id) REFERENCES scan_reports(id)
+	);
+	
+	CREATE TABLE IF NOT EXISTS track_artists (
+		track_id INTEGER NOT NULL,
+		artist_id INTEGER NOT NULL,
+		role TEXT NOT NULL,
+		position INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (track_id, artist_id, role),
+		FOREIGN KEY (track_id) REFERENCES tracks(id),
+		FOREIGN KEY (artist_id) REFERENCES artists(id)
+	);
+	
+	CREATE TABLE IF NOT EXISTS detached_tracks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_name TEXT NOT NULL,
+		title TEXT NOT NULL,
+		album_name TEXT NOT NULL,
+		artist_name TEXT NOT NULL,
+		cue_track INTEGER NOT NULL DEFAULT 0,
+		detached_at DATETIME NOT NULL
+	);
+	
+	CREATE TABLE IF NOT EXISTS detached_lyrics_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		detached_track_id INTEGER NOT NULL,
+		lrc_lyrics TEXT,
+		txt_lyrics TEXT,
+		instrumental BOOLEAN NOT NULL DEFAULT FALSE,
+		source TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (detached_track_id) REFERENCES detached_tracks(id)
+	);
+	
+	CREATE TABLE IF NOT EXISTS detached_lyrics_source (
+		detached_track_id INTEGER PRIMARY KEY,
+		lrclib_id INTEGER NOT NULL,
+		name TEXT,
+		lang TEXT,
+		isrc TEXT,
+		spotify_id TEXT,
+		release_date TEXT,
+		downloaded_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (detached_track_id) REFERENCES detached_tracks(id)
+	);
+	
 	-- Create indexes
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_tracks_file_path ON tracks(file_path, cue_track);
 	CREATE INDEX IF NOT EXISTS idx_tracks_title ON tracks(title);
 	CREATE INDEX IF NOT EXISTS idx_tracks_title_lower ON tracks(title_lower);
 	CREATE INDEX IF NOT EXISTS idx_tracks_track_number ON tracks(track_number);
@@ -605,13 +1266,20 @@ func (c *Connection) createInitialSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_albums_album_artist_name_lower ON albums(album_artist_name_lower);
 	CREATE INDEX IF NOT EXISTS idx_artists_name ON artists(name);
 	CREATE INDEX IF NOT EXISTS idx_artists_name_lower ON artists(name_lower);
+	CREATE INDEX IF NOT EXISTS idx_track_lyrics_source_lrclib_id ON track_lyrics_source(lrclib_id);
+	CREATE INDEX IF NOT EXISTS idx_track_lyrics_source_lang ON track_lyrics_source(lang);
+	CREATE INDEX IF NOT EXISTS idx_lyrics_history_track_id ON lyrics_history(track_id);
+	CREATE INDEX IF NOT EXISTS idx_scan_report_entries_report_id ON scan_report_entries(report_id);
+	CREATE INDEX IF NOT EXISTS idx_track_artists_artist_id ON track_artists(artist_id);
+	CREATE INDEX IF NOT EXISTS idx_detached_tracks_title ON detached_tracks(title, artist_name, album_name);
+	CREATE INDEX IF NOT EXISTS idx_detached_lyrics_history_detached_track_id ON detached_lyrics_history(detached_track_id);
 	
 	-- Insert default data
 	INSERT OR IGNORE INTO library_data (id, init) VALUES (1, 0);
 	INSERT OR IGNORE INTO config_data (id, skip_tracks_with_synced_lyrics, skip_tracks_with_plain_lyrics, show_line_count, try_embed_lyrics, theme_mode, lrclib_instance) 
 	VALUES (1, 1, 0, 1, 0, 'system', 'https://lrclib.net');
 	
-	PRAGMA user_version = 7;
+	PRAGMA user_version = 17;
 	`
 
 	_, err := c.db.Exec(schema)
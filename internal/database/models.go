@@ -22,8 +22,22 @@ type PersistentTrack struct {
 	Duration           float64 `json:"duration" db:"duration"`
 	Instrumental       bool    `json:"instrumental" db:"instrumental"`
 	TitleLower         *string `json:"title_lower" db:"title_lower"`
+	CueTrack           int64    `json:"cue_track" db:"cue_track"`
+	StartOffset        *float64 `json:"start_offset" db:"start_offset"`
+	EndOffset          *float64 `json:"end_offset" db:"end_offset"`
 	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at" db:"updated_at"`
+
+	// KeepCueTracks marks the whole file read in place of a CUE sheet that
+	// could not be read, so that the tracks of the sheet read before are kept
+	KeepCueTracks bool `json:"-" db:"-"`
+}
+
+// TrackArtist is an artist credited on a track, with its role
+type TrackArtist struct {
+	ArtistID int64  `json:"artist_id" db:"artist_id"`
+	Name     string `json:"name" db:"name"`
+	Role     string `json:"role" db:"role"`
 }
 
 // PersistentAlbum represents an album in the database
@@ -59,14 +73,102 @@ type PersistentConfig struct {
 	TryEmbedLyrics               bool   `json:"try_embed_lyrics" db:"try_embed_lyrics"`
 	ThemeMode                    string `json:"theme_mode" db:"theme_mode"`
 	LrclibInstance               string `json:"lrclib_instance" db:"lrclib_instance"`
+	SkipHiddenFiles              bool   `json:"skip_hidden_files" db:"skip_hidden_files"`
+	SkipSmallFiles               bool   `json:"skip_small_files" db:"skip_small_files"`
+	FollowSymlinks               bool   `json:"follow_symlinks" db:"follow_symlinks"`
+	CueLyricsLayout              string `json:"cue_lyrics_layout" db:"cue_lyrics_layout"`
+	ArtistSeparators             []string `json:"artist_separators" db:"artist_separators"`
 	CreatedAt                    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt                    time.Time `json:"updated_at" db:"updated_at"`
 }
 
-// PersistentDirectory represents a directory in the database
+// PersistentLyricsSource represents the LRCLIB record a track's lyrics were downloaded from
+type PersistentLyricsSource struct {
+	TrackID      int64     `json:"track_id" db:"track_id"`
+	LrclibID     int64     `json:"lrclib_id" db:"lrclib_id"`
+	Name         *string   `json:"name" db:"name"`
+	Lang         *string   `json:"lang" db:"lang"`
+	Isrc         *string   `json:"isrc" db:"isrc"`
+	SpotifyID    *string   `json:"spotify_id" db:"spotify_id"`
+	ReleaseDate  *string   `json:"release_date" db:"release_date"`
+	DownloadedAt time.Time `json:"downloaded_at" db:"downloaded_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Lyrics history sources
+const (
+	HistorySourceScan     = "scan"
+	HistorySourceDownload = "download"
+	HistorySourceEdit     = "edit"
+	HistorySourceImport   = "import"
+	HistorySourceAuto     = "auto"
+)
+
+// PersistentLyricsHistory represents a saved version of a track's lyrics
+type PersistentLyricsHistory struct {
+	ID           int64     `json:"id" db:"id"`
+	TrackID      int64     `json:"track_id" db:"track_id"`
+	LrcLyrics    *string   `json:"lrc_lyrics" db:"lrc_lyrics"`
+	TxtLyrics    *string   `json:"txt_lyrics" db:"txt_lyrics"`
+	Instrumental bool      `json:"instrumental" db:"instrumental"`
+	Source       string    `json:"source" db:"source"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// PersistentQueueEntry represents a track in the playback queue. Position is
+// the order the track was queued in, PlayOrder the order it is played in.
+type PersistentQueueEntry struct {
+	Position  int   `json:"position" db:"position"`
+	TrackID   int64 `json:"track_id" db:"track_id"`
+	PlayOrder int   `json:"play_order" db:"play_order"`
+}
+
+// PersistentQueue represents the saved playback queue
+type PersistentQueue struct {
+	Entries         []PersistentQueueEntry `json:"entries"`
+	CurrentPosition *int                   `json:"current_position" db:"current_position"`
+	RepeatMode      string                 `json:"repeat_mode" db:"repeat_mode"`
+	Shuffle         bool                   `json:"shuffle" db:"shuffle"`
+	UpdatedAt       time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// Scan report entry kinds
+const (
+	ScanEntrySkipped = "skipped"
+	ScanEntryError   = "error"
+)
+
+// PersistentScanReport represents the outcome of a library scan. Error is set
+// if the scan did not complete; Entries are only loaded for a single report.
+type PersistentScanReport struct {
+	ID           int64                       `json:"id" db:"id"`
+	StartedAt    time.Time                   `json:"started_at" db:"started_at"`
+	FinishedAt   time.Time                   `json:"finished_at" db:"finished_at"`
+	Tracks       int                         `json:"tracks" db:"tracks"`
+	Error        *string                     `json:"error" db:"error"`
+	SkippedCount int                         `json:"skipped_count"`
+	ErrorCount   int                         `json:"error_count"`
+	Entries      []PersistentScanReportEntry `json:"entries"`
+}
+
+// PersistentScanReportEntry represents a path a scan left out (kind skipped)
+// or failed to read (kind error)
+type PersistentScanReportEntry struct {
+	Path    string  `json:"path" db:"path"`
+	Kind    string  `json:"kind" db:"kind"`
+	Reason  string  `json:"reason" db:"reason"`
+	Message *string `json:"message" db:"message"`
+}
+
+// PersistentDirectory represents a directory in the database. Include and
+// Exclude are the glob patterns selecting the files scanned in it, stored
+// together in the scan_patterns column.
 type PersistentDirectory struct {
 	ID        int64     `json:"id" db:"id"`
 	Path      string    `json:"path" db:"path"`
+	Include   []string  `json:"include"`
+	Exclude   []string  `json:"exclude"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
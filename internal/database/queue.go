@@ -0,0 +1,85 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetQueue retrieves the saved playback queue. An empty queue is returned if
+// none has been saved.
+func (c *Connection) GetQueue() (*PersistentQueue, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	queue := &PersistentQueue{Entries: []PersistentQueueEntry{}, RepeatMode: "off"}
+	err := c.db.QueryRow(`
+		SELECT current_position, repeat_mode, shuffle, updated_at
+		FROM playback_queue_state
+		WHERE id = 1
+	`).Scan(&queue.CurrentPosition, &queue.RepeatMode, &queue.Shuffle, &queue.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get queue state: %w", err)
+	}
+
+	rows, err := c.db.Query("SELECT position, track_id, play_order FROM playback_queue ORDER BY position")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry PersistentQueueEntry
+		if err := rows.Scan(&entry.Position, &entry.TrackID, &entry.PlayOrder); err != nil {
+			return nil, fmt.Errorf("failed to scan queue entry: %w", err)
+		}
+		queue.Entries = append(queue.Entries, entry)
+	}
+
+	return queue, rows.Err()
+}
+
+// SaveQueue replaces the saved playback queue
+func (c *Connection) SaveQueue(queue *PersistentQueue) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM playback_queue"); err != nil {
+		return fmt.Errorf("failed to clear queue: %w", err)
+	}
+
+	for _, entry := range queue.Entries {
+		_, err := tx.Exec("INSERT INTO playback_queue (position, track_id, play_order) VALUES (?, ?, ?)",
+			entry.Position, entry.TrackID, entry.PlayOrder)
+		if err != nil {
+			return fmt.Errorf("failed to save queue entry: %w", err)
+		}
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO playback_queue_state (id, current_position, repeat_mode, shuffle, updated_at)
+		VALUES (1, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			current_position = excluded.current_position,
+			repeat_mode = excluded.repeat_mode,
+			shuffle = excluded.shuffle,
+			updated_at = excluded.updated_at
+	`, queue.CurrentPosition, queue.RepeatMode, queue.Shuffle, now)
+	if err != nil {
+		return fmt.Errorf("failed to save queue state: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	queue.UpdatedAt = now
+	return nil
+}
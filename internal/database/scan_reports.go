@@ -0,0 +1,143 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// maxScanReports is the number of scan reports kept, older ones are removed
+const maxScanReports = 10
+
+// SaveScanReport records the report of a library scan with its entries, and
+// removes the oldest reports beyond the ones kept
+func (c *Connection) SaveScanReport(report *PersistentScanReport) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO scan_reports (started_at, finished_at, tracks, error) VALUES (?, ?, ?, ?)",
+		report.StartedAt, report.FinishedAt, report.Tracks, report.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert scan report: %w", err)
+	}
+
+	reportID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get scan report ID: %w", err)
+	}
+
+	insertEntry, err := tx.Prepare("INSERT INTO scan_report_entries (report_id, path, kind, reason, message) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare scan report entry statement: %w", err)
+	}
+	defer insertEntry.Close()
+
+	for _, entry := range report.Entries {
+		if _, err := insertEntry.Exec(reportID, entry.Path, entry.Kind, entry.Reason, entry.Message); err != nil {
+			return fmt.Errorf("failed to insert scan report entry: %w", err)
+		}
+	}
+
+	// Keep the newest reports only
+	_, err = tx.Exec(`
+		DELETE FROM scan_report_entries WHERE report_id NOT IN (
+			SELECT id FROM scan_reports ORDER BY id DESC LIMIT ?
+		)`, maxScanReports)
+	if err != nil {
+		return fmt.Errorf("failed to delete old scan report entries: %w", err)
+	}
+	_, err = tx.Exec("DELETE FROM scan_reports WHERE id NOT IN (SELECT id FROM scan_reports ORDER BY id DESC LIMIT ?)", maxScanReports)
+	if err != nil {
+		return fmt.Errorf("failed to delete old scan reports: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	report.ID = reportID
+	return nil
+}
+
+// GetScanReports retrieves the saved scan reports, newest first, with the
+// number of their entries but not the entries themselves
+func (c *Connection) GetScanReports() ([]PersistentScanReport, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := `
+		SELECT r.id, r.started_at, r.finished_at, r.tracks, r.error,
+		       COUNT(CASE WHEN e.kind = ? THEN 1 END), COUNT(CASE WHEN e.kind = ? THEN 1 END)
+		FROM scan_reports r
+		LEFT JOIN scan_report_entries e ON e.report_id = r.id
+		GROUP BY r.id
+		ORDER BY r.id DESC
+	`
+
+	rows, err := c.db.Query(query, ScanEntrySkipped, ScanEntryError)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []PersistentScanReport
+	for rows.Next() {
+		var report PersistentScanReport
+		err := rows.Scan(
+			&report.ID, &report.StartedAt, &report.FinishedAt, &report.Tracks, &report.Error,
+			&report.SkippedCount, &report.ErrorCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scan report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// GetScanReport retrieves a scan report with its entries
+func (c *Connection) GetScanReport(id int64) (*PersistentScanReport, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var report PersistentScanReport
+	err := c.db.QueryRow(
+		"SELECT id, started_at, finished_at, tracks, error FROM scan_reports WHERE id = ?", id,
+	).Scan(&report.ID, &report.StartedAt, &report.FinishedAt, &report.Tracks, &report.Error)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("scan report with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get scan report: %w", err)
+	}
+
+	rows, err := c.db.Query("SELECT path, kind, reason, message FROM scan_report_entries WHERE report_id = ? ORDER BY id", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scan report entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry PersistentScanReportEntry
+		if err := rows.Scan(&entry.Path, &entry.Kind, &entry.Reason, &entry.Message); err != nil {
+			return nil, fmt.Errorf("failed to scan scan report entry: %w", err)
+		}
+		switch entry.Kind {
+		case ScanEntrySkipped:
+			report.SkippedCount++
+		case ScanEntryError:
+			report.ErrorCount++
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	return &report, nil
+}
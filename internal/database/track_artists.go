@@ -0,0 +1,289 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Roles of the artists credited on a track
+const (
+	ArtistRolePrimary     = "primary"
+	ArtistRoleFeatured    = "featured"
+	ArtistRoleAlbumArtist = "album_artist"
+)
+
+// DefaultArtistSeparators are the separators between the artists credited in
+// an artist tag, used until the configuration sets others. There are none, so
+// that band names such as "Simon & Garfunkel" stay whole unless the user asks
+// otherwise; only featured artists are split by default.
+var DefaultArtistSeparators = []string{}
+
+// featuredPattern matches the marker introducing the featured artists of an
+// artist tag, such as "A feat. B", "A ft B" or "A (with B)", capturing the
+// bracket opened before it
+var featuredPattern = regexp.MustCompile(`(?i)(?:\s+|\s*([(\[]))(?:feat\.?|ft\.?|featuring|with)\s+`)
+
+// ParseArtists splits an artist tag into the artists it credits: the primary
+// artists, then the artists featured after a "feat." marker. Featured artists
+// in brackets end at the closing bracket. Names are split on each of the
+// separators. A tag that cannot be split is a single primary artist.
+func ParseArtists(name string, separators []string) []TrackArtist {
+	main, featured := name, ""
+	if loc := featuredPattern.FindStringSubmatchIndex(name); loc != nil && strings.TrimSpace(name[:loc[0]]) != "" {
+		main, featured = name[:loc[0]], name[loc[1]:]
+		if loc[2] >= 0 {
+			featured = closeBracket(featured, name[loc[2]])
+		}
+	}
+
+	var artists []TrackArtist
+	seen := make(map[string]bool)
+	add := func(names []string, role string) {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				artists = append(artists, TrackArtist{Name: name, Role: role})
+			}
+		}
+	}
+	add(splitArtists(main, separators), ArtistRolePrimary)
+	if len(artists) == 0 {
+		return []TrackArtist{{Name: strings.TrimSpace(name), Role: ArtistRolePrimary}}
+	}
+	add(splitArtists(featured, separators), ArtistRoleFeatured)
+	return artists
+}
+
+// closeBracket returns the text before the bracket closing an opening bracket
+// that precedes it, or the whole text if it is not closed
+func closeBracket(text string, open byte) string {
+	closing := byte(')')
+	if open == '[' {
+		closing = ']'
+	}
+
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case open:
+			depth++
+		case closing:
+			if depth == 0 {
+				return text[:i]
+			}
+			depth--
+		}
+	}
+	return text
+}
+
+// splitArtists splits names on each of the separators, dropping empty names
+func splitArtists(names string, separators []string) []string {
+	parts := []string{names}
+	for _, separator := range separators {
+		if separator == "" {
+			continue
+		}
+		var split []string
+		for _, part := range parts {
+			split = append(split, strings.Split(part, separator)...)
+		}
+		parts = split
+	}
+
+	var result []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// trackArtists returns the artists credited on a track: those of its artist
+// tag, then its album artists. The primary artist comes first.
+func trackArtists(track *PersistentTrack, separators []string) []TrackArtist {
+	artists := ParseArtists(track.ArtistName, separators)
+	if track.AlbumArtistName == nil || strings.TrimSpace(*track.AlbumArtistName) == "" {
+		return artists
+	}
+
+	for _, albumArtist := range ParseArtists(*track.AlbumArtistName, separators) {
+		albumArtist.Role = ArtistRoleAlbumArtist
+		artists = append(artists, albumArtist)
+	}
+	return artists
+}
+
+// resolveTrackArtists sets the IDs of the artists of a track, creating them
+// if needed, and returns the ID of its primary artist
+func resolveTrackArtists(artists []TrackArtist, artistID func(name string) (int64, error)) (int64, error) {
+	for i := range artists {
+		id, err := artistID(artists[i].Name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get or create artist: %w", err)
+		}
+		artists[i].ArtistID = id
+	}
+	return artists[0].ArtistID, nil
+}
+
+// setTrackArtists replaces the artists credited on a track
+func setTrackArtists(q queryer, trackID int64, artists []TrackArtist) error {
+	_, err := q.Exec("DELETE FROM track_artists WHERE track_id = ?", trackID)
+	if err != nil {
+		return fmt.Errorf("failed to delete track artists: %w", err)
+	}
+
+	for position, artist := range artists {
+		_, err := q.Exec(
+			"INSERT OR IGNORE INTO track_artists (track_id, artist_id, role, position) VALUES (?, ?, ?, ?)",
+			trackID, artist.ArtistID, artist.Role, position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert track artist: %w", err)
+		}
+	}
+	return nil
+}
+
+// artistSeparators reads the configured artist separators
+func artistSeparators(q queryer) ([]string, error) {
+	var data string
+	err := q.QueryRow("SELECT artist_separators FROM config_data WHERE id = 1").Scan(&data)
+	if err == sql.ErrNoRows {
+		return DefaultArtistSeparators, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist separators: %w", err)
+	}
+
+	var separators []string
+	if err := json.Unmarshal([]byte(data), &separators); err != nil {
+		return nil, fmt.Errorf("failed to decode artist separators: %w", err)
+	}
+	return separators, nil
+}
+
+// GetTrackArtists retrieves the artists credited on a track, primary artists first
+func (c *Connection) GetTrackArtists(trackID int64) ([]TrackArtist, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := `
+		SELECT ta.artist_id, a.name, ta.role
+		FROM track_artists ta
+		JOIN artists a ON a.id = ta.artist_id
+		WHERE ta.track_id = ?
+		ORDER BY ta.position
+	`
+
+	rows, err := c.db.Query(query, trackID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query track artists: %w", err)
+	}
+	defer rows.Close()
+
+	var artists []TrackArtist
+	for rows.Next() {
+		var artist TrackArtist
+		if err := rows.Scan(&artist.ArtistID, &artist.Name, &artist.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan track artist: %w", err)
+		}
+		artists = append(artists, artist)
+	}
+
+	return artists, nil
+}
+
+// RebuildTrackArtists splits the artists of every track again with the
+// configured separators, moving tracks to the album of their new primary
+// artist. Artists and albums left without tracks are removed.
+func (c *Connection) RebuildTrackArtists() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	separators, err := artistSeparators(c.db)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := rebuildTrackArtists(tx, separators); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return c.deleteOrphans()
+}
+
+// rebuildTrackArtists sets the artists, primary artist and album of every track
+func rebuildTrackArtists(tx *sql.Tx, separators []string) error {
+	// Read every track first, since the transaction cannot run statements while rows are open
+	rows, err := tx.Query("SELECT id, artist_name, album_artist_name, album_name, image_path FROM tracks")
+	if err != nil {
+		return fmt.Errorf("failed to query tracks: %w", err)
+	}
+	var tracks []PersistentTrack
+	for rows.Next() {
+		var track PersistentTrack
+		if err := rows.Scan(&track.ID, &track.ArtistName, &track.AlbumArtistName, &track.AlbumName, &track.ImagePath); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan track: %w", err)
+		}
+		tracks = append(tracks, track)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query tracks: %w", err)
+	}
+
+	cache, err := newLibraryCache(tx)
+	if err != nil {
+		return err
+	}
+	defer cache.close()
+
+	albumIDs := make(map[int64]bool)
+	for i := range tracks {
+		track := &tracks[i]
+
+		artists := trackArtists(track, separators)
+		artistID, err := resolveTrackArtists(artists, cache.artist)
+		if err != nil {
+			return err
+		}
+
+		albumID, err := cache.album(track.AlbumName, track.AlbumArtistName, track.ImagePath, artistID)
+		if err != nil {
+			return fmt.Errorf("failed to get or create album: %w", err)
+		}
+
+		_, err = tx.Exec("UPDATE tracks SET artist_id = ?, album_id = ? WHERE id = ?", artistID, albumID, track.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update track artist: %w", err)
+		}
+		if err := setTrackArtists(tx, track.ID, artists); err != nil {
+			return err
+		}
+		albumIDs[albumID] = true
+	}
+
+	for albumID := range albumIDs {
+		if err := refreshAlbumImage(tx, albumID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArtists(t *testing.T) {
+	primary := func(name string) TrackArtist { return TrackArtist{Name: name, Role: ArtistRolePrimary} }
+	featured := func(name string) TrackArtist { return TrackArtist{Name: name, Role: ArtistRoleFeatured} }
+	separators := []string{" & ", ", ", " / ", "; "}
+
+	tests := []struct {
+		name       string
+		artist     string
+		separators []string
+		expected   []TrackArtist
+	}{
+		{"single artist", "Artist", DefaultArtistSeparators, []TrackArtist{primary("Artist")}},
+		{"band names kept by default", "Earth, Wind & Fire feat. Simon & Garfunkel", DefaultArtistSeparators, []TrackArtist{primary("Earth, Wind & Fire"), featured("Simon & Garfunkel")}},
+		{"separators", "A & B, C / D; E", separators, []TrackArtist{primary("A"), primary("B"), primary("C"), primary("D"), primary("E")}},
+		{"featured", "A & B feat. C", separators, []TrackArtist{primary("A"), primary("B"), featured("C")}},
+		{"featured in brackets", "A (Ft. B & C)", separators, []TrackArtist{primary("A"), featured("B"), featured("C")}},
+		{"featuring", "A featuring B", DefaultArtistSeparators, []TrackArtist{primary("A"), featured("B")}},
+		{"ft without a dot", "A ft B", DefaultArtistSeparators, []TrackArtist{primary("A"), featured("B")}},
+		{"with", "A (with B)", DefaultArtistSeparators, []TrackArtist{primary("A"), featured("B")}},
+		{"bracket closed before another", "A (feat. B) [Live]", DefaultArtistSeparators, []TrackArtist{primary("A"), featured("B")}},
+		{"nested brackets", "A [feat. B (UK)] [Live]", DefaultArtistSeparators, []TrackArtist{primary("A"), featured("B (UK)")}},
+		{"unclosed bracket", "A (feat. B", DefaultArtistSeparators, []TrackArtist{primary("A"), featured("B")}},
+		{"marker word inside a name", "Within Temptation", DefaultArtistSeparators, []TrackArtist{primary("Within Temptation")}},
+		{"no separators", "A & B feat. C", nil, []TrackArtist{primary("A & B"), featured("C")}},
+		{"custom separator", "A x B", []string{" x "}, []TrackArtist{primary("A"), primary("B")}},
+		{"marker inside a name", "Left Featherstone", DefaultArtistSeparators, []TrackArtist{primary("Left Featherstone")}},
+		{"marker first", "Feat. A", DefaultArtistSeparators, []TrackArtist{primary("Feat. A")}},
+		{"duplicates", "A & A feat. A", separators, []TrackArtist{primary("A")}},
+		{"only separators", " & ", separators, []TrackArtist{primary("&")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseArtists(tt.artist, tt.separators); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseArtists(%q) = %v, expected %v", tt.artist, got, tt.expected)
+			}
+		})
+	}
+}
@@ -3,8 +3,10 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // GetTracks retrieves all tracks from the database
@@ -16,7 +18,7 @@ func (c *Connection) GetTracks() ([]PersistentTrack, error) {
 		SELECT id, file_path, file_name, title, album_name, album_artist_name, 
 		       album_id, artist_name, artist_id, image_path, track_number, 
 		       txt_lyrics, lrc_lyrics, duration, instrumental, title_lower,
-		       created_at, updated_at
+		       cue_track, start_offset, end_offset, created_at, updated_at
 		FROM tracks
 		ORDER BY artist_name, album_name, track_number
 	`
@@ -36,6 +38,7 @@ func (c *Connection) GetTracks() ([]PersistentTrack, error) {
 			&track.ArtistName, &track.ArtistID, &track.ImagePath,
 			&track.TrackNumber, &track.TxtLyrics, &track.LrcLyrics,
 			&track.Duration, &track.Instrumental, &track.TitleLower,
+			&track.CueTrack, &track.StartOffset, &track.EndOffset,
 			&track.CreatedAt, &track.UpdatedAt,
 		)
 		if err != nil {
@@ -56,7 +59,7 @@ func (c *Connection) GetTrackByID(id int64) (*PersistentTrack, error) {
 		SELECT id, file_path, file_name, title, album_name, album_artist_name, 
 		       album_id, artist_name, artist_id, image_path, track_number, 
 		       txt_lyrics, lrc_lyrics, duration, instrumental, title_lower,
-		       created_at, updated_at
+		       cue_track, start_offset, end_offset, created_at, updated_at
 		FROM tracks
 		WHERE id = ?
 	`
@@ -68,6 +71,7 @@ func (c *Connection) GetTrackByID(id int64) (*PersistentTrack, error) {
 		&track.ArtistName, &track.ArtistID, &track.ImagePath,
 		&track.TrackNumber, &track.TxtLyrics, &track.LrcLyrics,
 		&track.Duration, &track.Instrumental, &track.TitleLower,
+		&track.CueTrack, &track.StartOffset, &track.EndOffset,
 		&track.CreatedAt, &track.UpdatedAt,
 	)
 
@@ -86,14 +90,336 @@ func (c *Connection) AddTrack(track *PersistentTrack) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// First, ensure artist exists
-	artistID, err := c.getOrCreateArtist(track.ArtistName)
+	return insertTrack(c.db, track)
+}
+
+// BulkUpsertTracks adds tracks to the database in a single transaction. A
+// track whose file and CUE track are already in the library has its tags
+// updated in place, keeping its lyrics and history, and credits the artists
+// split from its artist and album artist tags. Tracks of the batch's files
+// that are no longer among them, such as those of an edited or deleted CUE
+// sheet, are removed with their history kept for a later relink. A track
+// marked KeepCueTracks is skipped when its file already has CUE tracks,
+// which are kept. Artist and album IDs are cached for the batch, so each is
+// looked up at most once.
+func (c *Connection) BulkUpsertTracks(tracks []PersistentTrack) error {
+	if len(tracks) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Track IDs only grow, so a returned ID above the current maximum is a new track
+	var maxID int64
+	err = tx.QueryRow("SELECT COALESCE(MAX(id), 0) FROM tracks").Scan(&maxID)
+	if err != nil {
+		return fmt.Errorf("failed to query max track ID: %w", err)
+	}
+
+	upsertTrack, err := tx.Prepare(`
+		INSERT INTO tracks (file_path, file_name, title, album_name, album_artist_name,
+		                   album_id, artist_name, artist_id, image_path, track_number,
+		                   txt_lyrics, lrc_lyrics, duration, instrumental, title_lower,
+		                   cue_track, start_offset, end_offset, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(file_path, cue_track) DO UPDATE SET
+		    file_name = excluded.file_name, title = excluded.title, album_name = excluded.album_name,
+		    album_artist_name = excluded.album_artist_name, album_id = excluded.album_id,
+		    artist_name = excluded.artist_name, artist_id = excluded.artist_id,
+		    image_path = excluded.image_path, track_number = excluded.track_number,
+		    duration = excluded.duration, title_lower = excluded.title_lower,
+		    start_offset = excluded.start_offset, end_offset = excluded.end_offset,
+		    updated_at = excluded.updated_at
+		RETURNING id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare track statement: %w", err)
+	}
+	defer upsertTrack.Close()
+
+	insertHistory, err := tx.Prepare("INSERT INTO lyrics_history (track_id, lrc_lyrics, txt_lyrics, instrumental, source, created_at) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare lyrics history statement: %w", err)
+	}
+	defer insertHistory.Close()
+
+	separators, err := artistSeparators(tx)
+	if err != nil {
+		return err
+	}
+
+	cache, err := newLibraryCache(tx)
+	if err != nil {
+		return err
+	}
+	defer cache.close()
+
+	now := time.Now()
+	albumIDs := make(map[int64]bool)
+	for i := range tracks {
+		track := &tracks[i]
+
+		if track.KeepCueTracks {
+			var cueTracks int
+			err := tx.QueryRow("SELECT COUNT(*) FROM tracks WHERE file_path = ? AND cue_track > 0", track.FilePath).Scan(&cueTracks)
+			if err != nil {
+				return fmt.Errorf("failed to count CUE tracks of %s: %w", track.FilePath, err)
+			}
+			if cueTracks > 0 {
+				continue
+			}
+		}
+
+		artists := trackArtists(track, separators)
+		artistID, err := resolveTrackArtists(artists, cache.artist)
+		if err != nil {
+			return err
+		}
+
+		albumID, err := cache.album(track.AlbumName, track.AlbumArtistName, track.ImagePath, artistID)
+		if err != nil {
+			return fmt.Errorf("failed to get or create album: %w", err)
+		}
+
+		var trackID int64
+		err = upsertTrack.QueryRow(
+			track.FilePath, track.FileName, track.Title, track.AlbumName, track.AlbumArtistName,
+			albumID, track.ArtistName, artistID, track.ImagePath, track.TrackNumber,
+			track.TxtLyrics, track.LrcLyrics, track.Duration, track.Instrumental, strings.ToLower(track.Title),
+			track.CueTrack, track.StartOffset, track.EndOffset, now, now,
+		).Scan(&trackID)
+		if err != nil {
+			return fmt.Errorf("failed to upsert track %s: %w", track.FilePath, err)
+		}
+		if err := setTrackArtists(tx, trackID, artists); err != nil {
+			return err
+		}
+
+		// A new track takes over the history of a detached track with the
+		// same tags, or starts it with the lyrics found next to the audio file
+		relinked := false
+		if trackID > maxID {
+			if relinked, err = relinkDetachedTrack(tx, track, trackID); err != nil {
+				return err
+			}
+		}
+		if trackID > maxID && !relinked && (track.LrcLyrics != nil || track.TxtLyrics != nil || track.Instrumental) {
+			_, err = insertHistory.Exec(trackID, track.LrcLyrics, track.TxtLyrics, track.Instrumental, HistorySourceScan, now)
+			if err != nil {
+				return fmt.Errorf("failed to insert lyrics history: %w", err)
+			}
+		}
+
+		track.ID = trackID
+		track.AlbumID = albumID
+		track.ArtistID = artistID
+		albumIDs[albumID] = true
+		if trackID > maxID {
+			track.CreatedAt = now
+		}
+		track.UpdatedAt = now
+	}
+
+	deleted, err := deleteStaleTracks(tx, tracks)
+	if err != nil {
+		return err
+	}
+
+	for albumID := range albumIDs {
+		if err := refreshAlbumImage(tx, albumID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		return c.deleteOrphans()
+	}
+	return nil
+}
+
+// deleteStaleTracks removes the tracks of the files of tracks that are not
+// among them, keeping their lyrics history and source as detached tracks, and
+// returns the number of tracks removed. Files read without their CUE sheet
+// keep all their tracks.
+func deleteStaleTracks(tx *sql.Tx, tracks []PersistentTrack) (int64, error) {
+	cueTracks := make(map[string][]any)
+	var files []string
+	for _, track := range tracks {
+		if track.KeepCueTracks {
+			continue
+		}
+		if _, ok := cueTracks[track.FilePath]; !ok {
+			files = append(files, track.FilePath)
+		}
+		cueTracks[track.FilePath] = append(cueTracks[track.FilePath], track.CueTrack)
+	}
+
+	var deleted int64
+	for _, file := range files {
+		// Most files have no other tracks, which the index answers quickly
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM tracks WHERE file_path = ?", file).Scan(&count)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count tracks of %s: %w", file, err)
+		}
+		if count <= len(cueTracks[file]) {
+			continue
+		}
+
+		keep := cueTracks[file]
+		where := "file_path = ? AND cue_track NOT IN (?" + strings.Repeat(", ?", len(keep)-1) + ")"
+		args := append([]any{file}, keep...)
+		if err := detachTrackRows(tx, where, args...); err != nil {
+			return 0, err
+		}
+		n, err := deleteTrackRows(tx, where, args...)
+		if err != nil {
+			return 0, err
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// libraryCache gets or creates artists and albums with prepared statements,
+// remembering their IDs for the lifetime of a transaction
+type libraryCache struct {
+	selectArtist *sql.Stmt
+	insertArtist *sql.Stmt
+	selectAlbum  *sql.Stmt
+	insertAlbum  *sql.Stmt
+	artists      map[string]int64
+	albums       map[albumKey]int64
+}
+
+// albumKey identifies an album by its name and artist
+type albumKey struct {
+	name     string
+	artistID int64
+}
+
+// newLibraryCache prepares the artist and album statements on a transaction
+func newLibraryCache(tx *sql.Tx) (*libraryCache, error) {
+	cache := &libraryCache{
+		artists: make(map[string]int64),
+		albums:  make(map[albumKey]int64),
+	}
+
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&cache.selectArtist, "SELECT id FROM artists WHERE name = ?"},
+		{&cache.insertArtist, "INSERT INTO artists (name, name_lower, created_at, updated_at) VALUES (?, ?, ?, ?)"},
+		{&cache.selectAlbum, "SELECT id FROM albums WHERE name = ? AND artist_id = ?"},
+		{&cache.insertAlbum, "INSERT INTO albums (name, name_lower, artist_id, artist_name, album_artist_name, album_artist_name_lower, image_path, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"},
+	}
+	for _, s := range statements {
+		stmt, err := tx.Prepare(s.query)
+		if err != nil {
+			cache.close()
+			return nil, fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		*s.stmt = stmt
+	}
+
+	return cache, nil
+}
+
+// artist returns the ID of an artist, creating it if needed
+func (lc *libraryCache) artist(name string) (int64, error) {
+	if id, ok := lc.artists[name]; ok {
+		return id, nil
+	}
+
+	var id int64
+	err := lc.selectArtist.QueryRow(name).Scan(&id)
+	if err == sql.ErrNoRows {
+		now := time.Now()
+		var result sql.Result
+		result, err = lc.insertArtist.Exec(name, strings.ToLower(name), now, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create artist: %w", err)
+		}
+		id, err = result.LastInsertId()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query artist: %w", err)
+	}
+
+	lc.artists[name] = id
+	return id, nil
+}
+
+// album returns the ID of an album, creating it if needed
+func (lc *libraryCache) album(name string, albumArtistName *string, imagePath *string, artistID int64) (int64, error) {
+	key := albumKey{name, artistID}
+	if id, ok := lc.albums[key]; ok {
+		return id, nil
+	}
+
+	var id int64
+	err := lc.selectAlbum.QueryRow(name, artistID).Scan(&id)
+	if err == sql.ErrNoRows {
+		var albumArtistNameLower *string
+		if albumArtistName != nil {
+			lower := strings.ToLower(*albumArtistName)
+			albumArtistNameLower = &lower
+		}
+
+		now := time.Now()
+		var result sql.Result
+		result, err = lc.insertAlbum.Exec(name, strings.ToLower(name), artistID, "", albumArtistName, albumArtistNameLower, imagePath, now, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create album: %w", err)
+		}
+		id, err = result.LastInsertId()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to query album: %w", err)
+	}
+
+	lc.albums[key] = id
+	return id, nil
+}
+
+// close releases the prepared statements
+func (lc *libraryCache) close() {
+	for _, stmt := range []*sql.Stmt{lc.selectArtist, lc.insertArtist, lc.selectAlbum, lc.insertAlbum} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// insertTrack inserts a track with its artist and album
+func insertTrack(q queryer, track *PersistentTrack) error {
+	// First, ensure the artists exist
+	separators, err := artistSeparators(q)
+	if err != nil {
+		return err
+	}
+	artists := trackArtists(track, separators)
+	artistID, err := resolveTrackArtists(artists, func(name string) (int64, error) {
+		return getOrCreateArtist(q, name)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get or create artist: %w", err)
+		return err
 	}
 
 	// Then, ensure album exists
-	albumID, err := c.getOrCreateAlbum(track.AlbumName, track.AlbumArtistName, track.ImagePath, artistID)
+	albumID, err := getOrCreateAlbum(q, track.AlbumName, track.AlbumArtistName, track.ImagePath, artistID)
 	if err != nil {
 		return fmt.Errorf("failed to get or create album: %w", err)
 	}
@@ -105,16 +431,16 @@ func (c *Connection) AddTrack(track *PersistentTrack) error {
 		INSERT INTO tracks (file_path, file_name, title, album_name, album_artist_name,
 		                   album_id, artist_name, artist_id, image_path, track_number,
 		                   txt_lyrics, lrc_lyrics, duration, instrumental, title_lower,
-		                   created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                   cue_track, start_offset, end_offset, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
-	result, err := c.db.Exec(query,
+	result, err := q.Exec(query,
 		track.FilePath, track.FileName, track.Title, track.AlbumName, track.AlbumArtistName,
 		albumID, track.ArtistName, artistID, track.ImagePath, track.TrackNumber,
 		track.TxtLyrics, track.LrcLyrics, track.Duration, track.Instrumental, titleLower,
-		now, now,
+		track.CueTrack, track.StartOffset, track.EndOffset, now, now,
 	)
 
 	if err != nil {
@@ -126,6 +452,30 @@ func (c *Connection) AddTrack(track *PersistentTrack) error {
 		return fmt.Errorf("failed to get track ID: %w", err)
 	}
 
+	if err := setTrackArtists(q, trackID, artists); err != nil {
+		return err
+	}
+
+	// Take over the history of a detached track with the same tags, or start
+	// it with the lyrics found next to the audio file
+	relinked, err := relinkDetachedTrack(q, track, trackID)
+	if err != nil {
+		return err
+	}
+	if !relinked && (track.LrcLyrics != nil || track.TxtLyrics != nil || track.Instrumental) {
+		_, err = q.Exec(
+			"INSERT INTO lyrics_history (track_id, lrc_lyrics, txt_lyrics, instrumental, source, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			trackID, track.LrcLyrics, track.TxtLyrics, track.Instrumental, HistorySourceScan, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert lyrics history: %w", err)
+		}
+	}
+
+	if err := refreshAlbumImage(q, albumID); err != nil {
+		return err
+	}
+
 	track.ID = trackID
 	track.AlbumID = albumID
 	track.ArtistID = artistID
@@ -135,6 +485,345 @@ func (c *Connection) AddTrack(track *PersistentTrack) error {
 	return nil
 }
 
+// refreshAlbumImage sets the image of an album to the art of its first track
+// that has some, or clears it when none has
+func refreshAlbumImage(q queryer, albumID int64) error {
+	_, err := q.Exec(`
+		UPDATE albums SET image_path = (
+			SELECT image_path FROM tracks
+			WHERE album_id = albums.id AND image_path IS NOT NULL
+			ORDER BY track_number, id
+			LIMIT 1
+		)
+		WHERE id = ?`, albumID)
+	if err != nil {
+		return fmt.Errorf("failed to update album image: %w", err)
+	}
+	return nil
+}
+
+// GetImagePaths retrieves the distinct album art paths of tracks and albums
+func (c *Connection) GetImagePaths() ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	rows, err := c.db.Query(`
+		SELECT image_path FROM tracks WHERE image_path IS NOT NULL
+		UNION
+		SELECT image_path FROM albums WHERE image_path IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query image paths: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan image path: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// GetTrackByFilePath retrieves the track of an audio file, or its first CUE
+// track. It returns nil if the file is not in the library.
+func (c *Connection) GetTrackByFilePath(filePath string) (*PersistentTrack, error) {
+	tracks, err := c.GetTracksByFilePath(filePath)
+	if err != nil || len(tracks) == 0 {
+		return nil, err
+	}
+	return &tracks[0], nil
+}
+
+// GetTracksByFilePath retrieves the tracks of an audio file: a single one, or
+// one per track of its CUE sheet ordered by track
+func (c *Connection) GetTracksByFilePath(filePath string) ([]PersistentTrack, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := `
+		SELECT id, file_path, file_name, title, album_name, album_artist_name, 
+		       album_id, artist_name, artist_id, image_path, track_number, 
+		       txt_lyrics, lrc_lyrics, duration, instrumental, title_lower,
+		       cue_track, start_offset, end_offset, created_at, updated_at
+		FROM tracks
+		WHERE file_path = ?
+		ORDER BY cue_track
+	`
+
+	rows, err := c.db.Query(query, filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tracks by file path: %w", err)
+	}
+	defer rows.Close()
+
+	var tracks []PersistentTrack
+	for rows.Next() {
+		var track PersistentTrack
+		err := rows.Scan(
+			&track.ID, &track.FilePath, &track.FileName, &track.Title,
+			&track.AlbumName, &track.AlbumArtistName, &track.AlbumID,
+			&track.ArtistName, &track.ArtistID, &track.ImagePath,
+			&track.TrackNumber, &track.TxtLyrics, &track.LrcLyrics,
+			&track.Duration, &track.Instrumental, &track.TitleLower,
+			&track.CueTrack, &track.StartOffset, &track.EndOffset,
+			&track.CreatedAt, &track.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan track: %w", err)
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+// UpdateTrackMetadata updates the tags of a track read from its audio file.
+// Lyrics are left untouched.
+func (c *Connection) UpdateTrackMetadata(track *PersistentTrack) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	separators, err := artistSeparators(c.db)
+	if err != nil {
+		return err
+	}
+	artists := trackArtists(track, separators)
+	artistID, err := resolveTrackArtists(artists, func(name string) (int64, error) {
+		return getOrCreateArtist(c.db, name)
+	})
+	if err != nil {
+		return err
+	}
+
+	albumID, err := getOrCreateAlbum(c.db, track.AlbumName, track.AlbumArtistName, track.ImagePath, artistID)
+	if err != nil {
+		return fmt.Errorf("failed to get or create album: %w", err)
+	}
+
+	query := `
+		UPDATE tracks
+		SET file_name = ?, title = ?, album_name = ?, album_artist_name = ?, album_id = ?,
+		    artist_name = ?, artist_id = ?, image_path = ?, track_number = ?, duration = ?,
+		    start_offset = ?, end_offset = ?, title_lower = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	now := time.Now()
+	_, err = c.db.Exec(query,
+		track.FileName, track.Title, track.AlbumName, track.AlbumArtistName, albumID,
+		track.ArtistName, artistID, track.ImagePath, track.TrackNumber, track.Duration,
+		track.StartOffset, track.EndOffset, strings.ToLower(track.Title), now, track.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update track metadata: %w", err)
+	}
+
+	if err := setTrackArtists(c.db, track.ID, artists); err != nil {
+		return err
+	}
+
+	if err := refreshAlbumImage(c.db, albumID); err != nil {
+		return err
+	}
+
+	track.AlbumID = albumID
+	track.ArtistID = artistID
+	track.UpdatedAt = now
+
+	return c.deleteOrphans()
+}
+
+// DeleteTracksByPath removes the track of an audio file, or every track under
+// a directory, with their lyrics history and source. Albums and artists left
+// without tracks are removed as well. It returns the number of tracks removed.
+func (c *Connection) DeleteTracksByPath(path string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	where, args := pathCondition(path)
+	return c.deleteTracks(where, args...)
+}
+
+// DetachTracksByPath removes the track of an audio file, or every track under
+// a directory, like DeleteTracksByPath, but keeps their lyrics history and
+// source. They are linked to the next track added with the same tags, so that
+// a file moved in a way that could not be followed keeps its history.
+func (c *Connection) DetachTracksByPath(path string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	where, args := pathCondition(path)
+	if err := detachTrackRows(tx, where, args...); err != nil {
+		return 0, err
+	}
+	deleted, err := deleteTrackRows(tx, where, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if deleted > 0 {
+		if err := c.deleteOrphans(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// MoveTrackPaths follows an audio file, or a directory, renamed or moved
+// within the library, so that its tracks keep their ID, lyrics history and
+// source. Tracks already at the new path were replaced and are removed. It
+// returns the new paths of the moved audio files.
+func (c *Connection) MoveTrackPaths(from, to string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	where, args := pathCondition(to)
+	replaced, err := deleteTrackRows(tx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// substr counts characters rather than bytes
+	where, args = pathCondition(from)
+	_, err = tx.Exec(`
+		UPDATE tracks
+		SET file_path = ? || substr(file_path, ?),
+		    file_name = CASE WHEN file_path = ? THEN ? ELSE file_name END,
+		    updated_at = ?
+		WHERE `+where,
+		append([]any{to, utf8.RuneCountInString(from) + 1, from, filepath.Base(to), time.Now()}, args...)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move tracks: %w", err)
+	}
+
+	where, args = pathCondition(to)
+	rows, err := tx.Query("SELECT DISTINCT file_path FROM tracks WHERE "+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moved tracks: %w", err)
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan track path: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query moved tracks: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if replaced > 0 {
+		if err := c.deleteOrphans(); err != nil {
+			return paths, err
+		}
+	}
+	return paths, nil
+}
+
+// pathCondition returns the condition matching the tracks of an audio file,
+// or the tracks under a directory
+func pathCondition(path string) (string, []any) {
+	// Escape LIKE wildcards so that only paths under the directory match
+	prefix := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.TrimSuffix(path, string(filepath.Separator)))
+	return `(file_path = ? OR file_path LIKE ? ESCAPE '\')`, []any{path, prefix + string(filepath.Separator) + "%"}
+}
+
+// deleteTracks removes the tracks matching a condition with their lyrics
+// history, source and queue entries, then albums and artists left without
+// tracks; the caller must hold c.mu
+func (c *Connection) deleteTracks(where string, args ...any) (int64, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	deleted, err := deleteTrackRows(tx, where, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if deleted > 0 {
+		if err := c.deleteOrphans(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// deleteTrackRows removes the tracks matching a condition with their lyrics
+// history, source, queue entries and artists, and returns the number of tracks removed
+func deleteTrackRows(q queryer, where string, args ...any) (int64, error) {
+	for _, table := range []string{"lyrics_history", "track_lyrics_source", "playback_queue", "track_artists"} {
+		_, err := q.Exec("DELETE FROM "+table+" WHERE track_id IN (SELECT id FROM tracks WHERE "+where+")", args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete from %s: %w", table, err)
+		}
+	}
+
+	result, err := q.Exec("DELETE FROM tracks WHERE "+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete tracks: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return deleted, nil
+}
+
+// deleteOrphans removes albums and artists without tracks; the caller must hold c.mu
+func (c *Connection) deleteOrphans() error {
+	_, err := c.db.Exec("DELETE FROM albums WHERE id NOT IN (SELECT album_id FROM tracks WHERE album_id IS NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("failed to delete empty albums: %w", err)
+	}
+
+	_, err = c.db.Exec(`
+		DELETE FROM artists
+		WHERE id NOT IN (SELECT artist_id FROM track_artists)
+		  AND id NOT IN (SELECT artist_id FROM tracks WHERE artist_id IS NOT NULL)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to delete empty artists: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateTrackSyncedLyrics updates a track's synced lyrics
 func (c *Connection) UpdateTrackSyncedLyrics(trackID int64, syncedLyrics, plainLyrics string) error {
 	c.mu.Lock()
@@ -192,11 +881,17 @@ func (c *Connection) UpdateTrackInstrumental(trackID int64) error {
 	return nil
 }
 
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 // getOrCreateArtist gets an existing artist or creates a new one
-func (c *Connection) getOrCreateArtist(artistName string) (int64, error) {
+func getOrCreateArtist(q queryer, artistName string) (int64, error) {
 	// Try to find existing artist
 	var artistID int64
-	err := c.db.QueryRow("SELECT id FROM artists WHERE name = ?", artistName).Scan(&artistID)
+	err := q.QueryRow("SELECT id FROM artists WHERE name = ?", artistName).Scan(&artistID)
 	if err == nil {
 		return artistID, nil
 	}
@@ -209,7 +904,7 @@ func (c *Connection) getOrCreateArtist(artistName string) (int64, error) {
 	nameLower := strings.ToLower(artistName)
 	now := time.Now()
 	
-	result, err := c.db.Exec(
+	result, err := q.Exec(
 		"INSERT INTO artists (name, name_lower, created_at, updated_at) VALUES (?, ?, ?, ?)",
 		artistName, nameLower, now, now,
 	)
@@ -226,11 +921,11 @@ func (c *Connection) getOrCreateArtist(artistName string) (int64, error) {
 }
 
 // getOrCreateAlbum gets an existing album or creates a new one
-func (c *Connection) getOrCreateAlbum(albumName string, albumArtistName *string, imagePath *string, artistID int64) (int64, error) {
+func getOrCreateAlbum(q queryer, albumName string, albumArtistName *string, imagePath *string, artistID int64) (int64, error) {
 	// Try to find existing album
 	var albumID int64
 	query := "SELECT id FROM albums WHERE name = ? AND artist_id = ?"
-	err := c.db.QueryRow(query, albumName, artistID).Scan(&albumID)
+	err := q.QueryRow(query, albumName, artistID).Scan(&albumID)
 	if err == nil {
 		return albumID, nil
 	}
@@ -249,9 +944,9 @@ func (c *Connection) getOrCreateAlbum(albumName string, albumArtistName *string,
 
 	now := time.Now()
 	
-	result, err := c.db.Exec(
-		"INSERT INTO albums (name, name_lower, artist_name, album_artist_name, album_artist_name_lower, image_path, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
-		albumName, nameLower, "", albumArtistName, albumArtistNameLower, imagePath, now, now,
+	result, err := q.Exec(
+		"INSERT INTO albums (name, name_lower, artist_id, artist_name, album_artist_name, album_artist_name_lower, image_path, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		albumName, nameLower, artistID, "", albumArtistName, albumArtistNameLower, imagePath, now, now,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create album: %w", err)
@@ -0,0 +1,129 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dhowden/tag"
+
+	"lrcget-go/internal/artwork"
+)
+
+// folderImageNames are the names of album art images in an album folder, by
+// preference, matched without case and with a .jpg, .jpeg or .png extension
+var folderImageNames = []string{"cover", "folder", "front", "album"}
+
+// folderImage is the album art found in a folder, remembered so that the
+// folder is listed and the image read once for all the tracks of the folder
+type folderImage struct {
+	dirModTime time.Time
+	path       string // image file, or empty if the folder has none
+	modTime    time.Time
+	imagePath  string // thumbnail in the artwork cache
+}
+
+// SetArtworkCache sets the cache album art thumbnails are written to. Without
+// a cache, album art is not looked for.
+func (s *Scanner) SetArtworkCache(cache *artwork.Cache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.artwork = cache
+	s.folderImages = make(map[string]folderImage)
+}
+
+// albumArt returns the path of the thumbnail of a track's album art: the
+// picture embedded in its tags, or else the image in its folder. It returns
+// nil when there is no art or it cannot be decoded.
+func (s *Scanner) albumArt(filePath string, picture *tag.Picture) *string {
+	s.mu.RLock()
+	cache := s.artwork
+	s.mu.RUnlock()
+	if cache == nil {
+		return nil
+	}
+
+	if picture != nil && len(picture.Data) > 0 {
+		if imagePath, err := cache.Store(picture.Data); err == nil {
+			return &imagePath
+		}
+	}
+
+	if imagePath := s.folderArt(cache, filepath.Dir(filePath)); imagePath != "" {
+		return &imagePath
+	}
+	return nil
+}
+
+// folderArt returns the thumbnail of the album art image of a folder, or an
+// empty string if it has none. The folder is listed again only when its
+// modification time changes, and the image read again when it changes.
+func (s *Scanner) folderArt(cache *artwork.Cache, dir string) string {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return ""
+	}
+
+	s.mu.RLock()
+	known, ok := s.folderImages[dir]
+	s.mu.RUnlock()
+
+	image := folderImage{dirModTime: info.ModTime(), path: known.path}
+	if !ok || !known.dirModTime.Equal(image.dirModTime) {
+		image.path = findFolderImage(dir)
+	}
+	if image.path != "" {
+		// An image written in place leaves the folder's modification time alone
+		imageInfo, err := os.Stat(image.path)
+		if err != nil {
+			image.path = ""
+		} else {
+			image.modTime = imageInfo.ModTime()
+		}
+	}
+
+	if ok && known.path == image.path && known.modTime.Equal(image.modTime) {
+		image.imagePath = known.imagePath
+	} else if image.path != "" {
+		if data, err := os.ReadFile(image.path); err == nil {
+			image.imagePath, _ = cache.Store(data)
+		}
+	}
+
+	s.mu.Lock()
+	s.folderImages[dir] = image
+	s.mu.Unlock()
+	return image.imagePath
+}
+
+// findFolderImage returns the album art image of a folder, or an empty path
+// if it has none
+func findFolderImage(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+
+	best, bestRank := "", len(folderImageNames)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".jpg" && ext != ".jpeg" && ext != ".png" {
+			continue
+		}
+		stem := strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		for rank, name := range folderImageNames[:bestRank] {
+			if stem == name {
+				best, bestRank = entry.Name(), rank
+				break
+			}
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return filepath.Join(dir, best)
+}
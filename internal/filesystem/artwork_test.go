@@ -0,0 +1,128 @@
+package filesystem
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lrcget-go/internal/artwork"
+)
+
+// testPicture returns a small PNG of a solid color
+func testPicture(t *testing.T, c color.Color) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := range 16 {
+		img.Set(i%4, i/4, c)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writePictureFile writes an MP3 file with only an ID3v2 tag holding a title
+// and an embedded front cover
+func writePictureFile(t *testing.T, path, title string, picture []byte) {
+	t.Helper()
+
+	frame := func(id string, data []byte) []byte {
+		n := len(data)
+		header := append([]byte(id), byte(n>>24), byte(n>>16), byte(n>>8), byte(n), 0, 0)
+		return append(header, data...)
+	}
+	apic := append([]byte("\x00image/png\x00\x03\x00"), picture...)
+	tag := append(frame("TIT2", append([]byte{0}, title...)), frame("APIC", apic)...)
+
+	size := len(tag)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(header, tag...), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAlbumArt(t *testing.T) {
+	root := t.TempDir()
+	red, blue, green := testPicture(t, color.NRGBA{R: 255, A: 255}), testPicture(t, color.NRGBA{B: 255, A: 255}), testPicture(t, color.NRGBA{G: 255, A: 255})
+
+	writePictureFile(t, filepath.Join(root, "Embedded", "01.mp3"), "Embedded", red)
+	os.WriteFile(filepath.Join(root, "Embedded", "cover.jpg"), blue, 0644)
+	writeTaggedFile(t, filepath.Join(root, "Folder", "01.mp3"), "Folder")
+	os.WriteFile(filepath.Join(root, "Folder", "Front.PNG"), green, 0644)
+	os.WriteFile(filepath.Join(root, "Folder", "Folder.png"), blue, 0644)
+	writeTaggedFile(t, filepath.Join(root, "None", "01.mp3"), "None")
+	os.WriteFile(filepath.Join(root, "None", "scan.jpg"), blue, 0644)
+
+	cache := artwork.NewCache(t.TempDir())
+	thumbnail := func(picture []byte) string {
+		path, err := cache.Store(picture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		cache    bool
+		expected string
+	}{
+		{"embedded picture first", "Embedded", true, thumbnail(red)},
+		{"preferred folder image", "Folder", true, thumbnail(blue)},
+		{"no album art", "None", true, ""},
+		{"no cache", "Embedded", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner()
+			if tt.cache {
+				scanner.SetArtworkCache(cache)
+			}
+
+			track, err := scanner.ExtractMetadata(filepath.Join(root, tt.dir, "01.mp3"))
+			if err != nil {
+				t.Fatalf("ExtractMetadata() error = %v", err)
+			}
+			got := ""
+			if track.ImagePath != nil {
+				got = *track.ImagePath
+			}
+			if got != tt.expected {
+				t.Errorf("ExtractMetadata() image = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	// A folder image replaced since the last track is read again
+	scanner := NewScanner()
+	scanner.SetArtworkCache(cache)
+	path := filepath.Join(root, "Folder", "01.mp3")
+	scanner.ExtractMetadata(path)
+	os.Remove(filepath.Join(root, "Folder", "Folder.png"))
+	track, err := scanner.ExtractMetadata(path)
+	if err != nil || track.ImagePath == nil || *track.ImagePath != thumbnail(green) {
+		t.Errorf("ExtractMetadata() after replacing the folder image = %v, %v, expected %s", track, err, thumbnail(green))
+	}
+
+	// So is an image written in place, which leaves the folder unchanged
+	front := filepath.Join(root, "Folder", "Front.PNG")
+	os.WriteFile(front, red, 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(front, later, later)
+	track, err = scanner.ExtractMetadata(path)
+	if err != nil || track.ImagePath == nil || *track.ImagePath != thumbnail(red) {
+		t.Errorf("ExtractMetadata() after rewriting the folder image = %v, %v, expected %s", track, err, thumbnail(red))
+	}
+}
@@ -0,0 +1,424 @@
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"lrcget-go/internal/audio"
+	"lrcget-go/internal/constants"
+	"lrcget-go/internal/database"
+)
+
+// cueFramesPerSecond is the number of CD frames per second in CUE sheet times
+const cueFramesPerSecond = 75
+
+// CueSheet is a parsed CUE sheet, describing the tracks of one or more audio files
+type CueSheet struct {
+	Performer string
+	Title     string
+	Files     []CueFile
+}
+
+// CueFile is an audio file of a CUE sheet with its tracks
+type CueFile struct {
+	Name   string
+	Tracks []CueTrack
+}
+
+// CueTrack is a track of a CUE sheet. Start is its INDEX 01, in seconds from
+// the start of the file.
+type CueTrack struct {
+	Number    int
+	Title     string
+	Performer string
+	Start     float64
+}
+
+// CueError is a CUE sheet next to an audio file that cannot be read
+type CueError struct {
+	Path string
+	Err  error
+}
+
+func (e *CueError) Error() string {
+	return fmt.Sprintf("failed to read CUE sheet %s: %v", e.Path, e.Err)
+}
+
+func (e *CueError) Unwrap() error {
+	return e.Err
+}
+
+// ParseCue parses a CUE sheet. Sheets are expected in UTF-8, with or without
+// a byte order mark; sheets that are not valid UTF-8 are read as Latin-1,
+// which older rippers write. Commands other than FILE, TRACK, INDEX, TITLE
+// and PERFORMER are ignored.
+func ParseCue(data []byte) (*CueSheet, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	text := string(data)
+	if !utf8.Valid(data) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		text = string(runes)
+	}
+
+	sheet := &CueSheet{}
+	var file *CueFile
+	var track *CueTrack
+	// Tracks that have an INDEX 01, by file and track position
+	started := make(map[[2]int]bool)
+
+	for i, line := range strings.Split(text, "\n") {
+		fields := cueFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		lineErr := func(format string, args ...any) error {
+			return fmt.Errorf("line %d: %s", i+1, fmt.Sprintf(format, args...))
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "FILE":
+			if len(fields) < 2 {
+				return nil, lineErr("FILE without a file name")
+			}
+			sheet.Files = append(sheet.Files, CueFile{Name: fields[1]})
+			file = &sheet.Files[len(sheet.Files)-1]
+			track = nil
+
+		case "TRACK":
+			if file == nil {
+				return nil, lineErr("TRACK before FILE")
+			}
+			if len(fields) < 2 {
+				return nil, lineErr("TRACK without a number")
+			}
+			number, err := strconv.Atoi(fields[1])
+			if err != nil || number < 1 {
+				return nil, lineErr("invalid track number %q", fields[1])
+			}
+			file.Tracks = append(file.Tracks, CueTrack{Number: number})
+			track = &file.Tracks[len(file.Tracks)-1]
+
+		case "INDEX":
+			if track == nil {
+				return nil, lineErr("INDEX outside of a TRACK")
+			}
+			if len(fields) < 3 {
+				return nil, lineErr("INDEX without a number and time")
+			}
+			seconds, err := parseCueTime(fields[2])
+			if err != nil {
+				return nil, lineErr("%v", err)
+			}
+			// Other indexes mark gaps and subindexes within tracks
+			if index, err := strconv.Atoi(fields[1]); err == nil && index == 1 {
+				track.Start = seconds
+				started[[2]int{len(sheet.Files) - 1, len(file.Tracks) - 1}] = true
+			}
+
+		case "TITLE", "PERFORMER":
+			if len(fields) < 2 {
+				continue
+			}
+			value := fields[1]
+			switch {
+			case track != nil && strings.EqualFold(fields[0], "TITLE"):
+				track.Title = value
+			case track != nil:
+				track.Performer = value
+			case strings.EqualFold(fields[0], "TITLE"):
+				sheet.Title = value
+			default:
+				sheet.Performer = value
+			}
+		}
+	}
+
+	tracks := 0
+	for i, file := range sheet.Files {
+		for j, track := range file.Tracks {
+			if !started[[2]int{i, j}] {
+				return nil, fmt.Errorf("track %d has no INDEX 01", track.Number)
+			}
+			tracks++
+		}
+	}
+	if tracks == 0 {
+		return nil, errors.New("no tracks")
+	}
+
+	return sheet, nil
+}
+
+// cueFields splits a CUE sheet line into fields, keeping quoted strings together
+func cueFields(line string) []string {
+	var fields []string
+	line = strings.TrimSpace(line)
+	for line != "" {
+		var field string
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				field, line = line[1:], ""
+			} else {
+				field, line = line[1:end+1], line[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				field, line = line, ""
+			} else {
+				field, line = line[:end], line[end:]
+			}
+		}
+		fields = append(fields, field)
+		line = strings.TrimLeft(line, " \t")
+	}
+	return fields
+}
+
+// parseCueTime parses a mm:ss:ff CUE sheet time into seconds
+func parseCueTime(value string) (float64, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid time %q", value)
+		}
+		numbers[i] = n
+	}
+	if numbers[1] >= 60 || numbers[2] >= cueFramesPerSecond {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+
+	return float64(numbers[0]*60+numbers[1]) + float64(numbers[2])/cueFramesPerSecond, nil
+}
+
+// cuePaths returns the paths of the CUE sheets that may describe an audio
+// file: album.cue and album.flac.cue for album.flac
+func cuePaths(filePath string) []string {
+	return []string{
+		strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".cue",
+		filePath + ".cue",
+	}
+}
+
+// hasCueSheet reports whether an audio file has a CUE sheet next to it
+func hasCueSheet(filePath string) bool {
+	for _, cuePath := range cuePaths(filePath) {
+		if _, err := os.Stat(cuePath); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// readCueSheet reads the CUE sheet next to an audio file and returns its path
+// and the entry describing the file. It returns a nil entry if there is no
+// sheet or if the sheet does not describe the file.
+func readCueSheet(filePath string) (string, *CueSheet, *CueFile, error) {
+	for _, cuePath := range cuePaths(filePath) {
+		data, err := os.ReadFile(cuePath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return cuePath, nil, nil, err
+		}
+
+		sheet, err := ParseCue(data)
+		if err != nil {
+			return cuePath, nil, nil, err
+		}
+		return cuePath, sheet, sheet.fileFor(filePath), nil
+	}
+	return "", nil, nil, nil
+}
+
+// fileFor returns the entry of the sheet describing an audio file. Sheets
+// often name the file they were ripped to, such as album.wav for a file since
+// converted to album.flac, so names are compared without their extension, and
+// the only entry of a sheet is used whatever its name.
+func (c *CueSheet) fileFor(filePath string) *CueFile {
+	name := filepath.Base(filePath)
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+
+	// Sheets written on Windows separate directories with backslashes
+	entryName := func(file *CueFile) string {
+		return path.Base(strings.ReplaceAll(file.Name, `\`, "/"))
+	}
+
+	for i := range c.Files {
+		if strings.EqualFold(entryName(&c.Files[i]), name) {
+			return &c.Files[i]
+		}
+	}
+	for i := range c.Files {
+		entry := entryName(&c.Files[i])
+		if strings.EqualFold(strings.TrimSuffix(entry, path.Ext(entry)), stem) {
+			return &c.Files[i]
+		}
+	}
+	if len(c.Files) == 1 {
+		return &c.Files[0]
+	}
+	return nil
+}
+
+// ExtractTracks reads the tracks of an audio file: a single track, or one per
+// track of its CUE sheet. A CUE sheet that cannot be read is returned as a
+// *CueError, along with the file as a single track marked KeepCueTracks.
+func (s *Scanner) ExtractTracks(filePath string) ([]database.PersistentTrack, error) {
+	track, err := s.extractMetadata(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cuePath, sheet, file, err := readCueSheet(filePath)
+	if err != nil {
+		track.KeepCueTracks = true
+		return []database.PersistentTrack{*track}, &CueError{Path: cuePath, Err: err}
+	}
+	if file == nil || len(file.Tracks) == 0 {
+		return []database.PersistentTrack{*track}, nil
+	}
+
+	return s.cueTracks(track, sheet, file), nil
+}
+
+// cueTracks splits the track of a whole file into the tracks of its CUE sheet.
+// As in most players, a track ends where the next one starts, so the gap
+// before a track is played at the end of the previous one, and the last track
+// ends with the file.
+func (s *Scanner) cueTracks(whole *database.PersistentTrack, sheet *CueSheet, file *CueFile) []database.PersistentTrack {
+	var fileDuration float64
+	if decoder, err := audio.OpenDecoder(whole.FilePath); err == nil {
+		if decoder.SampleRate() > 0 {
+			fileDuration = float64(decoder.Length()) / float64(decoder.SampleRate())
+		}
+		decoder.Close()
+	}
+
+	tracks := make([]database.PersistentTrack, 0, len(file.Tracks))
+	for i, cueTrack := range file.Tracks {
+		track := *whole
+		track.CueTrack = int64(cueTrack.Number)
+		trackNumber := int64(cueTrack.Number)
+		track.TrackNumber = &trackNumber
+
+		track.Title = cueTrack.Title
+		if track.Title == "" {
+			track.Title = fmt.Sprintf("Track %02d", cueTrack.Number)
+		}
+		titleLower := strings.ToLower(track.Title)
+		track.TitleLower = &titleLower
+
+		if cueTrack.Performer != "" {
+			track.ArtistName = cueTrack.Performer
+		} else if sheet.Performer != "" {
+			track.ArtistName = sheet.Performer
+		}
+		if sheet.Title != "" {
+			track.AlbumName = sheet.Title
+		}
+		if sheet.Performer != "" {
+			albumArtist := sheet.Performer
+			track.AlbumArtistName = &albumArtist
+		}
+
+		start := cueTrack.Start
+		track.StartOffset = &start
+		track.EndOffset = nil
+		track.Duration = 0
+		if i+1 < len(file.Tracks) {
+			end := file.Tracks[i+1].Start
+			track.EndOffset = &end
+			track.Duration = max(end-start, 0)
+		} else if fileDuration > start {
+			track.Duration = fileDuration - start
+		}
+
+		track.LrcLyrics, track.TxtLyrics = s.readLyrics(&track)
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+// cueLayoutPlaceholder matches the placeholders of a CUE lyrics layout
+var cueLayoutPlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// ValidateCueLyricsLayout checks that a layout gives each CUE track its own
+// lyrics file name. Layouts name the file without its extension, next to the
+// audio file, with the placeholders {file} for the name of the audio file
+// without its extension, {track} for the two-digit track number, and {title}
+// for the track title.
+func ValidateCueLyricsLayout(layout string) error {
+	if strings.TrimSpace(layout) == "" {
+		return errors.New("empty layout")
+	}
+	if strings.ContainsAny(layout, `/\`) {
+		return fmt.Errorf("layout %q must be a file name, not a path", layout)
+	}
+	for _, placeholder := range cueLayoutPlaceholder.FindAllString(layout, -1) {
+		switch placeholder {
+		case "{file}", "{track}", "{title}":
+		default:
+			return fmt.Errorf("unknown placeholder %s in layout %q", placeholder, layout)
+		}
+	}
+	if !strings.Contains(layout, "{track}") && !strings.Contains(layout, "{title}") {
+		return fmt.Errorf("layout %q must contain {track} or {title}", layout)
+	}
+	return nil
+}
+
+// SetCueLyricsLayout sets the layout of the lyrics file names of CUE tracks,
+// as checked by ValidateCueLyricsLayout. An invalid layout is replaced by the
+// default one.
+func (s *Scanner) SetCueLyricsLayout(layout string) {
+	if ValidateCueLyricsLayout(layout) != nil {
+		layout = constants.DefaultCueLyricsLayout
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cueLyricsLayout = layout
+}
+
+// cueLyricsBase returns the path of the lyrics files of a CUE track, without extension
+func (s *Scanner) cueLyricsBase(track *database.PersistentTrack) string {
+	s.mu.RLock()
+	layout := s.cueLyricsLayout
+	s.mu.RUnlock()
+	if layout == "" {
+		layout = constants.DefaultCueLyricsLayout
+	}
+
+	name := filepath.Base(track.FilePath)
+	replacer := strings.NewReplacer(
+		"{file}", strings.TrimSuffix(name, filepath.Ext(name)),
+		"{track}", fmt.Sprintf("%02d", track.CueTrack),
+		"{title}", fileNameReplacer.Replace(track.Title),
+	)
+	return filepath.Join(filepath.Dir(track.FilePath), replacer.Replace(layout))
+}
+
+// fileNameReplacer replaces the characters that are not allowed in file names
+var fileNameReplacer = strings.NewReplacer(
+	"/", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_",
+)
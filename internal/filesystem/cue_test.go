@@ -0,0 +1,262 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lrcget-go/internal/database"
+)
+
+const testCueSheet = `REM GENRE Rock
+PERFORMER "The Band"
+TITLE "Live Album"
+FILE "album.wav" WAVE
+  TRACK 01 AUDIO
+    TITLE "Opening"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Duet"
+    PERFORMER "The Band & Guest"
+    INDEX 00 03:58:00
+    INDEX 01 04:00:37
+  TRACK 03 AUDIO
+    INDEX 01 07:30:00
+`
+
+func TestParseCue(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+		wantErr  bool
+	}{
+		{
+			"sheet",
+			testCueSheet,
+			"The Band|Live Album|album.wav|1 Opening  0.00|2 Duet The Band & Guest 240.49|3   450.00",
+			false,
+		},
+		{
+			"byte order mark and CRLF",
+			"\xef\xbb\xbfTITLE \"Album\"\r\nFILE \"a.flac\" WAVE\r\nTRACK 1 AUDIO\r\nTITLE Intro\r\nINDEX 01 00:01:00\r\n",
+			"|Album|a.flac|1 Intro  1.00",
+			false,
+		},
+		{
+			"Latin-1",
+			"FILE \"a.flac\" WAVE\nTRACK 01 AUDIO\nTITLE \"Caf\xe9\"\nINDEX 01 00:00:00\n",
+			"||a.flac|1 Café  0.00",
+			false,
+		},
+		{"track before file", "TRACK 01 AUDIO\nINDEX 01 00:00:00\n", "", true},
+		{"missing index", "FILE \"a.flac\" WAVE\nTRACK 01 AUDIO\nINDEX 00 00:00:00\n", "", true},
+		{"invalid time", "FILE \"a.flac\" WAVE\nTRACK 01 AUDIO\nINDEX 01 00:00:75\n", "", true},
+		{"no tracks", "FILE \"a.flac\" WAVE\n", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, err := ParseCue([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := sheet.Performer + "|" + sheet.Title
+			for _, file := range sheet.Files {
+				got += "|" + file.Name
+				for _, track := range file.Tracks {
+					got += fmt.Sprintf("|%d %s %s %.2f", track.Number, track.Title, track.Performer, track.Start)
+				}
+			}
+			if got != tt.expected {
+				t.Errorf("ParseCue() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExtractTracks(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "album.mp3")
+	writeTaggedFile(t, audioPath, "Whole Album")
+	os.WriteFile(filepath.Join(dir, "album.02.lrc"), []byte("[00:01.00]Second"), 0644)
+	os.WriteFile(filepath.Join(dir, "Opening.txt"), []byte("First"), 0644)
+
+	scanner := NewScanner()
+	tracks, err := scanner.ExtractTracks(audioPath)
+	if err != nil || len(tracks) != 1 || tracks[0].CueTrack != 0 {
+		t.Fatalf("ExtractTracks() without a CUE sheet = %v, %v, expected a single track", tracks, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "album.cue"), []byte(testCueSheet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		layout   string
+		expected []string
+	}{
+		{
+			"default layout",
+			"",
+			[]string{
+				"1 Opening by The Band on Live Album, 0.00-240.49 (240.49s), lyrics: <nil> <nil>",
+				"2 Duet by The Band & Guest on Live Album, 240.49-450.00 (209.51s), lyrics: [00:01.00]Second <nil>",
+				"3 Track 03 by The Band on Live Album, 450.00-end (0.00s), lyrics: <nil> <nil>",
+			},
+		},
+		{
+			"title layout",
+			"{title}",
+			[]string{
+				"1 Opening by The Band on Live Album, 0.00-240.49 (240.49s), lyrics: <nil> First",
+				"2 Duet by The Band & Guest on Live Album, 240.49-450.00 (209.51s), lyrics: <nil> <nil>",
+				"3 Track 03 by The Band on Live Album, 450.00-end (0.00s), lyrics: <nil> <nil>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner.SetCueLyricsLayout(tt.layout)
+			tracks, err := scanner.ExtractTracks(audioPath)
+			if err != nil {
+				t.Fatalf("ExtractTracks() error = %v", err)
+			}
+
+			var got []string
+			for _, track := range tracks {
+				end := "end"
+				if track.EndOffset != nil {
+					end = fmt.Sprintf("%.2f", *track.EndOffset)
+				}
+				lyrics := func(s *string) string {
+					if s == nil {
+						return "<nil>"
+					}
+					return *s
+				}
+				got = append(got, fmt.Sprintf("%d %s by %s on %s, %.2f-%s (%.2fs), lyrics: %s %s",
+					track.CueTrack, track.Title, track.ArtistName, track.AlbumName,
+					*track.StartOffset, end, track.Duration, lyrics(track.LrcLyrics), lyrics(track.TxtLyrics)))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("ExtractTracks() =\n%v\nexpected\n%v", got, tt.expected)
+			}
+		})
+	}
+
+	// A broken sheet falls back to the whole file
+	if err := os.WriteFile(filepath.Join(dir, "album.cue"), []byte("TRACK 01 AUDIO\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tracks, err = scanner.ExtractTracks(audioPath)
+	var cueErr *CueError
+	if !errors.As(err, &cueErr) || len(tracks) != 1 || tracks[0].Title != "Whole Album" {
+		t.Errorf("ExtractTracks() with a broken CUE sheet = %v, %v, expected the whole file and a CueError", tracks, err)
+	}
+}
+
+func TestCueTracksKeepHistory(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "album.mp3")
+	cuePath := filepath.Join(dir, "album.cue")
+	writeTaggedFile(t, audioPath, "Whole Album")
+	if err := os.WriteFile(cuePath, []byte(testCueSheet), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := database.NewConnection(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	scanner := NewScanner()
+	index := func(step string) []database.PersistentTrack {
+		t.Helper()
+		tracks, err := scanner.ExtractTracks(audioPath)
+		var cueErr *CueError
+		if err != nil && !errors.As(err, &cueErr) {
+			t.Fatalf("%s: ExtractTracks() error = %v", step, err)
+		}
+		if err := conn.BulkUpsertTracks(tracks); err != nil {
+			t.Fatalf("%s: BulkUpsertTracks() error = %v", step, err)
+		}
+		tracks, err = conn.GetTracksByFilePath(audioPath)
+		if err != nil {
+			t.Fatalf("%s: GetTracksByFilePath() error = %v", step, err)
+		}
+		return tracks
+	}
+	history := func(step string, trackID int64) int {
+		t.Helper()
+		entries, err := conn.GetLyricsHistory(trackID)
+		if err != nil {
+			t.Fatalf("%s: GetLyricsHistory() error = %v", step, err)
+		}
+		return len(entries)
+	}
+
+	tracks := index("read")
+	if len(tracks) != 3 {
+		t.Fatalf("Expected the 3 tracks of the CUE sheet, got %d", len(tracks))
+	}
+	duet := tracks[1]
+	lrc := "[00:01.00]Duet"
+	if err := conn.SaveTrackLyrics(duet.ID, &lrc, nil, false, database.HistorySourceDownload); err != nil {
+		t.Fatal(err)
+	}
+
+	// A sheet that cannot be read keeps the tracks read from it before
+	if err := os.WriteFile(cuePath, []byte("TRACK 01 AUDIO\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tracks = index("broken")
+	if len(tracks) != 3 || tracks[1].ID != duet.ID || history("broken", duet.ID) != 1 {
+		t.Fatalf("Expected the CUE tracks and their history to be kept, got %+v", tracks)
+	}
+
+	// A deleted sheet replaces its tracks with the whole file, and their
+	// history comes back with the sheet
+	os.Remove(cuePath)
+	if tracks = index("deleted"); len(tracks) != 1 || tracks[0].CueTrack != 0 {
+		t.Fatalf("Expected the whole file after deleting the CUE sheet, got %+v", tracks)
+	}
+	if err := os.WriteFile(cuePath, []byte(testCueSheet), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tracks = index("restored")
+	if len(tracks) != 3 || history("restored", tracks[1].ID) != 1 {
+		t.Errorf("Expected the history of the CUE track after restoring the sheet, got %d entries", history("restored", tracks[1].ID))
+	}
+}
+
+func TestValidateCueLyricsLayout(t *testing.T) {
+	tests := []struct {
+		layout string
+		valid  bool
+	}{
+		{"{file}.{track}", true},
+		{"{track} - {title}", true},
+		{"{file}", false},
+		{"lyrics/{file}.{track}", false},
+		{"{file}.{number}", false},
+		{" ", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			if err := ValidateCueLyricsLayout(tt.layout); (err == nil) != tt.valid {
+				t.Errorf("ValidateCueLyricsLayout() error = %v, expected valid = %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+
+	"lrcget-go/internal/database"
+)
+
+// InstrumentalLyrics is written to the .lrc file of instrumental tracks
+const InstrumentalLyrics = "[au: instrumental]"
+
+// SaveLyrics writes a track's lyrics next to its audio file. Synced lyrics are
+// written to a .lrc file, plain lyrics to a .txt file, and instrumental tracks
+// get a .lrc file with an instrumental marker. The other sidecar is removed so
+// that players do not pick up stale lyrics. Tracks of a CUE sheet get their
+// own files, named after the CUE lyrics layout.
+func (s *Scanner) SaveLyrics(track *database.PersistentTrack, lrcLyrics, txtLyrics *string, instrumental bool) error {
+	lrcPath, txtPath := s.lyricsPaths(track)
+
+	switch {
+	case instrumental:
+		if err := removeIfExists(txtPath); err != nil {
+			return err
+		}
+		return writeLyricsFile(lrcPath, InstrumentalLyrics)
+
+	case lrcLyrics != nil && *lrcLyrics != "":
+		if err := removeIfExists(txtPath); err != nil {
+			return err
+		}
+		return writeLyricsFile(lrcPath, *lrcLyrics)
+
+	case txtLyrics != nil && *txtLyrics != "":
+		if err := removeIfExists(lrcPath); err != nil {
+			return err
+		}
+		return writeLyricsFile(txtPath, *txtLyrics)
+	}
+
+	// No lyrics at all
+	if err := removeIfExists(lrcPath); err != nil {
+		return err
+	}
+	return removeIfExists(txtPath)
+}
+
+// writeLyricsFile writes lyrics to a file
+func writeLyricsFile(path string, lyrics string) error {
+	if err := os.WriteFile(path, []byte(lyrics), 0644); err != nil {
+		return fmt.Errorf("failed to write lyrics file: %w", err)
+	}
+	return nil
+}
+
+// removeIfExists removes a file, ignoring it if it does not exist
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lyrics file: %w", err)
+	}
+	return nil
+}
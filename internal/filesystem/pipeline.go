@@ -0,0 +1,191 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"lrcget-go/internal/database"
+)
+
+// scanBatchSize is the number of tracks handed to the writer at a time
+const scanBatchSize = 100
+
+// scanPath is an audio file found by the walker, numbered in walk order
+type scanPath struct {
+	index int
+	path  string
+}
+
+// scanItem is the tracks read from a scanPath: a single one, one per track of
+// its CUE sheet, or none if its tags could not be read
+type scanItem struct {
+	index  int
+	tracks []database.PersistentTrack
+}
+
+// Scan walks directories and passes their tracks to write in batches, in walk
+// order. The tracks of a file are always in the same batch. write is called
+// from a single goroutine while the next tracks are read, so only a couple of
+// batches are held in memory at a time. Scanning stops at the first error
+// from write, or when ctx is done. The report lists the paths left out of the
+// scan or that failed to be read.
+func (s *Scanner) Scan(ctx context.Context, directories []string, workers int, write func([]database.PersistentTrack) error) (*ScanReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Write batches on a single goroutine
+	batches := make(chan []database.PersistentTrack, 1)
+	var writeErr error
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		for batch := range batches {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := write(batch); err != nil {
+				writeErr = err
+				cancel()
+				return
+			}
+		}
+	}()
+
+	var batch []database.PersistentTrack
+	send := func() {
+		if len(batch) > 0 {
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+			}
+			batch = nil
+		}
+	}
+	report, err := s.ScanIter(ctx, directories, workers, func(track database.PersistentTrack) error {
+		// The tracks of a file split by a CUE sheet stay in the same batch
+		if len(batch) >= scanBatchSize && batch[len(batch)-1].FilePath != track.FilePath {
+			send()
+		}
+		batch = append(batch, track)
+		return nil
+	})
+	if err == nil {
+		send()
+	}
+	close(batches)
+	<-written
+
+	if writeErr != nil {
+		return report, writeErr
+	}
+	return report, err
+}
+
+// ScanIter walks directories and reads the tags of their audio files with a
+// pipeline: a walker feeds paths to workers goroutines reading tags, and each
+// track is passed to yield as soon as it and every track before it in walk
+// order are read. A file with a CUE sheet yields one track per track of the
+// sheet. yield is called from the calling goroutine. Files and
+// directories that cannot be read are skipped, and recorded with the paths
+// left out of the scan in the report. Scanning stops at the first error from
+// yield, or when ctx is done.
+func (s *Scanner) ScanIter(ctx context.Context, directories []string, workers int, yield func(database.PersistentTrack) error) (*ScanReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	workers = max(workers, 1)
+	report := &ScanReport{StartedAt: time.Now()}
+
+	// Walk directories into a bounded queue of paths
+	paths := make(chan scanPath, workers*2)
+	walked := make(chan struct{})
+	go func() {
+		defer close(walked)
+		defer close(paths)
+		s.walk(ctx, directories, report, paths)
+	}()
+
+	// Read tags concurrently
+	items := make(chan scanItem, workers*2)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range paths {
+				tracks, err := s.ExtractTracks(p.path)
+				var cueErr *CueError
+				switch {
+				case errors.As(err, &cueErr):
+					report.fail(cueErr.Path, ScanErrorCue, cueErr.Err)
+				case err != nil:
+					report.fail(p.path, ScanErrorTags, err)
+				}
+
+				select {
+				case items <- scanItem{index: p.index, tracks: tracks}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(items)
+	}()
+
+	// Restore walk order, since workers finish in any order. Items are
+	// drained after a stop so that the workers can exit.
+	pending := make(map[int][]database.PersistentTrack)
+	next := 0
+	var yieldErr error
+	for item := range items {
+		if ctx.Err() != nil {
+			continue
+		}
+
+		pending[item.index] = item.tracks
+		for ctx.Err() == nil {
+			tracks, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			for _, track := range tracks {
+				report.Tracks++
+				if err := yield(track); err != nil {
+					yieldErr = err
+					cancel()
+					break
+				}
+			}
+		}
+	}
+
+	<-walked
+	report.FinishedAt = time.Now()
+
+	if yieldErr != nil {
+		return report, yieldErr
+	}
+	return report, ctx.Err()
+}
+
+// walk sends the audio files of directories that are scanned to paths, in
+// lexical order, until ctx is done
+func (s *Scanner) walk(ctx context.Context, directories []string, report *ScanReport, paths chan<- scanPath) {
+	index := 0
+	s.walkLibrary(directories, report, func(path string) error {
+		select {
+		case paths <- scanPath{index: index, path: path}:
+			index++
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lrcget-go/internal/database"
+)
+
+// writeTaggedFile writes an MP3 file with only an ID3v2 tag holding a title
+func writeTaggedFile(t *testing.T, path, title string) {
+	t.Helper()
+
+	frame := append([]byte{0}, title...)
+	tag := append([]byte("TIT2"), byte(len(frame)>>24), byte(len(frame)>>16), byte(len(frame)>>8), byte(len(frame)), 0, 0)
+	tag = append(tag, frame...)
+
+	size := len(tag)
+	header := []byte{'I', 'D', '3', 3, 0, 0, byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(header, tag...), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeLibrary writes n tagged files spread over nested directories, plus
+// files that must be skipped, and returns the expected titles in walk order
+func writeLibrary(t *testing.T, root string, n int) []string {
+	t.Helper()
+
+	var titles []string
+	for i := range n {
+		title := fmt.Sprintf("Song %03d", i)
+		writeTaggedFile(t, filepath.Join(root, fmt.Sprintf("disc %d", i/60), fmt.Sprintf("%03d.mp3", i)), title)
+		titles = append(titles, title)
+	}
+	os.WriteFile(filepath.Join(root, "disc 0", "broken.mp3"), []byte("not audio"), 0644)
+	os.WriteFile(filepath.Join(root, "disc 0", "cover.jpg"), nil, 0644)
+	return titles
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	expected := writeLibrary(t, root, 250)
+
+	for _, workers := range []int{1, 8} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			var titles []string
+			var sizes []int
+			_, err := NewScanner().Scan(context.Background(), []string{root}, workers, func(tracks []database.PersistentTrack) error {
+				sizes = append(sizes, len(tracks))
+				for _, track := range tracks {
+					titles = append(titles, track.Title)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			if fmt.Sprint(titles) != fmt.Sprint(expected) {
+				t.Errorf("Scan() titles = %v, expected %v", titles, expected)
+			}
+			if fmt.Sprint(sizes) != "[100 100 50]" {
+				t.Errorf("Scan() batch sizes = %v, expected [100 100 50]", sizes)
+			}
+		})
+	}
+}
+
+func TestScanStops(t *testing.T) {
+	root := t.TempDir()
+	writeLibrary(t, root, 250)
+	errWrite := errors.New("disk full")
+
+	tests := []struct {
+		name     string
+		write    func(cancel context.CancelFunc) error
+		expected error
+	}{
+		{"write error", func(context.CancelFunc) error { return errWrite }, errWrite},
+		{"cancelled", func(cancel context.CancelFunc) error { cancel(); return nil }, context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			batches := 0
+			_, err := NewScanner().Scan(ctx, []string{root}, 4, func([]database.PersistentTrack) error {
+				batches++
+				return tt.write(cancel)
+			})
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Scan() error = %v, expected %v", err, tt.expected)
+			}
+			if batches != 1 {
+				t.Errorf("Scan() wrote %d batches, expected 1", batches)
+			}
+		})
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	root := t.TempDir()
+	library := filepath.Join(root, "music")
+	writeLibrary(t, library, 3)
+	os.Symlink(filepath.Join(root, "gone"), filepath.Join(library, "disc 0", "dangling"))
+	locked := filepath.Join(library, "locked")
+	writeTaggedFile(t, filepath.Join(locked, "01.mp3"), "Locked")
+	os.Chmod(locked, 0)
+	defer os.Chmod(locked, 0755)
+
+	// A missing directory or an unreadable one does not stop the scan of the others
+	directories := []string{filepath.Join(root, "missing"), library}
+	var titles []string
+	report, err := NewScanner().Scan(context.Background(), directories, 4, func(tracks []database.PersistentTrack) error {
+		for _, track := range tracks {
+			titles = append(titles, track.Title)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	expected := []string{"Song 000", "Song 001", "Song 002"}
+	failures := []ScanError{
+		{Path: filepath.Join(root, "missing"), Kind: ScanErrorIO},
+		{Path: filepath.Join(library, "disc 0", "dangling"), Kind: ScanErrorIO},
+		{Path: filepath.Join(library, "disc 0", "broken.mp3"), Kind: ScanErrorTags},
+	}
+	if os.Geteuid() != 0 {
+		// Permissions do not apply to root
+		failures = append(failures, ScanError{Path: locked, Kind: ScanErrorPermission})
+	} else {
+		expected = append(expected, "Locked")
+	}
+
+	if fmt.Sprint(titles) != fmt.Sprint(expected) {
+		t.Errorf("Scan() titles = %v, expected %v", titles, expected)
+	}
+	if report.Tracks != len(expected) {
+		t.Errorf("Scan() report tracks = %d, expected %d", report.Tracks, len(expected))
+	}
+
+	kinds := make(map[string]string)
+	for _, e := range report.Errors {
+		if e.Message == "" {
+			t.Errorf("Scan() report error of %s has no message", e.Path)
+		}
+		kinds[e.Path] = e.Kind
+	}
+	if len(kinds) != len(failures) {
+		t.Errorf("Scan() report errors = %v, expected %v", report.Errors, failures)
+	}
+	for _, e := range failures {
+		if kinds[e.Path] != e.Kind {
+			t.Errorf("Scan() report error of %s = %q, expected %q", e.Path, kinds[e.Path], e.Kind)
+		}
+	}
+}
+
+func TestScanIter(t *testing.T) {
+	root := t.TempDir()
+	expected := writeLibrary(t, root, 150)
+
+	var titles []string
+	_, err := NewScanner().ScanIter(context.Background(), []string{root}, 8, func(track database.PersistentTrack) error {
+		titles = append(titles, track.Title)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanIter() error = %v", err)
+	}
+	if fmt.Sprint(titles) != fmt.Sprint(expected) {
+		t.Errorf("ScanIter() titles = %v, expected %v", titles, expected)
+	}
+
+	// Tracks stop coming once yield fails
+	errStop := errors.New("stop")
+	count := 0
+	_, err = NewScanner().ScanIter(context.Background(), []string{root}, 8, func(database.PersistentTrack) error {
+		count++
+		if count == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("ScanIter() error = %v, expected %v", err, errStop)
+	}
+	if count != 10 {
+		t.Errorf("ScanIter() yielded %d tracks, expected 10", count)
+	}
+}
@@ -0,0 +1,71 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// Reasons a path is left out of a scan
+const (
+	SkipSymlink   = "symlink"   // a symbolic link to a directory, while links are not followed
+	SkipLoop      = "loop"      // a directory that is also one of its own parents
+	SkipDuplicate = "duplicate" // a directory already walked through another path
+	SkipNested    = "nested"    // a library directory inside another one
+)
+
+// Kinds of errors a scan recovers from
+const (
+	ScanErrorPermission = "permission" // a file or directory that cannot be read
+	ScanErrorIO         = "io"         // a file or directory that failed to open or list
+	ScanErrorTags       = "tags"       // an audio file whose tags cannot be parsed
+	ScanErrorCue        = "cue"        // a CUE sheet that cannot be parsed
+)
+
+// ScanSkip is a path left out of a scan and the reason why
+type ScanSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ScanError is a path a scan failed to read
+type ScanError struct {
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// ScanReport lists what a scan found, left out and failed to read. It is
+// safe for concurrent use.
+type ScanReport struct {
+	mu         sync.Mutex
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt time.Time   `json:"finished_at"`
+	Tracks     int         `json:"tracks"`
+	Skipped    []ScanSkip  `json:"skipped"`
+	Errors     []ScanError `json:"errors"`
+}
+
+// skip records a path left out of the scan
+func (r *ScanReport) skip(path, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Skipped = append(r.Skipped, ScanSkip{Path: path, Reason: reason})
+}
+
+// fail records a path the scan failed to read. Errors that are not about
+// access to the file are recorded with kind.
+func (r *ScanReport) fail(path, kind string, err error) {
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		kind = ScanErrorPermission
+	case errors.As(err, &pathErr):
+		kind = ScanErrorIO
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Errors = append(r.Errors, ScanError{Path: path, Kind: kind, Message: err.Error()})
+}
@@ -0,0 +1,146 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ScanRules selects the files of a library directory that are scanned.
+//
+// Patterns use path.Match syntax. A pattern containing a slash is matched
+// against the slash-separated path relative to the library directory, any
+// other pattern against the name of each file and directory. Include patterns
+// only apply to files: when there are any, only matching files are scanned.
+// Exclude patterns apply to both, and an excluded directory is not walked.
+// Symbolic links to directories are only walked with FollowSymlinks.
+type ScanRules struct {
+	Include        []string `json:"include"`
+	Exclude        []string `json:"exclude"`
+	SkipHidden     bool     `json:"skip_hidden"`
+	MinSize        int64    `json:"min_size"`
+	FollowSymlinks bool     `json:"follow_symlinks"`
+}
+
+// ValidatePatterns checks that glob patterns are well formed
+func ValidatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("empty pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether rel, a slash-separated relative path, matches one of patterns
+func matchAny(patterns []string, rel string) bool {
+	name := path.Base(rel)
+	for _, pattern := range patterns {
+		target := name
+		if strings.Contains(pattern, "/") {
+			target = rel
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDir reports whether the directory at rel is left out of the scan
+func (r ScanRules) skipDir(rel string) bool {
+	if r.SkipHidden && strings.HasPrefix(path.Base(rel), ".") {
+		return true
+	}
+	return matchAny(r.Exclude, rel)
+}
+
+// skipFile reports whether the file at rel, of size bytes, is left out of the scan
+func (r ScanRules) skipFile(rel string, size int64) bool {
+	if r.SkipHidden && strings.HasPrefix(path.Base(rel), ".") {
+		return true
+	}
+	if size < r.MinSize {
+		return true
+	}
+	if matchAny(r.Exclude, rel) {
+		return true
+	}
+	return len(r.Include) > 0 && !matchAny(r.Include, rel)
+}
+
+// SetRules sets the scan rules of each library directory. Directories
+// without rules are scanned entirely.
+func (s *Scanner) SetRules(rules map[string]ScanRules) {
+	cleaned := make(map[string]ScanRules, len(rules))
+	for directory, r := range rules {
+		cleaned[filepath.Clean(directory)] = r
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = cleaned
+}
+
+// rulesFor returns the rules of a library directory
+func (s *Scanner) rulesFor(directory string) ScanRules {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rules[filepath.Clean(directory)]
+}
+
+// Includes reports whether an audio file is part of the library according to
+// the rules of the library directory containing it. As in a scan, the
+// outermost library directory applies.
+func (s *Scanner) Includes(filePath string) bool {
+	if !s.isAudioFile(filePath) {
+		return false
+	}
+
+	s.mu.RLock()
+	directory, rules := "", ScanRules{}
+	for root, r := range s.rules {
+		if within(root, filePath) && (directory == "" || len(root) < len(directory)) {
+			directory, rules = root, r
+		}
+	}
+	s.mu.RUnlock()
+	if directory == "" {
+		return true
+	}
+
+	rel, err := filepath.Rel(directory, filePath)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	// Every directory between the library directory and the file must be walked
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if rules.skipDir(strings.Join(parts[:i], "/")) {
+			return false
+		}
+	}
+
+	var size int64
+	if rules.MinSize > 0 {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return false
+		}
+		size = info.Size()
+	}
+	return !rules.skipFile(rel, size)
+}
+
+// within reports whether filePath is directory or inside it
+func within(directory, filePath string) bool {
+	rel, err := filepath.Rel(directory, filePath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,102 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lrcget-go/internal/database"
+)
+
+func TestScanRules(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"Artist/01.mp3",
+		"Artist/02.flac",
+		"Artist/@eaDir/01.mp3",
+		"Artist/._01.mp3",
+		".AppleDouble/03.mp3",
+		"Samples/kick.wav",
+		"Audiobooks/chapter 1.mp3",
+	} {
+		writeTaggedFile(t, filepath.Join(root, name), name)
+	}
+	// Padded to pass the minimum size
+	big := filepath.Join(root, "Artist", "03.mp3")
+	writeTaggedFile(t, big, "Artist/03.mp3")
+	file, err := os.OpenFile(big, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write(make([]byte, 2048))
+	file.Close()
+
+	tests := []struct {
+		name     string
+		rules    ScanRules
+		expected []string
+	}{
+		{"no rules", ScanRules{}, []string{".AppleDouble/03.mp3", "Artist/._01.mp3", "Artist/01.mp3", "Artist/02.flac", "Artist/03.mp3", "Artist/@eaDir/01.mp3", "Audiobooks/chapter 1.mp3", "Samples/kick.wav"}},
+		{"skip hidden", ScanRules{SkipHidden: true}, []string{"Artist/01.mp3", "Artist/02.flac", "Artist/03.mp3", "Artist/@eaDir/01.mp3", "Audiobooks/chapter 1.mp3", "Samples/kick.wav"}},
+		{"exclude names", ScanRules{Exclude: []string{"@eaDir", "Samples", "._*"}}, []string{".AppleDouble/03.mp3", "Artist/01.mp3", "Artist/02.flac", "Artist/03.mp3", "Audiobooks/chapter 1.mp3"}},
+		{"exclude path", ScanRules{Exclude: []string{"Audiobooks/*"}}, []string{".AppleDouble/03.mp3", "Artist/._01.mp3", "Artist/01.mp3", "Artist/02.flac", "Artist/03.mp3", "Artist/@eaDir/01.mp3", "Samples/kick.wav"}},
+		{"include", ScanRules{Include: []string{"*.flac", "Samples/*"}}, []string{"Artist/02.flac", "Samples/kick.wav"}},
+		{"minimum size", ScanRules{MinSize: 1024}, []string{"Artist/03.mp3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner()
+			scanner.SetRules(map[string]ScanRules{root: tt.rules})
+
+			var scanned []string
+			_, err := scanner.ScanIter(context.Background(), []string{root}, 2, func(track database.PersistentTrack) error {
+				scanned = append(scanned, track.Title)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ScanIter() error = %v", err)
+			}
+			if fmt.Sprint(scanned) != fmt.Sprint(tt.expected) {
+				t.Errorf("ScanIter() = %v, expected %v", scanned, tt.expected)
+			}
+
+			count, err := scanner.CountFiles([]string{root})
+			if err != nil || count != len(tt.expected) {
+				t.Errorf("CountFiles() = %d, %v, expected %d", count, err, len(tt.expected))
+			}
+
+			var included []string
+			filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+				if !entry.IsDir() && scanner.Includes(path) {
+					rel, _ := filepath.Rel(root, path)
+					included = append(included, filepath.ToSlash(rel))
+				}
+				return nil
+			})
+			if fmt.Sprint(included) != fmt.Sprint(tt.expected) {
+				t.Errorf("Includes() = %v, expected %v", included, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		valid    bool
+	}{
+		{nil, true},
+		{[]string{"@eaDir", "*.wav", "Audiobooks/*"}, true},
+		{[]string{"[a-"}, false},
+		{[]string{""}, false},
+	}
+
+	for _, tt := range tests {
+		if err := ValidatePatterns(tt.patterns); (err == nil) != tt.valid {
+			t.Errorf("ValidatePatterns(%q) error = %v, expected valid = %v", tt.patterns, err, tt.valid)
+		}
+	}
+}
@@ -1,12 +1,16 @@
 package filesystem
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"lrcget-go/internal/artwork"
+	"lrcget-go/internal/constants"
 	"lrcget-go/internal/database"
 
 	"github.com/dhowden/tag"
@@ -14,6 +18,11 @@ import (
 
 // Scanner represents a file system scanner
 type Scanner struct {
+	mu              sync.RWMutex
+	rules           map[string]ScanRules
+	cueLyricsLayout string
+	artwork         *artwork.Cache
+	folderImages    map[string]folderImage
 }
 
 // NewScanner creates a new file system scanner
@@ -21,56 +30,21 @@ func NewScanner() *Scanner {
 	return &Scanner{}
 }
 
-// ScanDirectories scans directories for audio files
+// ScanDirectories scans directories for audio files, reading tags with
+// several workers, and returns the tracks in walk order. Every track is held
+// in memory; use Scan or ScanIter for a whole library.
 func (s *Scanner) ScanDirectories(directories []string) ([]database.PersistentTrack, error) {
 	var allTracks []database.PersistentTrack
 
-	for _, directory := range directories {
-		tracks, err := s.scanDirectory(directory)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan directory %s: %w", directory, err)
-		}
+	_, err := s.Scan(context.Background(), directories, constants.DefaultMaxWorkers, func(tracks []database.PersistentTrack) error {
 		allTracks = append(allTracks, tracks...)
-	}
-
-	return allTracks, nil
-}
-
-// scanDirectory scans a single directory for audio files
-func (s *Scanner) scanDirectory(directory string) ([]database.PersistentTrack, error) {
-	var tracks []database.PersistentTrack
-
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		// Check if file is an audio file
-		if !s.isAudioFile(path) {
-			return nil
-		}
-
-		// Extract metadata from audio file
-		track, err := s.extractMetadata(path)
-		if err != nil {
-			// Log error but continue scanning
-			fmt.Printf("Failed to extract metadata from %s: %v\n", path, err)
-			return nil
-		}
-
-		tracks = append(tracks, *track)
 		return nil
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return nil, err
 	}
 
-	return tracks, nil
+	return allTracks, nil
 }
 
 // isAudioFile checks if a file is an audio file
@@ -146,6 +120,7 @@ func (s *Scanner) extractMetadata(filePath string) (*database.PersistentTrack, e
 		Instrumental:    false,
 		TxtLyrics:       txtLyrics,
 		LrcLyrics:       lrcLyrics,
+		ImagePath:       s.albumArt(filePath, tags.Picture()),
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
@@ -200,31 +175,96 @@ func (s *Scanner) getLrcPath(filePath string) string {
 	return filepath.Join(dir, base+".lrc")
 }
 
-// CountFiles counts the number of audio files in directories
+// lyricsPaths returns the paths to the .lrc and .txt lyrics files of a track.
+// Tracks of a CUE sheet have their own files, named after the CUE lyrics layout.
+func (s *Scanner) lyricsPaths(track *database.PersistentTrack) (string, string) {
+	if track.CueTrack == 0 {
+		return s.getLrcPath(track.FilePath), s.getTxtPath(track.FilePath)
+	}
+	base := s.cueLyricsBase(track)
+	return base + ".lrc", base + ".txt"
+}
+
+// readLyrics returns the content of a track's .lrc and .txt lyrics files, nil
+// for those that do not exist
+func (s *Scanner) readLyrics(track *database.PersistentTrack) (*string, *string) {
+	lrcPath, txtPath := s.lyricsPaths(track)
+
+	var lyrics [2]*string
+	for i, path := range []string{lrcPath, txtPath} {
+		if content, err := os.ReadFile(path); err == nil {
+			text := string(content)
+			lyrics[i] = &text
+		}
+	}
+	return lyrics[0], lyrics[1]
+}
+
+// CountFiles counts the number of audio files in directories that are
+// scanned. Files and directories that cannot be read are not counted.
 func (s *Scanner) CountFiles(directories []string) (int, error) {
 	count := 0
+	s.walkLibrary(directories, &ScanReport{}, func(string) error {
+		count++
+		return nil
+	})
+	return count, nil
+}
 
-	for _, directory := range directories {
-		err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
+// IsAudioFile checks if a file is an audio file
+func (s *Scanner) IsAudioFile(path string) bool {
+	return s.isAudioFile(path)
+}
 
-			if info.IsDir() {
-				return nil
-			}
+// ExtractMetadata reads a track from an audio file, including the lyrics of its sidecar files
+func (s *Scanner) ExtractMetadata(filePath string) (*database.PersistentTrack, error) {
+	return s.extractMetadata(filePath)
+}
 
-			if s.isAudioFile(path) {
-				count++
-			}
+// GetTxtLyrics returns the content of a track's .txt lyrics file, or nil if there is none
+func (s *Scanner) GetTxtLyrics(track *database.PersistentTrack) *string {
+	_, txtLyrics := s.readLyrics(track)
+	return txtLyrics
+}
 
-			return nil
-		})
+// GetLrcLyrics returns the content of a track's .lrc lyrics file, or nil if there is none
+func (s *Scanner) GetLrcLyrics(track *database.PersistentTrack) *string {
+	lrcLyrics, _ := s.readLyrics(track)
+	return lrcLyrics
+}
 
-		if err != nil {
-			return 0, fmt.Errorf("failed to count files in directory %s: %w", directory, err)
-		}
+// IsLyricsFile checks if a file is a .lrc or .txt lyrics file
+func (s *Scanner) IsLyricsFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".lrc" || ext == ".txt"
+}
+
+// IsCueFile checks if a file is a .cue sheet
+func (s *Scanner) IsCueFile(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".cue"
+}
+
+// FindAudioFiles returns the existing audio files that a lyrics file or CUE
+// sheet belongs to: album.flac for album.lrc, album.cue and album.flac.cue.
+// Since the lyrics files of CUE tracks are named after a layout, every audio
+// file with a CUE sheet in the directory of a lyrics file is returned as well.
+func (s *Scanner) FindAudioFiles(sidecarPath string) []string {
+	base := strings.TrimSuffix(sidecarPath, filepath.Ext(sidecarPath))
+	entries, err := os.ReadDir(filepath.Dir(sidecarPath))
+	if err != nil {
+		return nil
 	}
 
-	return count, nil
+	var files []string
+	for _, entry := range entries {
+		path := filepath.Join(filepath.Dir(sidecarPath), entry.Name())
+		if entry.IsDir() || !s.isAudioFile(path) {
+			continue
+		}
+		if path == base || strings.TrimSuffix(path, filepath.Ext(path)) == base ||
+			(s.IsLyricsFile(sidecarPath) && hasCueSheet(path)) {
+			files = append(files, path)
+		}
+	}
+	return files
 }
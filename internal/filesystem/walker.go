@@ -0,0 +1,165 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// walker visits the audio files of library directories in lexical order. A
+// directory reached a second time, through a symbolic link or a bind mount,
+// is walked only once, which also breaks loops.
+type walker struct {
+	scanner *Scanner
+	report  *ScanReport
+	visit   func(path string) error
+	visited map[fileID]string
+}
+
+// walkLibrary calls visit with every audio file of directories that is
+// scanned. Library directories inside another one are skipped, since their
+// files are visited with those of the outer directory, and so are repeated
+// ones. Files and directories that cannot be read are recorded in the report
+// and the walk goes on; it only stops at an error from visit.
+func (s *Scanner) walkLibrary(directories []string, report *ScanReport, visit func(path string) error) error {
+	w := &walker{
+		scanner: s,
+		report:  report,
+		visit:   visit,
+		visited: make(map[fileID]string),
+	}
+
+	for _, directory := range directories {
+		if isNested(directories, directory) {
+			report.skip(directory, SkipNested)
+			continue
+		}
+
+		info, err := os.Stat(directory)
+		if err == nil && !info.IsDir() {
+			err = &fs.PathError{Op: "scan", Path: directory, Err: errors.New("not a directory")}
+		}
+		if err != nil {
+			report.fail(directory, ScanErrorIO, err)
+			continue
+		}
+
+		if err := w.dir(directory, s.rulesFor(directory), directory, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNested reports whether directory is inside another library directory
+func isNested(directories []string, directory string) bool {
+	for _, other := range directories {
+		if filepath.Clean(other) != filepath.Clean(directory) && within(other, directory) {
+			return true
+		}
+	}
+	return false
+}
+
+// dir walks a directory of the library directory root
+func (w *walker) dir(root string, rules ScanRules, path string, info fs.FileInfo) error {
+	if id, ok := fileIdentity(path, info); ok {
+		if previous, seen := w.visited[id]; seen {
+			if previous != path && within(previous, path) {
+				w.report.skip(path, SkipLoop)
+			} else {
+				w.report.skip(path, SkipDuplicate)
+			}
+			return nil
+		}
+		w.visited[id] = path
+	}
+
+	// The entries read before an error are still walked
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		w.report.fail(path, ScanErrorIO, err)
+	}
+
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		rel, err := filepath.Rel(root, child)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		// Links to files are read as files; links to directories are only
+		// walked when following links is enabled
+		var info fs.FileInfo
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, err = os.Stat(child)
+			if err != nil {
+				w.report.fail(child, ScanErrorIO, err)
+				continue
+			}
+			if info.IsDir() && !rules.FollowSymlinks {
+				w.report.skip(child, SkipSymlink)
+				continue
+			}
+		} else {
+			info, err = entry.Info()
+			if err != nil {
+				w.report.fail(child, ScanErrorIO, err)
+				continue
+			}
+		}
+
+		if info.IsDir() {
+			if rules.skipDir(rel) {
+				continue
+			}
+			if err := w.dir(root, rules, child, info); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !w.scanner.isAudioFile(child) || rules.skipFile(rel, info.Size()) {
+			continue
+		}
+		if err := w.visit(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NormalizeDirectories cleans and makes library directories absolute, and
+// removes duplicates. Directories inside another one are rejected, since
+// their files would be indexed twice.
+func NormalizeDirectories(directories []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, directory := range directories {
+		if strings.TrimSpace(directory) == "" {
+			continue
+		}
+		abs, err := filepath.Abs(directory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid directory %s: %w", directory, err)
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			normalized = append(normalized, abs)
+		}
+	}
+
+	for _, directory := range normalized {
+		for _, other := range normalized {
+			if other != directory && within(other, directory) {
+				return nil, fmt.Errorf("directory %s is inside %s", directory, other)
+			}
+		}
+	}
+
+	return normalized, nil
+}
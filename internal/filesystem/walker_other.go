@@ -0,0 +1,22 @@
+//go:build !windows
+
+package filesystem
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileID identifies a directory independently of the path it is reached by
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileIdentity returns the device and inode of a file
+func fileIdentity(path string, info fs.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
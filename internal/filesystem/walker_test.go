@@ -0,0 +1,128 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lrcget-go/internal/database"
+)
+
+func TestWalkLinks(t *testing.T) {
+	root := t.TempDir()
+	library := filepath.Join(root, "music")
+	outside := filepath.Join(root, "elsewhere")
+	writeTaggedFile(t, filepath.Join(library, "Rock", "01.mp3"), "Rock 01")
+	writeTaggedFile(t, filepath.Join(outside, "01.mp3"), "Elsewhere 01")
+
+	links := map[string]string{
+		filepath.Join(library, "Rock", "Loop"): library,
+		filepath.Join(library, "Same Rock"):    filepath.Join(library, "Rock"),
+		filepath.Join(library, "Elsewhere"):    outside,
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		directories []string
+		follow      bool
+		expected    []string
+		skipped     []ScanSkip
+	}{
+		{
+			"links not followed",
+			[]string{library},
+			false,
+			[]string{"Rock 01"},
+			[]ScanSkip{
+				{filepath.Join(library, "Elsewhere"), SkipSymlink},
+				{filepath.Join(library, "Rock", "Loop"), SkipSymlink},
+				{filepath.Join(library, "Same Rock"), SkipSymlink},
+			},
+		},
+		{
+			"links followed",
+			[]string{library},
+			true,
+			[]string{"Elsewhere 01", "Rock 01"},
+			[]ScanSkip{
+				{filepath.Join(library, "Rock", "Loop"), SkipLoop},
+				{filepath.Join(library, "Same Rock"), SkipDuplicate},
+			},
+		},
+		{
+			"nested directories",
+			[]string{filepath.Join(library, "Rock"), library, library},
+			false,
+			[]string{"Rock 01"},
+			[]ScanSkip{
+				{filepath.Join(library, "Rock"), SkipNested},
+				{filepath.Join(library, "Elsewhere"), SkipSymlink},
+				{filepath.Join(library, "Rock", "Loop"), SkipSymlink},
+				{filepath.Join(library, "Same Rock"), SkipSymlink},
+				{library, SkipDuplicate},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner()
+			rules := make(map[string]ScanRules)
+			for _, directory := range tt.directories {
+				rules[directory] = ScanRules{FollowSymlinks: tt.follow}
+			}
+			scanner.SetRules(rules)
+
+			var titles []string
+			report, err := scanner.ScanIter(context.Background(), tt.directories, 2, func(track database.PersistentTrack) error {
+				titles = append(titles, track.Title)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ScanIter() error = %v", err)
+			}
+			if fmt.Sprint(titles) != fmt.Sprint(tt.expected) {
+				t.Errorf("ScanIter() titles = %v, expected %v", titles, tt.expected)
+			}
+			if fmt.Sprint(report.Skipped) != fmt.Sprint(tt.skipped) {
+				t.Errorf("ScanIter() skipped = %v, expected %v", report.Skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestNormalizeDirectories(t *testing.T) {
+	tests := []struct {
+		name        string
+		directories []string
+		expected    []string
+		valid       bool
+	}{
+		{"cleaned", []string{"/music/", "/podcasts/../audiobooks"}, []string{"/music", "/audiobooks"}, true},
+		{"duplicates", []string{"/music", "/music/", "", "/music/./"}, []string{"/music"}, true},
+		{"prefix is not nesting", []string{"/music", "/music2"}, []string{"/music", "/music2"}, true},
+		{"nested", []string{"/music", "/music/rock"}, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directories, err := NormalizeDirectories(tt.directories)
+			if (err == nil) != tt.valid {
+				t.Fatalf("NormalizeDirectories() error = %v, expected valid = %v", err, tt.valid)
+			}
+			for i := range tt.expected {
+				tt.expected[i] = filepath.FromSlash(tt.expected[i])
+			}
+			if tt.valid && fmt.Sprint(directories) != fmt.Sprint(tt.expected) {
+				t.Errorf("NormalizeDirectories() = %v, expected %v", directories, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package filesystem
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// fileID identifies a directory independently of the path it is reached by
+type fileID struct {
+	path string
+}
+
+// fileIdentity returns the path of a file with every link resolved, since
+// file information has no inode on Windows
+func fileIdentity(path string, info fs.FileInfo) (fileID, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	return fileID{path: strings.ToLower(resolved)}, true
+}
@@ -0,0 +1,260 @@
+package filesystem
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultWatchDebounce is how long a path must stay unchanged before its
+// change is reported, so that a file being copied is reported once complete
+const DefaultWatchDebounce = 2 * time.Second
+
+// watchEvent is a kind of change reported by the watcher
+type watchEvent int
+
+const (
+	fileAdded watchEvent = iota + 1
+	fileRemoved
+	fileModified
+	directoryAdded
+	directoryRemoved
+	fileMoved
+	directoryMoved
+)
+
+// watchBackend receives changes from the operating system until stopped
+type watchBackend interface {
+	stop() error
+}
+
+// pendingChange is a change waiting for its path to settle. A move records
+// the path it came from.
+type pendingChange struct {
+	event watchEvent
+	from  string
+	timer *time.Timer
+}
+
+// Watcher watches directories recursively and reports added, removed, modified
+// and moved files. Changes are debounced per path, and successive changes to
+// the same path are merged, so that creating and then writing a file is
+// reported as a single addition.
+type Watcher struct {
+	mu       sync.Mutex
+	debounce time.Duration
+	backend  watchBackend
+	pending  map[string]*pendingChange
+
+	// callbackMu makes callbacks run one at a time
+	callbackMu    sync.Mutex
+	callbacks     map[watchEvent][]func(string)
+	moveCallbacks map[watchEvent][]func(string, string)
+}
+
+// NewWatcher creates a file system watcher
+func NewWatcher() *Watcher {
+	return &Watcher{
+		debounce:      DefaultWatchDebounce,
+		pending:       make(map[string]*pendingChange),
+		callbacks:     make(map[watchEvent][]func(string)),
+		moveCallbacks: make(map[watchEvent][]func(string, string)),
+	}
+}
+
+// SetDebounce sets how long a path must stay unchanged before its change is reported
+func (w *Watcher) SetDebounce(debounce time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.debounce = debounce
+}
+
+// Watch starts watching directories and their subdirectories, replacing the
+// directories watched before
+func (w *Watcher) Watch(directories []string) error {
+	if err := w.Stop(); err != nil {
+		return err
+	}
+
+	backend, err := startWatchBackend(directories, w.notify, w.notifyMove)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.backend = backend
+	return nil
+}
+
+// Stop stops watching and drops the changes not reported yet
+func (w *Watcher) Stop() error {
+	w.mu.Lock()
+	backend := w.backend
+	w.backend = nil
+	for path, change := range w.pending {
+		change.timer.Stop()
+		delete(w.pending, path)
+	}
+	w.mu.Unlock()
+
+	if backend != nil {
+		return backend.stop()
+	}
+	return nil
+}
+
+// OnFileAdded subscribes to files being created or moved into a watched directory
+func (w *Watcher) OnFileAdded(callback func(string)) {
+	w.subscribe(fileAdded, callback)
+}
+
+// OnFileRemoved subscribes to files being deleted or moved out of a watched directory
+func (w *Watcher) OnFileRemoved(callback func(string)) {
+	w.subscribe(fileRemoved, callback)
+}
+
+// OnFileModified subscribes to files being written
+func (w *Watcher) OnFileModified(callback func(string)) {
+	w.subscribe(fileModified, callback)
+}
+
+// OnDirectoryAdded subscribes to directories being created or moved into a
+// watched directory. The files they contain are reported as added as well.
+func (w *Watcher) OnDirectoryAdded(callback func(string)) {
+	w.subscribe(directoryAdded, callback)
+}
+
+// OnDirectoryRemoved subscribes to directories being deleted or moved out of a
+// watched directory. The files they contained may not be reported as removed,
+// for example when the directory is moved away.
+func (w *Watcher) OnDirectoryRemoved(callback func(string)) {
+	w.subscribe(directoryRemoved, callback)
+}
+
+// OnFileMoved subscribes to files being renamed or moved from one watched
+// directory to another. Files moved in or out of the watched directories are
+// reported as added or removed instead.
+func (w *Watcher) OnFileMoved(callback func(from, to string)) {
+	w.subscribeMove(fileMoved, callback)
+}
+
+// OnDirectoryMoved subscribes to directories being renamed or moved from one
+// watched directory to another. The files they contain are not reported.
+func (w *Watcher) OnDirectoryMoved(callback func(from, to string)) {
+	w.subscribeMove(directoryMoved, callback)
+}
+
+func (w *Watcher) subscribe(event watchEvent, callback func(string)) {
+	w.callbackMu.Lock()
+	defer w.callbackMu.Unlock()
+	w.callbacks[event] = append(w.callbacks[event], callback)
+}
+
+func (w *Watcher) subscribeMove(event watchEvent, callback func(string, string)) {
+	w.callbackMu.Lock()
+	defer w.callbackMu.Unlock()
+	w.moveCallbacks[event] = append(w.moveCallbacks[event], callback)
+}
+
+// notify records a change reported by the backend and reports it once its
+// path has not changed for the debounce delay
+func (w *Watcher) notify(path string, event watchEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	from := ""
+	if previous := w.takePending(path); previous != nil {
+		switch {
+		case previous.from != "" && (event == fileRemoved || event == directoryRemoved):
+			// Moved and then removed: the library only knows the old path
+			path = previous.from
+		case previous.from != "":
+			// The move is still reported, and the new path is read again anyway
+			event, from = previous.event, previous.from
+		default:
+			if event = mergeEvents(previous.event, event); event == 0 {
+				return
+			}
+		}
+	}
+	w.schedule(path, &pendingChange{event: event, from: from})
+}
+
+// notifyMove records a file or directory moved from one watched path to
+// another. A change of the old path not reported yet moves with it, and
+// replaces any change of the new path.
+func (w *Watcher) notifyMove(from, to string, event watchEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.takePending(to)
+	if previous := w.takePending(from); previous != nil {
+		switch previous.event {
+		case fileAdded, directoryAdded:
+			// The old path was never reported, so neither is the move
+			w.schedule(to, &pendingChange{event: previous.event})
+			return
+		case fileMoved, directoryMoved:
+			from = previous.from
+		}
+	}
+	w.schedule(to, &pendingChange{event: event, from: from})
+}
+
+// takePending removes and returns the change of a path not reported yet
+func (w *Watcher) takePending(path string) *pendingChange {
+	change, ok := w.pending[path]
+	if !ok {
+		return nil
+	}
+	change.timer.Stop()
+	delete(w.pending, path)
+	return change
+}
+
+// schedule reports a change once the debounce delay has passed. A new change
+// is recorded every time, so that a timer that already fired for the previous
+// one does not report it early.
+func (w *Watcher) schedule(path string, change *pendingChange) {
+	change.timer = time.AfterFunc(w.debounce, func() { w.report(path, change) })
+	w.pending[path] = change
+}
+
+// report runs the callbacks of a settled change
+func (w *Watcher) report(path string, change *pendingChange) {
+	w.mu.Lock()
+	if w.pending[path] != change {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pending, path)
+	w.mu.Unlock()
+
+	w.callbackMu.Lock()
+	defer w.callbackMu.Unlock()
+	if change.from != "" {
+		for _, callback := range w.moveCallbacks[change.event] {
+			callback(change.from, path)
+		}
+		return
+	}
+	for _, callback := range w.callbacks[change.event] {
+		callback(path)
+	}
+}
+
+// mergeEvents returns the change resulting from two successive changes to the
+// same path, or 0 if they cancel out
+func mergeEvents(first, second watchEvent) watchEvent {
+	switch {
+	case first == fileAdded && second == fileModified:
+		return fileAdded
+	case first == fileAdded && second == fileRemoved,
+		first == directoryAdded && second == directoryRemoved:
+		return 0
+	case first == fileRemoved && second == fileAdded:
+		return fileModified
+	default:
+		return second
+	}
+}
@@ -0,0 +1,249 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask selects the inotify events the watcher needs
+const inotifyMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MODIFY | unix.IN_CLOSE_WRITE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE_SELF | unix.IN_ONLYDIR
+
+// inotifyBackend watches directories with inotify. Every subdirectory needs
+// its own watch, so watches are added as directories appear.
+type inotifyBackend struct {
+	fd         int
+	wake       [2]int
+	notify     func(string, watchEvent)
+	notifyMove func(string, string, watchEvent)
+	roots      map[string]bool
+	paths      map[int]string
+	done       chan struct{}
+}
+
+// movedPath is the source of a move waiting for its destination
+type movedPath struct {
+	path  string
+	isDir bool
+}
+
+func startWatchBackend(directories []string, notify func(string, watchEvent), notifyMove func(string, string, watchEvent)) (watchBackend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+
+	b := &inotifyBackend{
+		fd:         fd,
+		notify:     notify,
+		notifyMove: notifyMove,
+		roots:      make(map[string]bool),
+		paths:      make(map[int]string),
+		done:       make(chan struct{}),
+	}
+	if err := unix.Pipe2(b.wake[:], unix.O_CLOEXEC|unix.O_NONBLOCK); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to create wake pipe: %w", err)
+	}
+
+	for _, directory := range directories {
+		directory = filepath.Clean(directory)
+		b.roots[directory] = true
+		if err := b.addTree(directory, false); err != nil {
+			b.close()
+			return nil, err
+		}
+	}
+
+	go b.run()
+	return b, nil
+}
+
+func (b *inotifyBackend) stop() error {
+	unix.Write(b.wake[1], []byte{0})
+	<-b.done
+	return b.close()
+}
+
+func (b *inotifyBackend) close() error {
+	unix.Close(b.wake[0])
+	unix.Close(b.wake[1])
+	if err := unix.Close(b.fd); err != nil {
+		return fmt.Errorf("failed to close inotify: %w", err)
+	}
+	return nil
+}
+
+// addTree watches a directory and its subdirectories. When the directory is
+// new, the files already in it are reported as added, since they may have been
+// created before the watch was.
+func (b *inotifyBackend) addTree(root string, isNew bool) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories that disappear or cannot be read are skipped
+			if path == root && !isNew {
+				return fmt.Errorf("failed to watch %s: %w", root, err)
+			}
+			return nil
+		}
+
+		if !entry.IsDir() {
+			if isNew {
+				b.notify(path, fileAdded)
+			}
+			return nil
+		}
+
+		wd, err := unix.InotifyAddWatch(b.fd, path, inotifyMask)
+		if err != nil {
+			if path == root && !isNew {
+				return fmt.Errorf("failed to watch %s: %w", root, err)
+			}
+			fmt.Printf("Failed to watch %s: %v\n", path, err)
+			return filepath.SkipDir
+		}
+		b.paths[wd] = path
+		return nil
+	})
+}
+
+// removeTree stops watching a directory and its subdirectories
+func (b *inotifyBackend) removeTree(root string) {
+	for wd, path := range b.paths {
+		if path == root || strings.HasPrefix(path, root+string(os.PathSeparator)) {
+			unix.InotifyRmWatch(b.fd, uint32(wd))
+			delete(b.paths, wd)
+		}
+	}
+}
+
+// renameTree follows a watched directory and its subdirectories to a new path
+func (b *inotifyBackend) renameTree(from, to string) {
+	for wd, path := range b.paths {
+		if path == from || strings.HasPrefix(path, from+string(os.PathSeparator)) {
+			b.paths[wd] = to + path[len(from):]
+		}
+	}
+}
+
+func (b *inotifyBackend) run() {
+	defer close(b.done)
+
+	buf := make([]byte, 64*1024)
+	fds := []unix.PollFd{
+		{Fd: int32(b.fd), Events: unix.POLLIN},
+		{Fd: int32(b.wake[0]), Events: unix.POLLIN},
+	}
+	for {
+		if _, err := unix.Poll(fds, -1); err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			fmt.Printf("Failed to wait for file system events: %v\n", err)
+			return
+		}
+		if fds[1].Revents != 0 {
+			return
+		}
+
+		n, err := unix.Read(b.fd, buf)
+		if err != nil {
+			if err == unix.EAGAIN || err == unix.EINTR {
+				continue
+			}
+			fmt.Printf("Failed to read file system events: %v\n", err)
+			return
+		}
+		b.handle(buf[:n])
+	}
+}
+
+// handle translates a buffer of inotify events into watcher changes. The two
+// halves of a move share a cookie and arrive one after the other, so a move
+// within the watched directories is paired up in the same buffer. A source
+// left without a destination was moved out and is reported as removed.
+func (b *inotifyBackend) handle(buf []byte) {
+	moves := make(map[uint32]movedPath)
+	defer func() {
+		for _, moved := range moves {
+			if moved.isDir {
+				b.removeTree(moved.path)
+				b.notify(moved.path, directoryRemoved)
+			} else {
+				b.notify(moved.path, fileRemoved)
+			}
+		}
+	}()
+
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		offset = nameStart + int(event.Len)
+
+		if event.Mask&unix.IN_Q_OVERFLOW != 0 {
+			fmt.Printf("File system events were lost, the library may need a rescan\n")
+			continue
+		}
+
+		dir, ok := b.paths[int(event.Wd)]
+		if !ok {
+			continue
+		}
+		if event.Mask&unix.IN_IGNORED != 0 {
+			delete(b.paths, int(event.Wd))
+			continue
+		}
+		if event.Mask&unix.IN_DELETE_SELF != 0 {
+			// Removed subdirectories are reported by their parent
+			if b.roots[dir] {
+				b.notify(dir, directoryRemoved)
+			}
+			continue
+		}
+
+		name := strings.TrimRight(string(buf[nameStart:offset]), "\x00")
+		path := filepath.Join(dir, name)
+		isDir := event.Mask&unix.IN_ISDIR != 0
+
+		if event.Mask&unix.IN_MOVED_FROM != 0 {
+			moves[event.Cookie] = movedPath{path: path, isDir: isDir}
+			continue
+		}
+		if moved, ok := moves[event.Cookie]; ok && event.Mask&unix.IN_MOVED_TO != 0 {
+			delete(moves, event.Cookie)
+			if moved.isDir {
+				b.renameTree(moved.path, path)
+				b.notifyMove(moved.path, path, directoryMoved)
+			} else {
+				b.notifyMove(moved.path, path, fileMoved)
+			}
+			continue
+		}
+
+		switch {
+		case isDir && event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+			if err := b.addTree(path, true); err != nil {
+				fmt.Printf("Failed to watch %s: %v\n", path, err)
+			}
+			b.notify(path, directoryAdded)
+		case isDir && event.Mask&unix.IN_DELETE != 0:
+			b.removeTree(path)
+			b.notify(path, directoryRemoved)
+		case isDir:
+		case event.Mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+			b.notify(path, fileAdded)
+		case event.Mask&unix.IN_DELETE != 0:
+			b.notify(path, fileRemoved)
+		case event.Mask&(unix.IN_MODIFY|unix.IN_CLOSE_WRITE) != 0:
+			b.notify(path, fileModified)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// watcherChange is a change reported to the test callbacks
+type watcherChange struct {
+	event watchEvent
+	path  string
+}
+
+// movedChange is the change reported for a move, with both paths
+func movedChange(event watchEvent, from, to string) watcherChange {
+	return watcherChange{event, from + " -> " + to}
+}
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	watcher := NewWatcher()
+	watcher.SetDebounce(50 * time.Millisecond)
+
+	changes := make(chan watcherChange, 100)
+	watcher.OnFileAdded(func(path string) { changes <- watcherChange{fileAdded, path} })
+	watcher.OnFileRemoved(func(path string) { changes <- watcherChange{fileRemoved, path} })
+	watcher.OnFileModified(func(path string) { changes <- watcherChange{fileModified, path} })
+	watcher.OnDirectoryAdded(func(path string) { changes <- watcherChange{directoryAdded, path} })
+	watcher.OnDirectoryRemoved(func(path string) { changes <- watcherChange{directoryRemoved, path} })
+	watcher.OnFileMoved(func(from, to string) { changes <- movedChange(fileMoved, from, to) })
+	watcher.OnDirectoryMoved(func(from, to string) { changes <- movedChange(directoryMoved, from, to) })
+
+	if err := watcher.Watch([]string{filepath.Join(root, "missing")}); err == nil {
+		t.Error("Watch() of a missing directory succeeded, expected an error")
+	}
+	if err := watcher.Watch([]string{root}); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer watcher.Stop()
+
+	expect := func(step string, expected ...watcherChange) {
+		t.Helper()
+		remaining := map[watcherChange]bool{}
+		for _, change := range expected {
+			remaining[change] = true
+		}
+		for len(remaining) > 0 {
+			select {
+			case change := <-changes:
+				if !remaining[change] {
+					t.Fatalf("%s: unexpected change %+v", step, change)
+				}
+				delete(remaining, change)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("%s: missing changes %v", step, remaining)
+			}
+		}
+
+		// Nothing else is reported
+		select {
+		case change := <-changes:
+			t.Fatalf("%s: unexpected change %+v", step, change)
+		case <-time.After(150 * time.Millisecond):
+		}
+	}
+
+	// A file written in several parts is reported once
+	song := filepath.Join(root, "song.mp3")
+	file, err := os.Create(song)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		file.Write(make([]byte, 1024))
+		time.Sleep(10 * time.Millisecond)
+	}
+	file.Close()
+	expect("create", watcherChange{fileAdded, song})
+
+	os.WriteFile(song, []byte("changed"), 0644)
+	expect("write", watcherChange{fileModified, song})
+
+	os.Remove(song)
+	expect("remove", watcherChange{fileRemoved, song})
+
+	// A file created and removed within the debounce delay is not reported
+	os.WriteFile(song, nil, 0644)
+	os.Remove(song)
+	expect("create and remove")
+
+	// Files of a directory moved into the library are reported, and the
+	// directory is watched
+	outside := filepath.Join(t.TempDir(), "album")
+	os.MkdirAll(filepath.Join(outside, "disc 1"), 0755)
+	os.WriteFile(filepath.Join(outside, "disc 1", "track.flac"), nil, 0644)
+	album := filepath.Join(root, "album")
+	if err := os.Rename(outside, album); err != nil {
+		t.Fatal(err)
+	}
+	track := filepath.Join(album, "disc 1", "track.flac")
+	expect("move in", watcherChange{directoryAdded, album}, watcherChange{fileAdded, track})
+
+	lyrics := filepath.Join(album, "disc 1", "track.lrc")
+	os.WriteFile(lyrics, []byte("[00:01.00]Hello"), 0644)
+	expect("nested create", watcherChange{fileAdded, lyrics})
+
+	renamed := filepath.Join(album, "disc 1", "renamed.flac")
+	os.Rename(track, renamed)
+	expect("rename", movedChange(fileMoved, track, renamed))
+
+	// A file renamed before it settles is reported as added under its new name
+	draft := filepath.Join(album, "disc 1", "draft.lrc")
+	os.WriteFile(draft, nil, 0644)
+	os.Rename(draft, lyrics+".tmp")
+	expect("create and rename", watcherChange{fileAdded, lyrics + ".tmp"})
+
+	// The subdirectories of a renamed directory are still watched under their new path
+	moved := filepath.Join(root, "moved")
+	os.Rename(album, moved)
+	expect("rename directory", movedChange(directoryMoved, album, moved))
+
+	cover := filepath.Join(moved, "disc 1", "cover.jpg")
+	os.WriteFile(cover, nil, 0644)
+	expect("create in renamed directory", watcherChange{fileAdded, cover})
+
+	os.Rename(cover, filepath.Join(t.TempDir(), "cover.jpg"))
+	expect("move out", watcherChange{fileRemoved, cover})
+
+	os.RemoveAll(moved)
+	expect("remove directory",
+		watcherChange{directoryRemoved, moved},
+		watcherChange{directoryRemoved, filepath.Join(moved, "disc 1")},
+		watcherChange{fileRemoved, filepath.Join(moved, "disc 1", "renamed.flac")},
+		watcherChange{fileRemoved, filepath.Join(moved, "disc 1", "track.lrc")},
+		watcherChange{fileRemoved, filepath.Join(moved, "disc 1", "track.lrc.tmp")},
+	)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package filesystem
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func startWatchBackend(directories []string, notify func(string, watchEvent), notifyMove func(string, string, watchEvent)) (watchBackend, error) {
+	return nil, fmt.Errorf("watching directories is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,27 @@
+package filesystem
+
+import "testing"
+
+func TestMergeEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		first    watchEvent
+		second   watchEvent
+		expected watchEvent
+	}{
+		{"written after creation", fileAdded, fileModified, fileAdded},
+		{"removed after creation", fileAdded, fileRemoved, 0},
+		{"replaced", fileRemoved, fileAdded, fileModified},
+		{"removed after writing", fileModified, fileRemoved, fileRemoved},
+		{"written twice", fileModified, fileModified, fileModified},
+		{"directory removed after creation", directoryAdded, directoryRemoved, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeEvents(tt.first, tt.second); got != tt.expected {
+				t.Errorf("mergeEvents(%v, %v) = %v, expected %v", tt.first, tt.second, got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,10 +1,20 @@
 package interfaces
 
 import (
+	"time"
+
 	"lrcget-go/internal/audio"
 	"lrcget-go/internal/database"
 )
 
+// Compile-time checks that the audio package implements these interfaces
+var (
+	_ AudioPlayerInterface = (*audio.Player)(nil)
+	_ AudioStreamInterface = (*audio.Stream)(nil)
+	_ AudioData            = (*audio.PCM)(nil)
+	_ AudioMetadata        = (*audio.Metadata)(nil)
+)
+
 // AudioPlayerInterface defines the interface for audio player operations
 type AudioPlayerInterface interface {
 	// Playback control
@@ -14,12 +24,16 @@ type AudioPlayerInterface interface {
 	Stop() error
 	Seek(position float64) error
 	SetVolume(volume float64) error
+	SetRate(rate float64) error
+	SetLoop(start, end float64) error
+	ClearLoop() error
 	
 	// State management
 	GetState() audio.PlayerState
 	GetPosition() float64
 	GetDuration() float64
 	GetVolume() float64
+	GetRate() float64
 	IsPlaying() bool
 	IsPaused() bool
 	IsStopped() bool
@@ -28,6 +42,8 @@ type AudioPlayerInterface interface {
 	OnStateChange(callback func(audio.PlayerState))
 	OnPositionChange(callback func(float64))
 	OnVolumeChange(callback func(float64))
+	OnTrackFinished(callback func(*database.PersistentTrack))
+	SetPositionInterval(interval time.Duration)
 	
 	// Cleanup
 	Close() error
@@ -68,6 +84,9 @@ type AudioStreamInterface interface {
 	Resume() error
 	Seek(position float64) error
 	SetVolume(volume float64) error
+	SetRate(rate float64) error
+	SetLoop(start, end float64) error
+	ClearLoop() error
 	GetState() audio.PlayerState
 	GetPosition() float64
 	GetDuration() float64
@@ -2,6 +2,8 @@ package interfaces
 
 import (
 	"context"
+	"time"
+
 	"lrcget-go/internal/database"
 )
 
@@ -37,6 +39,16 @@ type DatabaseInterface interface {
 	UpdateArtist(artist *database.PersistentArtist) error
 	DeleteArtist(id int64) error
 	
+	// Lyrics source operations
+	GetLyricsSource(trackID int64) (*database.PersistentLyricsSource, error)
+	SetLyricsSource(source *database.PersistentLyricsSource) error
+	GetLyricsSourcesDownloadedBefore(cutoff time.Time) ([]database.PersistentLyricsSource, error)
+	
+	// Lyrics history operations
+	SaveTrackLyrics(trackID int64, lrcLyrics, txtLyrics *string, instrumental bool, source string) error
+	GetLyricsHistory(trackID int64) ([]database.PersistentLyricsHistory, error)
+	GetLyricsHistoryByID(id int64) (*database.PersistentLyricsHistory, error)
+	
 	// Configuration operations
 	GetConfig() (*database.PersistentConfig, error)
 	UpdateConfig(config *database.PersistentConfig) error
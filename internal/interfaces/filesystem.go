@@ -1,31 +1,35 @@
 package interfaces
 
 import (
+	"context"
+
 	"lrcget-go/internal/database"
+	"lrcget-go/internal/filesystem"
+)
+
+// Compile-time checks that the filesystem package implements these interfaces
+var (
+	_ FileSystemInterface  = (*filesystem.Scanner)(nil)
+	_ FileWatcherInterface = (*filesystem.Watcher)(nil)
 )
 
 // FileSystemInterface defines the interface for file system operations
 type FileSystemInterface interface {
 	// Directory scanning
 	ScanDirectories(directories []string) ([]database.PersistentTrack, error)
-	ScanDirectory(directory string) ([]database.PersistentTrack, error)
 	CountFiles(directories []string) (int, error)
 	
 	// File operations
 	IsAudioFile(path string) bool
 	ExtractMetadata(filePath string) (*database.PersistentTrack, error)
-	GetTxtLyrics(filePath string) *string
-	GetLrcLyrics(filePath string) *string
+	ExtractTracks(filePath string) ([]database.PersistentTrack, error)
+	GetTxtLyrics(track *database.PersistentTrack) *string
+	GetLrcLyrics(track *database.PersistentTrack) *string
+	SaveLyrics(track *database.PersistentTrack, lrcLyrics, txtLyrics *string, instrumental bool) error
 	
 	// Streaming operations
-	ScanDirectoriesStreaming(directories []string) ([]database.PersistentTrack, error)
-	ExtractMetadataStreaming(filePath string) (*database.PersistentTrack, error)
-	GetTxtLyricsStreaming(filePath string) *string
-	GetLrcLyricsStreaming(filePath string) *string
-	
-	// Utility operations
-	GetTxtPath(filePath string) string
-	GetLrcPath(filePath string) string
+	Scan(ctx context.Context, directories []string, workers int, write func([]database.PersistentTrack) error) (*filesystem.ScanReport, error)
+	ScanIter(ctx context.Context, directories []string, workers int, yield func(database.PersistentTrack) error) (*filesystem.ScanReport, error)
 }
 
 // FileWatcherInterface defines the interface for file system watching
@@ -37,6 +41,8 @@ type FileWatcherInterface interface {
 	OnFileModified(callback func(string))
 	OnDirectoryAdded(callback func(string))
 	OnDirectoryRemoved(callback func(string))
+	OnFileMoved(callback func(from, to string))
+	OnDirectoryMoved(callback func(from, to string))
 }
 
 // FileCacheInterface defines the interface for file caching
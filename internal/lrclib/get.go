@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+
+	"lrcget-go/internal/lyrics"
 )
 
 // GetLyrics retrieves lyrics for a track
@@ -82,32 +84,40 @@ func (c *Client) GetLyricsByID(ctx context.Context, trackID int64) (Response, er
 
 // convertResponse converts a raw response to the appropriate response type
 func (c *Client) convertResponse(raw RawResponse) Response {
+	metadata := Metadata{
+		ID:          raw.ID,
+		Name:        raw.Name,
+		Lang:        raw.Lang,
+		Isrc:        raw.Isrc,
+		SpotifyID:   raw.SpotifyID,
+		ReleaseDate: raw.ReleaseDate,
+	}
+	
 	if raw.SyncedLyrics != nil {
 		plain := raw.PlainLyrics
 		if plain == nil {
 			plain = c.stripTimestamp(*raw.SyncedLyrics)
 		}
 		return SyncedLyrics{
-			Synced: *raw.SyncedLyrics,
-			Plain:  *plain,
+			Synced:   *raw.SyncedLyrics,
+			Plain:    *plain,
+			Metadata: metadata,
 		}
 	}
 	
 	if raw.PlainLyrics != nil {
-		return UnsyncedLyrics{Plain: *raw.PlainLyrics}
+		return UnsyncedLyrics{Plain: *raw.PlainLyrics, Metadata: metadata}
 	}
 	
 	if raw.Instrumental {
-		return Instrumental{}
+		return Instrumental{Metadata: metadata}
 	}
 	
 	return None{}
 }
 
-// stripTimestamp strips timestamps from synced lyrics to create plain lyrics
+// stripTimestamp strips line and word timestamps from synced lyrics to create plain lyrics
 func (c *Client) stripTimestamp(syncedLyrics string) *string {
-	// This is a simplified implementation
-	// In the real version, you'd use regex to remove [mm:ss.xx] patterns
-	// For now, just return the synced lyrics as plain
-	return &syncedLyrics
+	plain := lyrics.PlainText(syncedLyrics)
+	return &plain
 }
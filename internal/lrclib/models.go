@@ -4,6 +4,7 @@ import "fmt"
 
 // RawResponse represents the raw response from LRCLIB API
 type RawResponse struct {
+	ID           int64    `json:"id"`
 	PlainLyrics  *string  `json:"plainLyrics"`
 	SyncedLyrics *string  `json:"syncedLyrics"`
 	Instrumental bool     `json:"instrumental"`
@@ -22,23 +23,37 @@ type Response interface {
 	Type() string
 }
 
+// Metadata represents the LRCLIB record a response was matched to
+type Metadata struct {
+	ID          int64   `json:"id"`
+	Name        *string `json:"name"`
+	Lang        *string `json:"lang"`
+	Isrc        *string `json:"isrc"`
+	SpotifyID   *string `json:"spotifyId"`
+	ReleaseDate *string `json:"releaseDate"`
+}
+
 // SyncedLyrics represents synced lyrics response
 type SyncedLyrics struct {
-	Synced string `json:"synced"`
-	Plain  string `json:"plain"`
+	Synced   string   `json:"synced"`
+	Plain    string   `json:"plain"`
+	Metadata Metadata `json:"metadata"`
 }
 
 func (s SyncedLyrics) Type() string { return "synced" }
 
 // UnsyncedLyrics represents unsynced lyrics response
 type UnsyncedLyrics struct {
-	Plain string `json:"plain"`
+	Plain    string   `json:"plain"`
+	Metadata Metadata `json:"metadata"`
 }
 
 func (u UnsyncedLyrics) Type() string { return "unsynced" }
 
 // Instrumental represents instrumental response
-type Instrumental struct{}
+type Instrumental struct {
+	Metadata Metadata `json:"metadata"`
+}
 
 func (i Instrumental) Type() string { return "instrumental" }
 
@@ -10,7 +10,9 @@ import (
 // SearchLyrics searches for lyrics using the LRCLIB API
 func (c *Client) SearchLyrics(ctx context.Context, title, artist, album, query string) (*SearchResponse, error) {
 	params := url.Values{}
-	params.Set("q", query)
+	if query != "" {
+		params.Set("q", query)
+	}
 	if title != "" {
 		params.Set("track_name", title)
 	}
@@ -46,4 +48,18 @@ func (c *Client) SearchLyrics(ctx context.Context, title, artist, album, query s
 	}
 	
 	return &searchResp, nil
-}
\ No newline at end of file
+}
+
+// ConvertSearchResult converts a search result to the appropriate response type
+func (c *Client) ConvertSearchResult(result SearchResult) Response {
+	return c.convertResponse(RawResponse{
+		ID:           result.ID,
+		PlainLyrics:  result.PlainLyrics,
+		SyncedLyrics: result.SyncedLyrics,
+		Instrumental: result.Instrumental,
+		Name:         &result.TrackName,
+		AlbumName:    &result.AlbumName,
+		ArtistName:   &result.ArtistName,
+		Duration:     &result.Duration,
+	})
+}
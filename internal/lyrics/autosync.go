@@ -0,0 +1,169 @@
+package lyrics
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// onsetSnapDistance is how far in seconds a line may be moved to start on a detected onset
+	onsetSnapDistance = 0.5
+
+	// minBreakGap is the shortest silence in seconds that gets an empty line in auto-synced lyrics
+	minBreakGap = 3.0
+)
+
+// Segment represents a stretch of audio where vocals are likely, with the
+// times of the note onsets detected in it
+type Segment struct {
+	Start  float64   `json:"start"`
+	End    float64   `json:"end"`
+	Onsets []float64 `json:"onsets,omitempty"`
+}
+
+// AutoSync distributes plain lyrics lines over vocal segments and returns
+// draft LRC synced lyrics. Each line gets a share of the total vocal time
+// proportional to its syllable count, and its start is moved to a nearby
+// segment start or onset. An empty line is inserted where vocals stop before a
+// long silence, and after the last line.
+func AutoSync(plain string, segments []Segment) (string, error) {
+	var texts []string
+	for _, line := range splitLines(plain) {
+		if line = strings.TrimSpace(line); line != "" {
+			texts = append(texts, line)
+		}
+	}
+	if len(texts) == 0 {
+		return "", fmt.Errorf("no lyrics lines to sync")
+	}
+
+	var vocalTime float64
+	for _, segment := range segments {
+		vocalTime += segment.End - segment.Start
+	}
+	if vocalTime <= 0 {
+		return "", fmt.Errorf("no vocals detected")
+	}
+
+	totalSyllables := 0
+	syllables := make([]int, len(texts))
+	for i, text := range texts {
+		syllables[i] = Syllables(text)
+		totalSyllables += syllables[i]
+	}
+
+	times := make([]float64, len(texts))
+	done := 0
+	for i := range texts {
+		t, j := vocalTimeAt(segments, vocalTime*float64(done)/float64(totalSyllables))
+
+		// A line that would barely start before a silence starts after it instead
+		share := vocalTime * float64(syllables[i]) / float64(totalSyllables)
+		if j+1 < len(segments) && segments[j].End-t < share/2 {
+			t = segments[j+1].Start
+		}
+
+		t = snapToOnset(segments, t)
+		if i > 0 && t <= times[i-1] {
+			t = times[i-1] + 0.01
+		}
+		times[i] = t
+		done += syllables[i]
+	}
+
+	var lines []string
+	for i, text := range texts {
+		lines = append(lines, "["+FormatTimestamp(times[i])+"]"+text)
+
+		// A line ends at the first long silence before the next line, or at
+		// the end of its segment for the last line
+		for j, segment := range segments {
+			if segment.End <= times[i] {
+				continue
+			}
+			if i+1 == len(texts) {
+				lines = append(lines, "["+FormatTimestamp(segment.End)+"]")
+				break
+			}
+			if j+1 == len(segments) || segments[j+1].Start > times[i+1] {
+				break
+			}
+			if segments[j+1].Start-segment.End >= minBreakGap {
+				lines = append(lines, "["+FormatTimestamp(segment.End)+"]")
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// Syllables estimates the number of syllables of a lyrics line. Latin and
+// Cyrillic words count their vowel groups, while every CJK character counts as
+// one syllable. Every line counts at least one.
+func Syllables(text string) int {
+	count := 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		count += wordSyllables(strings.ToLower(word))
+	}
+	return max(count, 1)
+}
+
+// wordSyllables estimates the number of syllables of a lowercase word
+func wordSyllables(word string) int {
+	count := 0
+	inVowel := false
+	runes := []rune(word)
+	for _, r := range runes {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			count++
+			inVowel = false
+			continue
+		}
+
+		vowel := strings.ContainsRune("aeiouyàáâãäåèéêëìíîïòóôõöùúûüýæœаеёиоуыэюяіїє", r)
+		if vowel && !inVowel {
+			count++
+		}
+		inVowel = vowel
+	}
+
+	// A final silent e, as in "time", does not make a syllable
+	if n := len(runes); count > 1 && n > 2 && runes[n-1] == 'e' &&
+		!strings.ContainsRune("aeiouyl", runes[n-2]) {
+		count--
+	}
+	return max(count, 1)
+}
+
+// vocalTimeAt converts an offset into the concatenated vocal segments to a
+// track time, and returns the index of the segment it is in
+func vocalTimeAt(segments []Segment, offset float64) (float64, int) {
+	for i, segment := range segments {
+		length := segment.End - segment.Start
+		if offset < length {
+			return segment.Start + offset, i
+		}
+		offset -= length
+	}
+	return segments[len(segments)-1].End, len(segments) - 1
+}
+
+// snapToOnset moves a time to the nearest segment start or onset within onsetSnapDistance
+func snapToOnset(segments []Segment, t float64) float64 {
+	best := t
+	bestDistance := onsetSnapDistance
+	for _, segment := range segments {
+		for _, onset := range append([]float64{segment.Start}, segment.Onsets...) {
+			// Segment starts win over onsets at the same distance
+			if distance := math.Abs(onset - t); distance < bestDistance {
+				best, bestDistance = onset, distance
+			}
+		}
+	}
+	return best
+}
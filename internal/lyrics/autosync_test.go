@@ -0,0 +1,76 @@
+package lyrics
+
+import "testing"
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"Hello world", 3},
+		{"It's time to go", 4},
+		{"Beautiful little day", 6},
+		{"夜に駆ける", 5},
+		{"!!!", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Syllables(tt.text); got != tt.expected {
+				t.Errorf("Syllables(%q) = %d, expected %d", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAutoSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		plain    string
+		segments []Segment
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:  "one line per segment",
+			plain: "Hello world\n\nSing it again\nOne more time",
+			segments: []Segment{
+				{Start: 1, End: 4},
+				{Start: 5, End: 8},
+				{Start: 12, End: 15},
+			},
+			expected: "[00:01.00]Hello world\n[00:05.00]Sing it again\n[00:08.00]\n[00:12.00]One more time\n[00:15.00]",
+		},
+		{
+			name:  "weighted by syllables",
+			plain: "Go\nThis line is longer\nNow",
+			segments: []Segment{
+				{Start: 2, End: 9, Onsets: []float64{3.2, 8.1}},
+			},
+			expected: "[00:02.00]Go\n[00:03.20]This line is longer\n[00:08.10]Now\n[00:09.00]",
+		},
+		{
+			name:    "no vocals",
+			plain:   "Hello",
+			wantErr: true,
+		},
+		{
+			name:     "no lyrics",
+			plain:    "\n",
+			segments: []Segment{{Start: 1, End: 2}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lrc, err := AutoSync(tt.plain, tt.segments)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AutoSync() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if lrc != tt.expected {
+				t.Errorf("AutoSync() = %q, expected %q", lrc, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package lyrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// timeTagRegex matches a line timestamp such as [01:23.45], [01:23.456] or [01:23]
+	timeTagRegex = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+
+	// wordTagRegex matches an enhanced LRC word timestamp such as <01:23.45>
+	wordTagRegex = regexp.MustCompile(`<(\d+):(\d{1,2})(?:[.:](\d{1,3}))?>`)
+
+	// idTagRegex matches a metadata tag such as [ar: Artist] or [offset:+200]
+	idTagRegex = regexp.MustCompile(`^\[([A-Za-z#]+):(.*)\]$`)
+)
+
+// Word represents a word of enhanced LRC lyrics with its own timestamp
+type Word struct {
+	Time float64 `json:"time"`
+	Text string  `json:"text"`
+}
+
+// Line represents a single timed line of synced lyrics. Words is only set for
+// enhanced LRC lines with word timestamps; Text never contains them.
+type Line struct {
+	Time  float64 `json:"time"`
+	Text  string  `json:"text"`
+	Words []Word  `json:"words,omitempty"`
+}
+
+// Lyrics represents parsed synced lyrics
+type Lyrics struct {
+	Tags  map[string]string `json:"tags"`
+	Lines []Line            `json:"lines"`
+}
+
+// Parse parses LRC synced lyrics, including enhanced LRC word timestamps. Lines
+// with several timestamps are expanded into one line per timestamp, the
+// [offset:] tag is applied, and lines are sorted by time. Lines without a
+// timestamp are ignored.
+func Parse(lrc string) *Lyrics {
+	lyrics := &Lyrics{Tags: make(map[string]string)}
+
+	for _, raw := range splitLines(lrc) {
+		times, text, ok := parseTimedLine(raw)
+		if ok {
+			for _, t := range times {
+				plain, words := parseWords(text, t)
+				lyrics.Lines = append(lyrics.Lines, Line{Time: t, Text: plain, Words: words})
+			}
+			continue
+		}
+
+		if key, value, ok := parseIDTag(raw); ok {
+			lyrics.Tags[key] = value
+		}
+	}
+
+	if offset, err := strconv.Atoi(lyrics.Tags["offset"]); err == nil && offset != 0 {
+		// A positive offset makes lyrics appear sooner
+		for i := range lyrics.Lines {
+			line := &lyrics.Lines[i]
+			line.Time = math.Max(line.Time-float64(offset)/1000, 0)
+			for j := range line.Words {
+				line.Words[j].Time = math.Max(line.Words[j].Time-float64(offset)/1000, 0)
+			}
+		}
+	}
+
+	sort.SliceStable(lyrics.Lines, func(i, j int) bool {
+		return lyrics.Lines[i].Time < lyrics.Lines[j].Time
+	})
+
+	return lyrics
+}
+
+// Position returns the index of the line and of the word within that line being
+// sung at the given time in seconds, or -1 before the first line or word.
+func (l *Lyrics) Position(t float64) (int, int) {
+	line := sort.Search(len(l.Lines), func(i int) bool {
+		return l.Lines[i].Time > t
+	}) - 1
+	if line < 0 {
+		return -1, -1
+	}
+
+	words := l.Lines[line].Words
+	word := sort.Search(len(words), func(i int) bool {
+		return words[i].Time > t
+	}) - 1
+
+	return line, word
+}
+
+// Shift moves every line and word timestamp in LRC lyrics by offsetMs
+// milliseconds; a positive offset makes lyrics appear later. Timestamps are
+// rewritten in place with their original precision, everything else is left
+// untouched, and timestamps that would become negative are clamped to zero.
+func Shift(lrc string, offsetMs int) string {
+	if offsetMs == 0 {
+		return lrc
+	}
+
+	lines := strings.Split(lrc, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := line[:len(line)-len(trimmed)]
+
+		var shifted strings.Builder
+		for {
+			match := timeTagRegex.FindStringSubmatch(trimmed)
+			if match == nil {
+				break
+			}
+			t := parseTimestamp(match[1], match[2], match[3]) + float64(offsetMs)/1000
+			shifted.WriteString("[" + formatTimestamp(t, len(match[3])) + "]")
+			trimmed = trimmed[len(match[0]):]
+		}
+
+		if shifted.Len() > 0 {
+			trimmed = wordTagRegex.ReplaceAllStringFunc(trimmed, func(tag string) string {
+				match := wordTagRegex.FindStringSubmatch(tag)
+				t := parseTimestamp(match[1], match[2], match[3]) + float64(offsetMs)/1000
+				return "<" + formatTimestamp(t, len(match[3])) + ">"
+			})
+			lines[i] = indent + shifted.String() + trimmed
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// PlainText removes line timestamps, word timestamps and metadata tags from
+// synced lyrics, keeping one line of text per lyrics line
+func PlainText(lrc string) string {
+	var lines []string
+	for _, raw := range splitLines(lrc) {
+		if _, text, ok := parseTimedLine(raw); ok {
+			lines = append(lines, stripWordTags(text))
+			continue
+		}
+		if _, _, ok := parseIDTag(raw); ok {
+			continue
+		}
+		lines = append(lines, raw)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// IsSynced reports whether the lyrics contain at least one timed line
+func IsSynced(lrc string) bool {
+	for _, raw := range splitLines(lrc) {
+		if _, _, ok := parseTimedLine(raw); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines splits lyrics into trimmed lines, handling any line ending
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return lines
+}
+
+// parseTimedLine parses the leading timestamps of a line and returns them in
+// seconds together with the remaining text
+func parseTimedLine(line string) ([]float64, string, bool) {
+	var times []float64
+	for {
+		match := timeTagRegex.FindStringSubmatch(line)
+		if match == nil {
+			break
+		}
+		times = append(times, parseTimestamp(match[1], match[2], match[3]))
+		line = line[len(match[0]):]
+	}
+
+	if len(times) == 0 {
+		return nil, "", false
+	}
+	return times, strings.TrimSpace(line), true
+}
+
+// parseWords splits the text of an enhanced LRC line into words using its word
+// timestamps. Text before the first word timestamp starts at the line time, and
+// a trailing timestamp only marks the end of the last word. Lines without word
+// timestamps return no words.
+func parseWords(text string, lineTime float64) (string, []Word) {
+	matches := wordTagRegex.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	var words []Word
+	add := func(t float64, segment string) {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			words = append(words, Word{Time: t, Text: segment})
+		}
+	}
+
+	add(lineTime, text[:matches[0][0]])
+	for i, m := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		t := parseTimestamp(submatch(text, m, 1), submatch(text, m, 2), submatch(text, m, 3))
+		add(t, text[m[1]:end])
+	}
+
+	return stripWordTags(text), words
+}
+
+// stripWordTags removes enhanced LRC word timestamps from text
+func stripWordTags(text string) string {
+	return strings.Join(strings.Fields(wordTagRegex.ReplaceAllString(text, "")), " ")
+}
+
+// submatch returns the nth submatch of a match found by FindAllStringSubmatchIndex
+func submatch(text string, match []int, n int) string {
+	if match[2*n] < 0 {
+		return ""
+	}
+	return text[match[2*n]:match[2*n+1]]
+}
+
+// parseTimestamp converts the minute, second and fraction parts of a timestamp to seconds
+func parseTimestamp(minutes, seconds, fraction string) float64 {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	t := float64(m*60 + s)
+
+	if fraction != "" {
+		f, _ := strconv.Atoi(fraction)
+		switch len(fraction) {
+		case 1:
+			t += float64(f) / 10
+		case 2:
+			t += float64(f) / 100
+		default:
+			t += float64(f) / 1000
+		}
+	}
+	return t
+}
+
+// FormatTimestamp formats seconds as an LRC timestamp without brackets (mm:ss.xx)
+func FormatTimestamp(seconds float64) string {
+	return formatTimestamp(seconds, 2)
+}
+
+// formatTimestamp formats seconds as a timestamp without brackets, using the
+// given number of fraction digits (0 to 3). The time is rounded to that
+// precision before it is split, so rounding carries into seconds and minutes.
+// Negative times are clamped to zero.
+func formatTimestamp(seconds float64, digits int) string {
+	digits = min(max(digits, 0), 3)
+	scale := int64(math.Pow10(digits))
+	units := int64(math.Round(max(seconds, 0) * float64(scale)))
+	whole, fraction := units/scale, units%scale
+
+	timestamp := fmt.Sprintf("%02d:%02d", whole/60, whole%60)
+	if digits > 0 {
+		timestamp += fmt.Sprintf(".%0*d", digits, fraction)
+	}
+	return timestamp
+}
+
+// parseIDTag parses a metadata tag line
+func parseIDTag(line string) (string, string, bool) {
+	match := idTagRegex.FindStringSubmatch(line)
+	if match == nil {
+		return "", "", false
+	}
+	return strings.ToLower(match[1]), strings.TrimSpace(match[2]), true
+}
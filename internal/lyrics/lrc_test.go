@@ -0,0 +1,150 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	lrc := "[ar: Artist]\n[offset:+500]\n[00:12.00]First <00:12.50>line<00:13.00>\n[00:10.50][00:20.25]Repeated line\n\nNot a lyric"
+
+	parsed := Parse(lrc)
+
+	if parsed.Tags["ar"] != "Artist" {
+		t.Errorf("Parse() ar tag = %v, expected %v", parsed.Tags["ar"], "Artist")
+	}
+
+	expected := []Line{
+		{Time: 10.0, Text: "Repeated line"},
+		{Time: 11.5, Text: "First line", Words: []Word{{Time: 11.5, Text: "First"}, {Time: 12.0, Text: "line"}}},
+		{Time: 19.75, Text: "Repeated line"},
+	}
+
+	if len(parsed.Lines) != len(expected) {
+		t.Fatalf("Parse() returned %d lines, expected %d", len(parsed.Lines), len(expected))
+	}
+
+	for i, line := range parsed.Lines {
+		if !reflect.DeepEqual(line, expected[i]) {
+			t.Errorf("Parse() line %d = %v, expected %v", i, line, expected[i])
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	parsed := Parse("[00:01.00]<00:01.00>Hello <00:02.00>there\n[00:04.00]World")
+
+	tests := []struct {
+		time         float64
+		expectedLine int
+		expectedWord int
+	}{
+		{time: 0.5, expectedLine: -1, expectedWord: -1},
+		{time: 1.0, expectedLine: 0, expectedWord: 0},
+		{time: 2.5, expectedLine: 0, expectedWord: 1},
+		{time: 4.0, expectedLine: 1, expectedWord: -1},
+		{time: 60, expectedLine: 1, expectedWord: -1},
+	}
+
+	for _, tt := range tests {
+		line, word := parsed.Position(tt.time)
+		if line != tt.expectedLine || word != tt.expectedWord {
+			t.Errorf("Position(%v) = (%d, %d), expected (%d, %d)", tt.time, line, word, tt.expectedLine, tt.expectedWord)
+		}
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	lrc := "[ar: Artist]\n[00:01.00]<00:01.00>Hello <00:01.50>there<00:02.00>\n[00:03.00][00:05.00]World"
+
+	expected := "Hello there\nWorld"
+	if result := PlainText(lrc); result != expected {
+		t.Errorf("PlainText() = %q, expected %q", result, expected)
+	}
+}
+
+func TestShift(t *testing.T) {
+	tests := []struct {
+		name     string
+		lrc      string
+		offsetMs int
+		expected string
+	}{
+		{
+			name:     "later",
+			lrc:      "[ar: Artist]\n[00:01.00]Hello\n[00:59.80]World",
+			offsetMs: 250,
+			expected: "[ar: Artist]\n[00:01.25]Hello\n[01:00.05]World",
+		},
+		{
+			name:     "earlier with clamping",
+			lrc:      "[00:00.20]Hello\n[00:02.00]World",
+			offsetMs: -500,
+			expected: "[00:00.00]Hello\n[00:01.50]World",
+		},
+		{
+			name:     "keeps precision",
+			lrc:      "[00:01.123][00:03]Hello",
+			offsetMs: 1001,
+			expected: "[00:02.124][00:04]Hello",
+		},
+		{
+			name:     "word timestamps",
+			lrc:      "[00:01.00]<00:01.00>Hello <00:01.50>world",
+			offsetMs: 500,
+			expected: "[00:01.50]<00:01.50>Hello <00:02.00>world",
+		},
+		{
+			name:     "zero offset",
+			lrc:      "[00:01.00]Hello",
+			offsetMs: 0,
+			expected: "[00:01.00]Hello",
+		},
+		{
+			name:     "carry at one digit",
+			lrc:      "[00:12.9]Hello",
+			offsetMs: 60,
+			expected: "[00:13.0]Hello",
+		},
+		{
+			name:     "carry at zero digits",
+			lrc:      "[00:59]Hello",
+			offsetMs: 600,
+			expected: "[01:00]Hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Shift(tt.lrc, tt.offsetMs)
+			if result != tt.expected {
+				t.Errorf("Shift() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		seconds  float64
+		digits   int
+		expected string
+	}{
+		{12.96, 1, "00:13.0"},
+		{59.95, 1, "01:00.0"},
+		{59.6, 0, "01:00"},
+		{59.995, 2, "01:00.00"},
+		{119.9996, 3, "02:00.000"},
+		{75.25, 2, "01:15.25"},
+		{-1.5, 2, "00:00.00"},
+		{-0.04, 1, "00:00.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := formatTimestamp(tt.seconds, tt.digits); got != tt.expected {
+				t.Errorf("formatTimestamp(%v, %d) = %q, expected %q", tt.seconds, tt.digits, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package lyrics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultCueDuration is how long the last line is shown, in seconds, when the
+// track duration is unknown
+const defaultCueDuration = 5.0
+
+// minCueGap is the gap between two subtitle cues, in seconds, from which an
+// empty line is inserted when importing
+const minCueGap = 0.5
+
+var (
+	// cueTimingRegex matches a subtitle cue timing line such as
+	// 00:00:01,000 --> 00:00:04,000 (SRT) or 00:01.000 --> 00:04.000 (WebVTT)
+	cueTimingRegex = regexp.MustCompile(`^((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})`)
+
+	// cueTagRegex matches WebVTT and SRT formatting tags such as <i> or <c.yellow>
+	cueTagRegex = regexp.MustCompile(`</?[^>\d][^>]*>`)
+
+	// cueTimestampRegex matches a WebVTT inline timestamp such as <00:00:01.500>
+	cueTimestampRegex = regexp.MustCompile(`<((?:\d+:)?\d{1,2}:\d{2}\.\d{3})>`)
+)
+
+// Cue represents a line of lyrics shown between a start and an end time
+type Cue struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+	Words []Word  `json:"words,omitempty"`
+}
+
+// Cues converts parsed lyrics to cues. The end of each line is the start of the
+// next one, capped by the track duration; empty lines only end the previous
+// line. A duration of zero or less is treated as unknown.
+func Cues(lyrics *Lyrics, duration float64) []Cue {
+	var cues []Cue
+	for i, line := range lyrics.Lines {
+		if line.Text == "" {
+			continue
+		}
+
+		end := line.Time + defaultCueDuration
+		if i+1 < len(lyrics.Lines) {
+			end = lyrics.Lines[i+1].Time
+		} else if duration > line.Time {
+			end = duration
+		}
+		if duration > line.Time && end > duration {
+			end = duration
+		}
+
+		cues = append(cues, Cue{Start: line.Time, End: end, Text: line.Text, Words: line.Words})
+	}
+	return cues
+}
+
+// ToSRT converts LRC synced lyrics to SubRip subtitles. SubRip has no word
+// timing, so word timestamps are dropped.
+func ToSRT(lrc string, duration float64) string {
+	var b strings.Builder
+	for i, cue := range Cues(Parse(lrc), duration) {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatCueTime(cue.Start, ","), formatCueTime(cue.End, ","), cue.Text)
+	}
+	return b.String()
+}
+
+// ToVTT converts LRC synced lyrics to WebVTT subtitles. Word timestamps are
+// written as WebVTT inline timestamps.
+func ToVTT(lrc string, duration float64) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range Cues(Parse(lrc), duration) {
+		text := cue.Text
+		if len(cue.Words) > 0 {
+			parts := make([]string, len(cue.Words))
+			for i, word := range cue.Words {
+				parts[i] = word.Text
+				if word.Time > cue.Start {
+					parts[i] = "<" + formatCueTime(word.Time, ".") + ">" + word.Text
+				}
+			}
+			text = strings.Join(parts, " ")
+		}
+
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			formatCueTime(cue.Start, "."), formatCueTime(cue.End, "."), text)
+	}
+	return b.String()
+}
+
+// ToTTML converts LRC synced lyrics to a TTML document. Word timestamps are
+// written as timed spans.
+func ToTTML(lrc string, duration float64) string {
+	parsed := Parse(lrc)
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<tt xmlns="http://www.w3.org/ns/ttml"`)
+	if lang := parsed.Tags["la"]; lang != "" {
+		fmt.Fprintf(&b, ` xml:lang="%s"`, escapeXML(lang))
+	}
+	b.WriteString(">\n  <body>\n    <div>\n")
+	for _, cue := range Cues(parsed, duration) {
+		text := escapeXML(cue.Text)
+		if len(cue.Words) > 0 {
+			spans := make([]string, len(cue.Words))
+			for i, word := range cue.Words {
+				end := cue.End
+				if i+1 < len(cue.Words) {
+					end = cue.Words[i+1].Time
+				}
+				spans[i] = fmt.Sprintf(`<span begin="%s" end="%s">%s</span>`,
+					formatCueTime(word.Time, "."), formatCueTime(end, "."), escapeXML(word.Text))
+			}
+			text = strings.Join(spans, " ")
+		}
+
+		fmt.Fprintf(&b, "      <p begin=\"%s\" end=\"%s\">%s</p>\n",
+			formatCueTime(cue.Start, "."), formatCueTime(cue.End, "."), text)
+	}
+	b.WriteString("    </div>\n  </body>\n</tt>\n")
+	return b.String()
+}
+
+// FromSubtitles converts SRT or WebVTT subtitles to LRC synced lyrics. WebVTT
+// inline timestamps become enhanced LRC word timestamps. An empty line is
+// inserted where a cue ends well before the next one starts, so that the lyrics
+// are not shown during instrumental breaks.
+func FromSubtitles(text string) (string, error) {
+	cues := parseCues(text)
+	if len(cues) == 0 {
+		return "", fmt.Errorf("no subtitle cues found")
+	}
+
+	var lines []string
+	for i, cue := range cues {
+		lines = append(lines, "["+FormatTimestamp(cue.Start)+"]"+cue.Text)
+
+		if i+1 == len(cues) || cues[i+1].Start-cue.End >= minCueGap {
+			lines = append(lines, "["+FormatTimestamp(cue.End)+"]")
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// parseCues parses the cues of SRT or WebVTT subtitles. Cue numbers, the WebVTT
+// header, notes and formatting tags are ignored, inline timestamps are kept in
+// the text as LRC word timestamps, and multi-line cues are joined into a single
+// line.
+func parseCues(text string) []Cue {
+	var cues []Cue
+	var current *Cue
+
+	for _, line := range splitLines(text) {
+		if line == "" {
+			current = nil
+			continue
+		}
+
+		if match := cueTimingRegex.FindStringSubmatch(line); match != nil {
+			cues = append(cues, Cue{Start: parseCueTime(match[1]), End: parseCueTime(match[2])})
+			current = &cues[len(cues)-1]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		content := strings.TrimSpace(cueTagRegex.ReplaceAllString(line, ""))
+		content = cueTimestampRegex.ReplaceAllStringFunc(content, func(tag string) string {
+			return "<" + FormatTimestamp(parseCueTime(tag[1:len(tag)-1])) + ">"
+		})
+		if current.Text == "" {
+			current.Text = content
+		} else if content != "" {
+			current.Text += " " + content
+		}
+	}
+
+	return cues
+}
+
+// parseCueTime converts a subtitle timestamp (hh:mm:ss,mmm or mm:ss.mmm) to seconds
+func parseCueTime(value string) float64 {
+	value = strings.Replace(value, ",", ".", 1)
+	parts := strings.Split(value, ":")
+
+	t := 0.0
+	for _, part := range parts {
+		n, _ := strconv.ParseFloat(part, 64)
+		t = t*60 + n
+	}
+	return t
+}
+
+// formatCueTime formats seconds as a subtitle timestamp (hh:mm:ss.mmm), using
+// the given separator before the milliseconds
+func formatCueTime(seconds float64, separator string) string {
+	if seconds < 0 {
+		seconds = 0
+	}
+	millis := int(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		millis/3600000, millis/60000%60, millis/1000%60, separator, millis%1000)
+}
+
+// escapeXML escapes text for use in XML content and attributes
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
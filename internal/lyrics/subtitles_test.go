@@ -0,0 +1,133 @@
+package lyrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCues(t *testing.T) {
+	lrc := "[00:01.00]Hello\n[00:03.50]World\n[00:06.00]\n[00:08.00]Again"
+
+	tests := []struct {
+		name     string
+		duration float64
+		expected []Cue
+	}{
+		{
+			name:     "known duration",
+			duration: 10,
+			expected: []Cue{
+				{Start: 1, End: 3.5, Text: "Hello"},
+				{Start: 3.5, End: 6, Text: "World"},
+				{Start: 8, End: 10, Text: "Again"},
+			},
+		},
+		{
+			name: "unknown duration",
+			expected: []Cue{
+				{Start: 1, End: 3.5, Text: "Hello"},
+				{Start: 3.5, End: 6, Text: "World"},
+				{Start: 8, End: 13, Text: "Again"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues := Cues(Parse(lrc), tt.duration)
+			if len(cues) != len(tt.expected) {
+				t.Fatalf("Cues() returned %d cues, expected %d", len(cues), len(tt.expected))
+			}
+			for i, cue := range cues {
+				if !reflect.DeepEqual(cue, tt.expected[i]) {
+					t.Errorf("Cues() cue %d = %v, expected %v", i, cue, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSubtitles(t *testing.T) {
+	lrc := "[00:01.00]Hello\n[00:03.50]World & more\n[00:06.00]"
+
+	tests := []struct {
+		name     string
+		convert  func(string, float64) string
+		expected string
+	}{
+		{
+			name:     "srt",
+			convert:  ToSRT,
+			expected: "1\n00:00:01,000 --> 00:00:03,500\nHello\n\n2\n00:00:03,500 --> 00:00:06,000\nWorld & more\n\n",
+		},
+		{
+			name:     "vtt",
+			convert:  ToVTT,
+			expected: "WEBVTT\n\n00:00:01.000 --> 00:00:03.500\nHello\n\n00:00:03.500 --> 00:00:06.000\nWorld & more\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.convert(lrc, 10)
+			if result != tt.expected {
+				t.Errorf("%s = %q, expected %q", tt.name, result, tt.expected)
+			}
+
+			imported, err := FromSubtitles(result)
+			if err != nil {
+				t.Fatalf("FromSubtitles() error = %v", err)
+			}
+			if imported != lrc {
+				t.Errorf("FromSubtitles() = %q, expected %q", imported, lrc)
+			}
+		})
+	}
+}
+
+func TestFromSubtitles(t *testing.T) {
+	vtt := "WEBVTT\n\nNOTE exported\n\n1\n00:01.000 --> 00:02.000 align:start\n<i>Hello</i>\nthere\n\n00:05.000 --> 00:07.000\nWorld"
+
+	result, err := FromSubtitles(vtt)
+	if err != nil {
+		t.Fatalf("FromSubtitles() error = %v", err)
+	}
+
+	expected := "[00:01.00]Hello there\n[00:02.00]\n[00:05.00]World\n[00:07.00]"
+	if result != expected {
+		t.Errorf("FromSubtitles() = %q, expected %q", result, expected)
+	}
+
+	if _, err := FromSubtitles("not subtitles"); err == nil {
+		t.Error("FromSubtitles() expected error for text without cues")
+	}
+}
+
+func TestEnhancedSubtitles(t *testing.T) {
+	lrc := "[00:01.00]<00:01.00>Hello <00:01.50>world\n[00:03.00]"
+
+	vtt := ToVTT(lrc, 10)
+	if !strings.Contains(vtt, "Hello <00:00:01.500>world") {
+		t.Errorf("ToVTT() = %q, expected inline word timestamps", vtt)
+	}
+
+	imported, err := FromSubtitles(vtt)
+	if err != nil {
+		t.Fatalf("FromSubtitles() error = %v", err)
+	}
+	expected := "[00:01.00]Hello <00:01.50>world\n[00:03.00]"
+	if imported != expected {
+		t.Errorf("FromSubtitles() = %q, expected %q", imported, expected)
+	}
+
+	srt := ToSRT(lrc, 10)
+	if strings.Contains(srt, "<") {
+		t.Errorf("ToSRT() = %q, expected no word timestamps", srt)
+	}
+
+	ttml := ToTTML(lrc, 10)
+	if !strings.Contains(ttml, `<span begin="00:00:01.500" end="00:00:03.000">world</span>`) {
+		t.Errorf("ToTTML() = %q, expected timed word spans", ttml)
+	}
+}
@@ -0,0 +1,215 @@
+package lyrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SyncLine represents a line of a sync session. Time is nil until the line is stamped.
+type SyncLine struct {
+	Time *float64 `json:"time"`
+	Text string   `json:"text"`
+}
+
+// SyncState represents the state of a sync session as shown in the editor
+type SyncState struct {
+	Lines   []SyncLine `json:"lines"`
+	Current int        `json:"current"`
+	CanUndo bool       `json:"can_undo"`
+	CanRedo bool       `json:"can_redo"`
+	Auto    bool       `json:"auto"`
+}
+
+// syncSnapshot is a saved state of a sync session for undo and redo
+type syncSnapshot struct {
+	lines   []SyncLine
+	current int
+}
+
+// SyncSession creates synced lyrics from plain lyrics by stamping one line after
+// another with the playback position. Every change can be undone and redone.
+type SyncSession struct {
+	lines   []SyncLine
+	current int
+	undo    []syncSnapshot
+	redo    []syncSnapshot
+	auto    bool
+}
+
+// NewSyncSession starts a sync session from plain lyrics. Empty lines are
+// dropped; instrumental breaks are inserted with InsertBlank while syncing.
+func NewSyncSession(plain string) (*SyncSession, error) {
+	s := &SyncSession{}
+	for _, line := range splitLines(plain) {
+		if line = strings.TrimSpace(line); line != "" {
+			s.lines = append(s.lines, SyncLine{Text: line})
+		}
+	}
+
+	if len(s.lines) == 0 {
+		return nil, fmt.Errorf("no lyrics lines to sync")
+	}
+	return s, nil
+}
+
+// NewDraftSyncSession starts a sync session from draft synced lyrics, such as
+// those made by AutoSync, with every line already stamped so that the draft is
+// reviewed and corrected before it is saved. The session is flagged as auto.
+func NewDraftSyncSession(lrc string) (*SyncSession, error) {
+	parsed := Parse(lrc)
+	if len(parsed.Lines) == 0 {
+		return nil, fmt.Errorf("no synced lines in draft")
+	}
+
+	s := &SyncSession{auto: true}
+	for _, line := range parsed.Lines {
+		t := line.Time
+		s.lines = append(s.lines, SyncLine{Time: &t, Text: line.Text})
+	}
+	s.current = len(s.lines)
+	return s, nil
+}
+
+// Auto reports whether the session started from an automatic draft
+func (s *SyncSession) Auto() bool {
+	return s.auto
+}
+
+// Stamp sets the time of the current line and moves to the next one
+func (s *SyncSession) Stamp(t float64) error {
+	if s.current >= len(s.lines) {
+		return fmt.Errorf("all lines are already stamped")
+	}
+
+	s.save()
+	s.lines[s.current].Time = &t
+	s.current++
+	return nil
+}
+
+// InsertBlank inserts an empty line stamped at t before the current line, to
+// mark an instrumental break
+func (s *SyncSession) InsertBlank(t float64) {
+	s.save()
+	s.lines = append(s.lines, SyncLine{})
+	copy(s.lines[s.current+1:], s.lines[s.current:])
+	s.lines[s.current] = SyncLine{Time: &t}
+	s.current++
+}
+
+// Nudge moves the time of a stamped line by offsetMs milliseconds
+func (s *SyncSession) Nudge(index int, offsetMs int) error {
+	if index < 0 || index >= len(s.lines) {
+		return fmt.Errorf("line %d does not exist", index)
+	}
+	if s.lines[index].Time == nil {
+		return fmt.Errorf("line %d is not stamped", index)
+	}
+
+	s.save()
+	t := max(*s.lines[index].Time+float64(offsetMs)/1000, 0)
+	s.lines[index].Time = &t
+	return nil
+}
+
+// Select makes a line the current one, so that the next stamp applies to it
+func (s *SyncSession) Select(index int) error {
+	if index < 0 || index > len(s.lines) {
+		return fmt.Errorf("line %d does not exist", index)
+	}
+
+	s.save()
+	s.current = index
+	return nil
+}
+
+// Undo reverts the last change and reports whether there was one
+func (s *SyncSession) Undo() bool {
+	if len(s.undo) == 0 {
+		return false
+	}
+
+	s.redo = append(s.redo, s.snapshot())
+	s.restore(s.undo[len(s.undo)-1])
+	s.undo = s.undo[:len(s.undo)-1]
+	return true
+}
+
+// Redo applies the last undone change again and reports whether there was one
+func (s *SyncSession) Redo() bool {
+	if len(s.redo) == 0 {
+		return false
+	}
+
+	s.undo = append(s.undo, s.snapshot())
+	s.restore(s.redo[len(s.redo)-1])
+	s.redo = s.redo[:len(s.redo)-1]
+	return true
+}
+
+// State returns the current state of the session
+func (s *SyncSession) State() SyncState {
+	return SyncState{
+		Lines:   s.snapshot().lines,
+		Current: s.current,
+		CanUndo: len(s.undo) > 0,
+		CanRedo: len(s.redo) > 0,
+		Auto:    s.auto,
+	}
+}
+
+// Complete reports whether every line has been stamped
+func (s *SyncSession) Complete() bool {
+	for _, line := range s.lines {
+		if line.Time == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// LRC returns the stamped lines as LRC synced lyrics, in time order. Lines
+// stamped at the same time keep their session order.
+func (s *SyncSession) LRC() string {
+	var stamped []SyncLine
+	for _, line := range s.lines {
+		if line.Time != nil {
+			stamped = append(stamped, line)
+		}
+	}
+	sort.SliceStable(stamped, func(i, j int) bool {
+		return *stamped[i].Time < *stamped[j].Time
+	})
+
+	lines := make([]string, len(stamped))
+	for i, line := range stamped {
+		lines[i] = "[" + FormatTimestamp(*line.Time) + "]" + line.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// save records the current state for undo and clears the redo stack
+func (s *SyncSession) save() {
+	s.undo = append(s.undo, s.snapshot())
+	s.redo = nil
+}
+
+// snapshot copies the current state, so that later changes do not affect it
+func (s *SyncSession) snapshot() syncSnapshot {
+	lines := make([]SyncLine, len(s.lines))
+	for i, line := range s.lines {
+		lines[i] = line
+		if line.Time != nil {
+			t := *line.Time
+			lines[i].Time = &t
+		}
+	}
+	return syncSnapshot{lines: lines, current: s.current}
+}
+
+// restore replaces the current state with a snapshot
+func (s *SyncSession) restore(snapshot syncSnapshot) {
+	s.lines = snapshot.lines
+	s.current = snapshot.current
+}
@@ -0,0 +1,114 @@
+package lyrics
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSyncSession(t *testing.T) {
+	if _, err := NewSyncSession("\n  \n"); err == nil {
+		t.Fatal("NewSyncSession() of empty lyrics succeeded, expected an error")
+	}
+
+	s, err := NewSyncSession("Hello\n\nWorld\nAgain\n")
+	if err != nil {
+		t.Fatalf("NewSyncSession() error = %v", err)
+	}
+
+	undo := func() error {
+		if !s.Undo() {
+			return errors.New("nothing to undo")
+		}
+		return nil
+	}
+	redo := func() error {
+		if !s.Redo() {
+			return errors.New("nothing to redo")
+		}
+		return nil
+	}
+
+	steps := []struct {
+		name     string
+		apply    func() error
+		expected string
+		current  int
+	}{
+		{"stamp first line", func() error { return s.Stamp(1) }, "[00:01.00]Hello", 1},
+		{"stamp second line", func() error { return s.Stamp(3.5) }, "[00:01.00]Hello\n[00:03.50]World", 2},
+		{"undo", undo, "[00:01.00]Hello", 1},
+		{"stamp again", func() error { return s.Stamp(3.25) }, "[00:01.00]Hello\n[00:03.25]World", 2},
+		{"insert blank", func() error { s.InsertBlank(5); return nil }, "[00:01.00]Hello\n[00:03.25]World\n[00:05.00]", 3},
+		{"nudge", func() error { return s.Nudge(0, -250) }, "[00:00.75]Hello\n[00:03.25]World\n[00:05.00]", 3},
+		{"undo nudge", undo, "[00:01.00]Hello\n[00:03.25]World\n[00:05.00]", 3},
+		{"redo nudge", redo, "[00:00.75]Hello\n[00:03.25]World\n[00:05.00]", 3},
+		{"stamp last line", func() error { return s.Stamp(8) }, "[00:00.75]Hello\n[00:03.25]World\n[00:05.00]\n[00:08.00]Again", 4},
+		{"select and restamp", func() error {
+			if err := s.Select(1); err != nil {
+				return err
+			}
+			return s.Stamp(3)
+		}, "[00:00.75]Hello\n[00:03.00]World\n[00:05.00]\n[00:08.00]Again", 2},
+		{"restamp before the previous line", func() error {
+			if err := s.Select(1); err != nil {
+				return err
+			}
+			return s.Stamp(0.5)
+		}, "[00:00.50]World\n[00:00.75]Hello\n[00:05.00]\n[00:08.00]Again", 2},
+		{"stamp at the same time", func() error {
+			if err := s.Select(0); err != nil {
+				return err
+			}
+			return s.Stamp(0.5)
+		}, "[00:00.50]Hello\n[00:00.50]World\n[00:05.00]\n[00:08.00]Again", 1},
+	}
+
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		if lrc := s.LRC(); lrc != step.expected {
+			t.Fatalf("%s: LRC() = %q, expected %q", step.name, lrc, step.expected)
+		}
+		if current := s.State().Current; current != step.current {
+			t.Fatalf("%s: current line = %d, expected %d", step.name, current, step.current)
+		}
+	}
+
+	if !s.Complete() {
+		t.Error("Complete() = false after stamping every line")
+	}
+	if err := s.Nudge(9, 100); err == nil {
+		t.Error("Nudge() of a missing line succeeded, expected an error")
+	}
+	if state := s.State(); !state.CanUndo || state.CanRedo {
+		t.Errorf("State() can undo = %v, can redo = %v, expected true, false", state.CanUndo, state.CanRedo)
+	}
+}
+
+func TestDraftSyncSession(t *testing.T) {
+	if _, err := NewDraftSyncSession("Hello\nWorld"); err == nil {
+		t.Fatal("NewDraftSyncSession() of plain lyrics succeeded, expected an error")
+	}
+
+	draft := "[00:01.00]Hello\n[00:03.50]World\n[00:06.00]"
+	s, err := NewDraftSyncSession(draft)
+	if err != nil {
+		t.Fatalf("NewDraftSyncSession() error = %v", err)
+	}
+
+	if state := s.State(); !state.Auto || state.Current != 3 || !s.Complete() {
+		t.Errorf("State() = %+v, expected a complete auto session", state)
+	}
+	if lrc := s.LRC(); lrc != draft {
+		t.Errorf("LRC() = %q, expected the draft %q", lrc, draft)
+	}
+
+	// The draft is corrected like any session
+	if err := s.Nudge(1, -500); err != nil {
+		t.Fatalf("Nudge() error = %v", err)
+	}
+	if lrc := s.LRC(); lrc != "[00:01.00]Hello\n[00:03.00]World\n[00:06.00]" {
+		t.Errorf("LRC() after nudge = %q", lrc)
+	}
+}
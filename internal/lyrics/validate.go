@@ -0,0 +1,249 @@
+package lyrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Issue codes reported by Validate
+const (
+	IssueNoLines         = "no_lines"
+	IssueMalformedTag    = "malformed_tag"
+	IssueNonMonotonic    = "non_monotonic"
+	IssuePastDuration    = "past_duration"
+	IssueEmptyLine       = "empty_line"
+	IssueMostlyUppercase = "mostly_uppercase"
+	IssueDuplicateLine   = "duplicate_line"
+	IssueEncodingGarbage = "encoding_garbage"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// issuePenalties is the number of points each issue takes off the score
+var issuePenalties = map[string]int{
+	IssueNoLines:         100,
+	IssueMalformedTag:    5,
+	IssueNonMonotonic:    10,
+	IssuePastDuration:    10,
+	IssueEmptyLine:       1,
+	IssueMostlyUppercase: 10,
+	IssueDuplicateLine:   3,
+	IssueEncodingGarbage: 30,
+}
+
+// mojibakeSequences are typical results of UTF-8 text decoded as Latin-1 or Windows-1252
+var mojibakeSequences = []string{"Ã©", "Ã¨", "Ã¡", "Ã­", "Ã³", "Ãº", "Ã±", "Ã¼", "Ã¶", "Ã¤", "Ã§", "â€", "Â\u00a0"}
+
+// Duplicated blocks are runs of at least duplicateRunLines lines repeating
+// earlier lines, each shifted by the same offset give or take
+// duplicateShiftTolerance seconds. A chorus sung again is timed by hand and
+// drifts by more than that.
+const (
+	duplicateRunLines       = 3
+	duplicateShiftTolerance = 0.05
+)
+
+// malformedTimeRegex matches text that starts like a timestamp
+var malformedTimeRegex = regexp.MustCompile(`^\[\d`)
+
+// Issue represents a problem found in synced lyrics
+type Issue struct {
+	Line     int    `json:"line"`
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Report represents the result of validating synced lyrics
+type Report struct {
+	Score  int     `json:"score"`
+	Issues []Issue `json:"issues"`
+}
+
+// Validate checks synced lyrics for common problems and scores them from 0 to
+// 100. Lines are numbered from 1; whole-lyrics issues use line 0. A duration of
+// zero or less skips the track duration check.
+func Validate(lrc string, duration float64) Report {
+	var issues []Issue
+	add := func(line int, code, severity, format string, args ...interface{}) {
+		issues = append(issues, Issue{
+			Line:     line,
+			Code:     code,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if !utf8.ValidString(lrc) || strings.ContainsRune(lrc, utf8.RuneError) {
+		add(0, IssueEncodingGarbage, SeverityError, "lyrics contain invalid or replaced characters")
+	} else if sequence := findMojibake(lrc); sequence != "" {
+		add(0, IssueEncodingGarbage, SeverityError, "lyrics contain mis-encoded text %q", sequence)
+	}
+
+	var previous *timedLine
+	// ordered is the previous line with a single timestamp. Lines repeated
+	// at several times are out of order by design, so they are not compared.
+	var ordered *timedLine
+	var lines []timedLine
+	timed := 0
+	var letters, upper int
+
+	for i, raw := range splitLines(lrc) {
+		number := i + 1
+		if raw == "" {
+			continue
+		}
+
+		times, text, ok := parseTimedLine(raw)
+		if !ok {
+			if _, _, isTag := parseIDTag(raw); !isTag && strings.HasPrefix(raw, "[") {
+				add(number, IssueMalformedTag, SeverityError, "malformed tag %q", truncate(raw, 20))
+			}
+			continue
+		}
+
+		if malformedTimeRegex.MatchString(text) {
+			add(number, IssueMalformedTag, SeverityError, "malformed timestamp %q", truncate(text, 20))
+		}
+		text = stripWordTags(text)
+
+		if text == "" && len(times) == 1 && previous != nil && previous.text == "" {
+			add(number, IssueEmptyLine, SeverityWarning, "consecutive empty timed lines")
+		} else if text == "" && previous == nil {
+			add(number, IssueEmptyLine, SeverityWarning, "lyrics start with an empty timed line")
+		}
+
+		for _, t := range times {
+			timed++
+			current := timedLine{time: t, text: text, number: number}
+
+			if len(times) == 1 && ordered != nil && t < ordered.time {
+				add(number, IssueNonMonotonic, SeverityError, "timestamp %s is before the previous line at %s",
+					FormatTimestamp(t), FormatTimestamp(ordered.time))
+			}
+
+			if duration > 0 && t > duration {
+				add(number, IssuePastDuration, SeverityError, "timestamp %s is past the track duration %s",
+					FormatTimestamp(t), FormatTimestamp(duration))
+			}
+
+			lines = append(lines, current)
+			previous = &current
+			if len(times) == 1 {
+				ordered = &current
+			}
+		}
+
+		for _, r := range text {
+			if unicode.IsLetter(r) {
+				letters++
+				if unicode.IsUpper(r) {
+					upper++
+				}
+			}
+		}
+	}
+
+	for _, run := range findDuplicateRuns(lines) {
+		repeat, original := lines[run.repeat], lines[run.original]
+		if run.length == 1 {
+			add(repeat.number, IssueDuplicateLine, SeverityWarning, "line duplicates line %d", original.number)
+			continue
+		}
+		add(repeat.number, IssueDuplicateLine, SeverityWarning, "lines %d-%d repeat lines %d-%d shifted by %+.2fs",
+			repeat.number, lines[run.repeat+run.length-1].number,
+			original.number, lines[run.original+run.length-1].number, repeat.time-original.time)
+	}
+
+	if timed == 0 {
+		add(0, IssueNoLines, SeverityError, "lyrics have no timed lines")
+	}
+
+	if letters >= 20 && upper*10 >= letters*7 {
+		add(0, IssueMostlyUppercase, SeverityWarning, "%d%% of the letters are uppercase", upper*100/letters)
+	}
+
+	score := 100
+	for _, issue := range issues {
+		score -= issuePenalties[issue.Code]
+	}
+	if score < 0 {
+		score = 0
+	}
+
+	return Report{Score: score, Issues: issues}
+}
+
+// timedLine is a timestamp of synced lyrics with its text and line number
+type timedLine struct {
+	time   float64
+	text   string
+	number int
+}
+
+// duplicateRun is a run of lines repeating earlier lines
+type duplicateRun struct {
+	original, repeat, length int
+}
+
+// findDuplicateRuns returns the runs of lines that repeat earlier lines at the
+// same times, or shifted by a constant offset over at least duplicateRunLines
+// lines. Empty lines are never duplicates.
+func findDuplicateRuns(lines []timedLine) []duplicateRun {
+	var runs []duplicateRun
+	for j := 0; j < len(lines); {
+		best := duplicateRun{}
+		for i := 0; i < j; i++ {
+			if lines[i].text == "" || lines[i].text != lines[j].text {
+				continue
+			}
+
+			shift := lines[j].time - lines[i].time
+			length := 1
+			for i+length < j && j+length < len(lines) &&
+				lines[i+length].text == lines[j+length].text &&
+				math.Abs(lines[j+length].time-lines[i+length].time-shift) <= duplicateShiftTolerance {
+				length++
+			}
+
+			if length > best.length && (length >= duplicateRunLines || math.Abs(shift) <= duplicateShiftTolerance) {
+				best = duplicateRun{original: i, repeat: j, length: length}
+			}
+		}
+
+		if best.length == 0 {
+			j++
+			continue
+		}
+		runs = append(runs, best)
+		j += best.length
+	}
+	return runs
+}
+
+// findMojibake returns the first mis-encoded sequence found in text
+func findMojibake(text string) string {
+	for _, sequence := range mojibakeSequences {
+		if strings.Contains(text, sequence) {
+			return sequence
+		}
+	}
+	return ""
+}
+
+// truncate shortens text to at most n runes
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,108 @@
+package lyrics
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		lrc      string
+		duration float64
+		expected string
+	}{
+		{
+			name:     "valid lyrics",
+			lrc:      "[ti: Song]\n[00:01.00]Hello there\n[00:05.00]General Kenobi",
+			duration: 10,
+			expected: "",
+		},
+		{
+			name:     "no timed lines",
+			lrc:      "Hello there",
+			expected: IssueNoLines,
+		},
+		{
+			name:     "non-monotonic timestamps",
+			lrc:      "[00:05.00]Second\n[00:01.00]First",
+			expected: IssueNonMonotonic,
+		},
+		{
+			name:     "line repeated at several times",
+			lrc:      "[00:10.00][01:30.00]Chorus line here\n[00:15.00]Verse one\n[00:20.00]Verse two",
+			expected: "",
+		},
+		{
+			name:     "non-monotonic after a repeated line",
+			lrc:      "[00:10.00][01:30.00]Chorus line here\n[00:15.00]Verse one\n[00:12.00]Verse two",
+			expected: IssueNonMonotonic,
+		},
+		{
+			name:     "malformed tag",
+			lrc:      "[00:01.00]Hello\n[0:02.00.1]World",
+			expected: IssueMalformedTag,
+		},
+		{
+			name:     "timestamp past duration",
+			lrc:      "[00:01.00]Hello\n[03:01.00]World",
+			duration: 180,
+			expected: IssuePastDuration,
+		},
+		{
+			name:     "consecutive empty lines",
+			lrc:      "[00:01.00]Hello\n[00:02.00]\n[00:03.00]",
+			expected: IssueEmptyLine,
+		},
+		{
+			name:     "mostly uppercase",
+			lrc:      "[00:01.00]WE ARE THE CHAMPIONS MY FRIEND\n[00:05.00]AND WE'LL KEEP ON FIGHTING",
+			expected: IssueMostlyUppercase,
+		},
+		{
+			name:     "duplicated block",
+			lrc:      "[00:01.00]Hello\n[00:02.00]World\n[00:01.00]Hello\n[00:02.00]World",
+			expected: IssueDuplicateLine,
+		},
+		{
+			name:     "duplicated block with new timestamps",
+			lrc:      "[00:01.00]One\n[00:02.00]Two\n[00:03.50]Three\n[00:11.00]One\n[00:12.00]Two\n[00:13.50]Three",
+			expected: IssueDuplicateLine,
+		},
+		{
+			name:     "chorus sung again",
+			lrc:      "[00:01.00]One\n[00:02.30]Two\n[00:03.10]Three\n[00:11.00]One\n[00:12.10]Two\n[00:13.60]Three",
+			expected: "",
+		},
+		{
+			name:     "mojibake",
+			lrc:      "[00:01.00]CafÃ© au lait",
+			expected: IssueEncodingGarbage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Validate(tt.lrc, tt.duration)
+
+			if tt.expected == "" {
+				if len(report.Issues) != 0 || report.Score != 100 {
+					t.Errorf("Validate() = %v, expected no issues", report)
+				}
+				return
+			}
+
+			found := false
+			for _, issue := range report.Issues {
+				if issue.Code == tt.expected {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Validate() issues = %v, expected %v", report.Issues, tt.expected)
+			}
+			if report.Score >= 100 {
+				t.Errorf("Validate() score = %v, expected less than 100", report.Score)
+			}
+		})
+	}
+}
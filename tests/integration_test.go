@@ -2,12 +2,15 @@ package integration
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"lrcget-go/internal/app"
+	"lrcget-go/internal/audio"
 	"lrcget-go/internal/database"
 )
 
@@ -372,3 +375,787 @@ func TestErrorHandling(t *testing.T) {
 		t.Errorf("Expected error for non-existent track, got nil")
 	}
 }
+
+func TestLyricsHistory(t *testing.T) {
+	// Create temporary directory for testing
+	tempDir := t.TempDir()
+
+	// Create database connection
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	// Create test track with lyrics found during the scan
+	track := &database.PersistentTrack{
+		FilePath:   "/tmp/test.mp3",
+		FileName:   "test.mp3",
+		Title:      "Test Song",
+		AlbumName:  "Test Album",
+		ArtistName: "Test Artist",
+		Duration:   180.0,
+		TxtLyrics:  stringPtr("Scanned lyrics"),
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	err = conn.AddTrack(track)
+	if err != nil {
+		t.Fatalf("Failed to add track: %v", err)
+	}
+
+	// Test saving a new version
+	err = conn.SaveTrackLyrics(track.ID, stringPtr("[00:01.00]Downloaded lyrics"), stringPtr("Downloaded lyrics"), false, database.HistorySourceDownload)
+	if err != nil {
+		t.Fatalf("Failed to save track lyrics: %v", err)
+	}
+
+	history, err := conn.GetLyricsHistory(track.ID)
+	if err != nil {
+		t.Fatalf("Failed to get lyrics history: %v", err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+
+	if history[0].Source != database.HistorySourceDownload {
+		t.Errorf("Expected newest entry to come from download, got '%s'", history[0].Source)
+	}
+
+	if history[1].Source != database.HistorySourceScan || history[1].TxtLyrics == nil || *history[1].TxtLyrics != "Scanned lyrics" {
+		t.Errorf("Expected oldest entry to hold the scanned lyrics")
+	}
+
+	// Test restoring the scanned version
+	scanned, err := conn.GetLyricsHistoryByID(history[1].ID)
+	if err != nil {
+		t.Fatalf("Failed to get lyrics history entry: %v", err)
+	}
+
+	err = conn.SaveTrackLyrics(track.ID, scanned.LrcLyrics, scanned.TxtLyrics, scanned.Instrumental, database.HistorySourceEdit)
+	if err != nil {
+		t.Fatalf("Failed to restore track lyrics: %v", err)
+	}
+
+	restored, err := conn.GetTrackByID(track.ID)
+	if err != nil {
+		t.Fatalf("Failed to get track by ID: %v", err)
+	}
+
+	if restored.LrcLyrics != nil || restored.TxtLyrics == nil || *restored.TxtLyrics != "Scanned lyrics" {
+		t.Errorf("Expected restored track to have the scanned lyrics")
+	}
+}
+
+func TestPlayerLyricsPosition(t *testing.T) {
+	// Create a silent audio file to play
+	filePath := filepath.Join(t.TempDir(), "test.wav")
+	writeSilence(t, filePath, 20)
+
+	player, err := audio.NewPlayerWithSink(audio.NewNullSink(true))
+	if err != nil {
+		t.Fatalf("Failed to create player: %v", err)
+	}
+	defer player.Close()
+
+	track := &database.PersistentTrack{
+		FilePath:  filePath,
+		Title:     "Test Song",
+		Duration:  20.0,
+		LrcLyrics: stringPtr("[00:01.00]First line\n[00:05.00]Second line\n[00:09.00]Third line"),
+	}
+
+	err = player.Play(track)
+	if err != nil {
+		t.Fatalf("Failed to play track: %v", err)
+	}
+	player.Pause()
+
+	state := player.GetState()
+	if state.LineIndex != -1 || state.NextLineTime == nil || *state.NextLineTime != 1.0 {
+		t.Errorf("Expected no current line before the first timestamp, got %+v", state)
+	}
+
+	player.Seek(6)
+	state = player.GetState()
+	if state.LineIndex != 1 || state.LineText != "Second line" {
+		t.Errorf("Expected second line after seeking, got %d '%s'", state.LineIndex, state.LineText)
+	}
+	if state.NextLineTime == nil || *state.NextLineTime != 9.0 {
+		t.Errorf("Expected next line at 9s, got %v", state.NextLineTime)
+	}
+
+	player.Seek(15)
+	state = player.GetState()
+	if state.LineIndex != 2 || state.NextLineTime != nil {
+		t.Errorf("Expected last line without a next line, got %+v", state)
+	}
+}
+
+// writeSilence writes a mono WAVE file of the given length in seconds
+func writeSilence(t *testing.T, filePath string, seconds int) {
+	sink := audio.NewFileSink(filePath)
+	format := audio.Format{SampleRate: 8000, Channels: 1}
+	if _, err := sink.Open(format); err != nil {
+		t.Fatalf("Failed to create audio file: %v", err)
+	}
+	if err := sink.Write(make([]float32, format.SampleRate*seconds)); err != nil {
+		t.Fatalf("Failed to write audio file: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Failed to close audio file: %v", err)
+	}
+}
+
+func TestQueuePersistence(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	// An empty queue is returned before anything is saved
+	queue, err := conn.GetQueue()
+	if err != nil {
+		t.Fatalf("Failed to get queue: %v", err)
+	}
+	if len(queue.Entries) != 0 || queue.CurrentPosition != nil || queue.RepeatMode != "off" {
+		t.Errorf("Expected an empty queue, got %+v", queue)
+	}
+
+	current := 1
+	err = conn.SaveQueue(&database.PersistentQueue{
+		Entries: []database.PersistentQueueEntry{
+			{Position: 0, TrackID: 10, PlayOrder: 1},
+			{Position: 1, TrackID: 20, PlayOrder: 0},
+		},
+		CurrentPosition: &current,
+		RepeatMode:      "all",
+		Shuffle:         true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to save queue: %v", err)
+	}
+
+	queue, err = conn.GetQueue()
+	if err != nil {
+		t.Fatalf("Failed to get queue: %v", err)
+	}
+
+	if len(queue.Entries) != 2 || queue.Entries[1].TrackID != 20 || queue.Entries[1].PlayOrder != 0 {
+		t.Errorf("Expected saved queue entries, got %+v", queue.Entries)
+	}
+	if queue.CurrentPosition == nil || *queue.CurrentPosition != 1 || queue.RepeatMode != "all" || !queue.Shuffle {
+		t.Errorf("Expected saved queue state, got %+v", queue)
+	}
+}
+
+func TestTrackPathOperations(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	paths := []string{"/music/album/01.mp3", "/music/album/02.mp3", "/music/album_2/01.mp3", "/music/other.mp3"}
+	for _, path := range paths {
+		track := &database.PersistentTrack{
+			FilePath:   path,
+			FileName:   filepath.Base(path),
+			Title:      "Song",
+			AlbumName:  filepath.Base(filepath.Dir(path)),
+			ArtistName: "Artist",
+			LrcLyrics:  stringPtr("[00:01.00]Hello"),
+		}
+		if err := conn.AddTrack(track); err != nil {
+			t.Fatalf("Failed to add track: %v", err)
+		}
+	}
+
+	track, err := conn.GetTrackByFilePath("/music/other.mp3")
+	if err != nil || track == nil {
+		t.Fatalf("Failed to get track by path: %v", err)
+	}
+	if missing, err := conn.GetTrackByFilePath("/music/missing.mp3"); err != nil || missing != nil {
+		t.Errorf("Expected no track for a missing path, got %v, %v", missing, err)
+	}
+
+	// Updating tags keeps the lyrics
+	track.Title = "Renamed"
+	track.ArtistName = "Other Artist"
+	if err := conn.UpdateTrackMetadata(track); err != nil {
+		t.Fatalf("Failed to update track metadata: %v", err)
+	}
+	updated, err := conn.GetTrackByID(track.ID)
+	if err != nil {
+		t.Fatalf("Failed to get track: %v", err)
+	}
+	if updated.Title != "Renamed" || updated.ArtistName != "Other Artist" || updated.LrcLyrics == nil {
+		t.Errorf("Unexpected track after metadata update: %+v", updated)
+	}
+
+	// Deleting a directory does not touch directories sharing its prefix
+	removed, err := conn.DeleteTracksByPath("/music/album")
+	if err != nil {
+		t.Fatalf("Failed to delete tracks: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 tracks removed, got %d", removed)
+	}
+
+	removed, err = conn.DeleteTracksByPath("/music/other.mp3")
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 track removed, got %d, %v", removed, err)
+	}
+
+	tracks, err := conn.GetTracks()
+	if err != nil {
+		t.Fatalf("Failed to get tracks: %v", err)
+	}
+	if len(tracks) != 1 || tracks[0].FilePath != "/music/album_2/01.mp3" {
+		t.Errorf("Expected only /music/album_2/01.mp3 to remain, got %v", tracks)
+	}
+
+	artists, err := conn.GetArtists()
+	if err != nil {
+		t.Fatalf("Failed to get artists: %v", err)
+	}
+	if len(artists) != 1 {
+		t.Errorf("Expected artists without tracks to be removed, got %d artists", len(artists))
+	}
+}
+
+func TestMovedTracks(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	newTrack := func(path string) *database.PersistentTrack {
+		return &database.PersistentTrack{
+			FilePath:   path,
+			FileName:   filepath.Base(path),
+			Title:      "Song " + filepath.Base(path),
+			AlbumName:  "Album",
+			ArtistName: "Artist",
+		}
+	}
+	for _, path := range []string{"/music/album/01.mp3", "/music/album/02.mp3"} {
+		if err := conn.AddTrack(newTrack(path)); err != nil {
+			t.Fatalf("Failed to add track: %v", err)
+		}
+	}
+	track, err := conn.GetTrackByFilePath("/music/album/01.mp3")
+	if err != nil || track == nil {
+		t.Fatalf("Failed to get track by path: %v", err)
+	}
+	if err := conn.SaveTrackLyrics(track.ID, stringPtr("[00:01.00]Hello"), nil, false, database.HistorySourceDownload); err != nil {
+		t.Fatalf("Failed to save track lyrics: %v", err)
+	}
+	if err := conn.SetLyricsSource(&database.PersistentLyricsSource{TrackID: track.ID, LrclibID: 42}); err != nil {
+		t.Fatalf("Failed to set lyrics source: %v", err)
+	}
+
+	// A renamed file keeps its track
+	paths, err := conn.MoveTrackPaths("/music/album/01.mp3", "/music/album/01 - Song.mp3")
+	if err != nil {
+		t.Fatalf("Failed to move file: %v", err)
+	}
+	if !reflect.DeepEqual(paths, []string{"/music/album/01 - Song.mp3"}) {
+		t.Errorf("Expected the new path of the file, got %v", paths)
+	}
+	moved, err := conn.GetTrackByID(track.ID)
+	if err != nil {
+		t.Fatalf("Failed to get track: %v", err)
+	}
+	if moved.FilePath != "/music/album/01 - Song.mp3" || moved.FileName != "01 - Song.mp3" {
+		t.Errorf("Unexpected track after rename: %s, %s", moved.FilePath, moved.FileName)
+	}
+
+	// A renamed directory keeps the tracks under it, but not those of
+	// directories sharing its prefix
+	if err := conn.AddTrack(newTrack("/music/album_2/01.mp3")); err != nil {
+		t.Fatalf("Failed to add track: %v", err)
+	}
+	paths, err = conn.MoveTrackPaths("/music/album", "/music/renamed")
+	if err != nil {
+		t.Fatalf("Failed to move directory: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Errorf("Expected 2 files moved, got %v", paths)
+	}
+	moved, err = conn.GetTrackByID(track.ID)
+	if err != nil {
+		t.Fatalf("Failed to get track: %v", err)
+	}
+	if moved.FilePath != "/music/renamed/01 - Song.mp3" || moved.FileName != "01 - Song.mp3" {
+		t.Errorf("Unexpected track after directory rename: %s, %s", moved.FilePath, moved.FileName)
+	}
+	if other, err := conn.GetTrackByFilePath("/music/album_2/01.mp3"); err != nil || other == nil {
+		t.Errorf("Expected /music/album_2/01.mp3 to stay, got %v, %v", other, err)
+	}
+
+	// A detached track gives its history and source to the next track with the same tags
+	removed, err := conn.DetachTracksByPath("/music/renamed/01 - Song.mp3")
+	if err != nil || removed != 1 {
+		t.Fatalf("Expected 1 track detached, got %d, %v", removed, err)
+	}
+	if err := conn.AddTrack(newTrack("/music/album_2/02.mp3")); err != nil {
+		t.Fatalf("Failed to add track: %v", err)
+	}
+	if _, err := conn.GetLyricsSource(track.ID); err == nil {
+		t.Error("Expected no lyrics source under the detached track ID")
+	}
+
+	back := newTrack("/music/elsewhere/01 - Song.mp3")
+	back.Title = moved.Title
+	if err := conn.AddTrack(back); err != nil {
+		t.Fatalf("Failed to add track: %v", err)
+	}
+	history, err := conn.GetLyricsHistory(back.ID)
+	if err != nil {
+		t.Fatalf("Failed to get lyrics history: %v", err)
+	}
+	if len(history) != 1 || history[0].Source != database.HistorySourceDownload {
+		t.Errorf("Expected the downloaded lyrics in the history of the track, got %+v", history)
+	}
+	source, err := conn.GetLyricsSource(back.ID)
+	if err != nil || source.LrclibID != 42 {
+		t.Errorf("Expected the LRCLIB source of the track, got %v, %v", source, err)
+	}
+
+	// Purged history is not linked again
+	if _, err := conn.DetachTracksByPath("/music/elsewhere"); err != nil {
+		t.Fatalf("Failed to detach tracks: %v", err)
+	}
+	if err := conn.PurgeDetachedTracks(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Failed to purge detached tracks: %v", err)
+	}
+	again := newTrack("/music/elsewhere/01 - Song.mp3")
+	again.Title = moved.Title
+	if err := conn.AddTrack(again); err != nil {
+		t.Fatalf("Failed to add track: %v", err)
+	}
+	if history, err := conn.GetLyricsHistory(again.ID); err != nil || len(history) != 0 {
+		t.Errorf("Expected no history after purging, got %v, %v", history, err)
+	}
+}
+
+func TestBulkUpsertTracks(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	newBatch := func(title string) []database.PersistentTrack {
+		var batch []database.PersistentTrack
+		for i := 1; i <= 3; i++ {
+			batch = append(batch, database.PersistentTrack{
+				FilePath:   fmt.Sprintf("/music/album/%02d.mp3", i),
+				FileName:   fmt.Sprintf("%02d.mp3", i),
+				Title:      fmt.Sprintf("%s %d", title, i),
+				AlbumName:  "Album",
+				ArtistName: "Artist",
+				LrcLyrics:  stringPtr("[00:01.00]Hello"),
+			})
+		}
+		return batch
+	}
+
+	first := newBatch("Song")
+	if err := conn.BulkUpsertTracks(first); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	if first[0].AlbumID != first[2].AlbumID || first[0].ArtistID != first[2].ArtistID {
+		t.Errorf("Expected tracks of the same album to share album and artist, got %+v", first)
+	}
+
+	// Lyrics saved since the first scan are kept by a rescan
+	if err := conn.UpdateTrackSyncedLyrics(first[0].ID, "[00:02.00]Edited", "Edited"); err != nil {
+		t.Fatalf("Failed to update lyrics: %v", err)
+	}
+
+	second := newBatch("Renamed")
+	if err := conn.BulkUpsertTracks(second); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	if second[0].ID != first[0].ID || second[0].AlbumID != first[0].AlbumID {
+		t.Errorf("Expected a rescan to update the existing track, got %+v", second[0])
+	}
+
+	tracks, err := conn.GetTracks()
+	if err != nil {
+		t.Fatalf("Failed to get tracks: %v", err)
+	}
+	if len(tracks) != 3 {
+		t.Fatalf("Expected 3 tracks after a rescan, got %d", len(tracks))
+	}
+
+	track, err := conn.GetTrackByID(first[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get track: %v", err)
+	}
+	if track.Title != "Renamed 1" || track.LrcLyrics == nil || *track.LrcLyrics != "[00:02.00]Edited" {
+		t.Errorf("Unexpected track after rescan: %+v", track)
+	}
+
+	history, err := conn.GetLyricsHistory(first[1].ID)
+	if err != nil {
+		t.Fatalf("Failed to get lyrics history: %v", err)
+	}
+	if len(history) != 1 {
+		t.Errorf("Expected a single scan history entry, got %d", len(history))
+	}
+
+	albums, err := conn.GetAlbums()
+	if err != nil {
+		t.Fatalf("Failed to get albums: %v", err)
+	}
+	if len(albums) != 1 {
+		t.Errorf("Expected 1 album, got %d", len(albums))
+	}
+}
+
+func TestDirectoryPatterns(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDirectories([]string{"/music", "/podcasts"}); err != nil {
+		t.Fatalf("Failed to set directories: %v", err)
+	}
+	if err := conn.SetDirectoryPatterns("/music", []string{"*.flac"}, []string{"@eaDir"}); err != nil {
+		t.Fatalf("Failed to set directory patterns: %v", err)
+	}
+	if err := conn.SetDirectoryPatterns("/missing", nil, []string{"@eaDir"}); err == nil {
+		t.Error("Expected an error for a directory that is not in the library")
+	}
+
+	// Patterns are kept when the directory stays in the library
+	if err := conn.SetDirectories([]string{"/music", "/audiobooks"}); err != nil {
+		t.Fatalf("Failed to set directories: %v", err)
+	}
+
+	directories, err := conn.GetDirectorySettings()
+	if err != nil {
+		t.Fatalf("Failed to get directory settings: %v", err)
+	}
+	if len(directories) != 2 || directories[0].Path != "/audiobooks" || directories[1].Path != "/music" {
+		t.Fatalf("Unexpected directories: %+v", directories)
+	}
+	if directories[0].Include != nil || directories[0].Exclude != nil {
+		t.Errorf("Expected no patterns for /audiobooks, got %+v", directories[0])
+	}
+	if fmt.Sprint(directories[1].Include) != "[*.flac]" || fmt.Sprint(directories[1].Exclude) != "[@eaDir]" {
+		t.Errorf("Unexpected patterns for /music: %+v", directories[1])
+	}
+
+	config, err := conn.GetConfig()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+	if !config.SkipHiddenFiles || !config.SkipSmallFiles {
+		t.Errorf("Expected hidden and small files to be skipped by default, got %+v", config)
+	}
+}
+
+func TestScanReports(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	message := "permission denied"
+	for i := 1; i <= 12; i++ {
+		report := &database.PersistentScanReport{
+			StartedAt:  time.Now(),
+			FinishedAt: time.Now(),
+			Tracks:     i,
+			Entries: []database.PersistentScanReportEntry{
+				{Path: "/music/link", Kind: database.ScanEntrySkipped, Reason: "symlink"},
+				{Path: "/music/locked", Kind: database.ScanEntryError, Reason: "permission", Message: &message},
+				{Path: "/music/broken.mp3", Kind: database.ScanEntryError, Reason: "tags", Message: &message},
+			},
+		}
+		if err := conn.SaveScanReport(report); err != nil {
+			t.Fatalf("Failed to save scan report: %v", err)
+		}
+	}
+
+	// Only the newest reports are kept
+	reports, err := conn.GetScanReports()
+	if err != nil {
+		t.Fatalf("Failed to get scan reports: %v", err)
+	}
+	if len(reports) != 10 || reports[0].Tracks != 12 || reports[9].Tracks != 3 {
+		t.Fatalf("Expected the 10 newest reports, got %d", len(reports))
+	}
+	if reports[0].SkippedCount != 1 || reports[0].ErrorCount != 2 || reports[0].Entries != nil {
+		t.Errorf("Unexpected report summary: %+v", reports[0])
+	}
+
+	report, err := conn.GetScanReport(reports[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get scan report: %v", err)
+	}
+	if len(report.Entries) != 3 || report.Entries[1].Path != "/music/locked" || *report.Entries[1].Message != message {
+		t.Errorf("Unexpected report entries: %+v", report.Entries)
+	}
+
+	if _, err := conn.GetScanReport(reports[9].ID - 1); err == nil {
+		t.Error("Expected an error for a removed report")
+	}
+}
+
+func TestCueTracks(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	whole := []database.PersistentTrack{{
+		FilePath:   "/music/album.flac",
+		FileName:   "album.flac",
+		Title:      "album",
+		AlbumName:  "Unknown Album",
+		ArtistName: "Unknown Artist",
+	}}
+	if err := conn.BulkUpsertTracks(whole); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	queue := &database.PersistentQueue{
+		Entries:    []database.PersistentQueueEntry{{Position: 0, TrackID: whole[0].ID, PlayOrder: 0}},
+		RepeatMode: "off",
+	}
+	if err := conn.SaveQueue(queue); err != nil {
+		t.Fatalf("Failed to save queue: %v", err)
+	}
+
+	cueTracks := func(titles ...string) []database.PersistentTrack {
+		var tracks []database.PersistentTrack
+		for i, title := range titles {
+			start, end := float64(i*180), float64((i+1)*180)
+			tracks = append(tracks, database.PersistentTrack{
+				FilePath:    "/music/album.flac",
+				FileName:    "album.flac",
+				Title:       title,
+				AlbumName:   "Album",
+				ArtistName:  "Artist",
+				CueTrack:    int64(i + 1),
+				StartOffset: &start,
+				EndOffset:   &end,
+				Duration:    end - start,
+			})
+		}
+		return tracks
+	}
+
+	// A CUE sheet replaces the whole file with its tracks
+	if err := conn.BulkUpsertTracks(cueTracks("One", "Two", "Three")); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	tracks, err := conn.GetTracksByFilePath("/music/album.flac")
+	if err != nil {
+		t.Fatalf("Failed to get tracks: %v", err)
+	}
+	if len(tracks) != 3 || tracks[1].Title != "Two" || *tracks[1].StartOffset != 180 || tracks[1].Duration != 180 {
+		t.Fatalf("Expected the 3 CUE tracks of the file, got %+v", tracks)
+	}
+	if queue, err := conn.GetQueue(); err != nil || len(queue.Entries) != 0 {
+		t.Errorf("Expected the whole file track to leave the queue, got %+v, %v", queue, err)
+	}
+	if albums, err := conn.GetAlbums(); err != nil || len(albums) != 1 {
+		t.Errorf("Expected only the album of the CUE sheet, got %+v, %v", albums, err)
+	}
+
+	// Tracks removed from the sheet are removed from the library
+	if err := conn.BulkUpsertTracks(cueTracks("One", "Two")); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	tracks, err = conn.GetTracksByFilePath("/music/album.flac")
+	if err != nil {
+		t.Fatalf("Failed to get tracks: %v", err)
+	}
+	if len(tracks) != 2 {
+		t.Errorf("Expected 2 tracks after editing the CUE sheet, got %d", len(tracks))
+	}
+}
+
+func TestAlbumImages(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	albumTracks := func(images ...*string) []database.PersistentTrack {
+		var tracks []database.PersistentTrack
+		for i, image := range images {
+			number := int64(i + 1)
+			tracks = append(tracks, database.PersistentTrack{
+				FilePath:    fmt.Sprintf("/music/album/%02d.mp3", i+1),
+				FileName:    fmt.Sprintf("%02d.mp3", i+1),
+				Title:       fmt.Sprintf("Song %d", i+1),
+				AlbumName:   "Album",
+				ArtistName:  "Artist",
+				TrackNumber: &number,
+				ImagePath:   image,
+			})
+		}
+		return tracks
+	}
+	albumImage := func() *string {
+		albums, err := conn.GetAlbums()
+		if err != nil || len(albums) != 1 {
+			t.Fatalf("Expected a single album, got %+v, %v", albums, err)
+		}
+		return albums[0].ImagePath
+	}
+
+	// The album takes the art of its first track that has some
+	if err := conn.BulkUpsertTracks(albumTracks(nil, stringPtr("/cache/b.jpg"), stringPtr("/cache/c.jpg"))); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	if image := albumImage(); image == nil || *image != "/cache/b.jpg" {
+		t.Errorf("Expected the album image of the second track, got %v", image)
+	}
+
+	// A rescan with new art updates it, and one without art clears it
+	if err := conn.BulkUpsertTracks(albumTracks(stringPtr("/cache/a.jpg"), nil, nil)); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	if image := albumImage(); image == nil || *image != "/cache/a.jpg" {
+		t.Errorf("Expected the album image of the first track, got %v", image)
+	}
+	if paths, err := conn.GetImagePaths(); err != nil || len(paths) != 1 {
+		t.Errorf("Expected a single image path, got %v, %v", paths, err)
+	}
+
+	if err := conn.BulkUpsertTracks(albumTracks(nil, nil, nil)); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+	if image := albumImage(); image != nil {
+		t.Errorf("Expected no album image, got %s", *image)
+	}
+}
+
+func TestTrackArtists(t *testing.T) {
+	tempDir := t.TempDir()
+
+	conn, err := database.NewConnection(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database connection: %v", err)
+	}
+	defer conn.Close()
+
+	tracks := []database.PersistentTrack{
+		{FilePath: "/music/01.mp3", FileName: "01.mp3", Title: "Duet", AlbumName: "Album", ArtistName: "A & B feat. C", AlbumArtistName: stringPtr("A")},
+		{FilePath: "/music/02.mp3", FileName: "02.mp3", Title: "Solo", AlbumName: "Album", ArtistName: "A", AlbumArtistName: stringPtr("A")},
+		{FilePath: "/music/03.mp3", FileName: "03.mp3", Title: "Guest", AlbumName: "Other", ArtistName: "C", AlbumArtistName: stringPtr("Various Artists")},
+	}
+	if err := conn.BulkUpsertTracks(tracks); err != nil {
+		t.Fatalf("Failed to upsert tracks: %v", err)
+	}
+
+	artistCounts := func() map[string]int64 {
+		artists, err := conn.GetArtists()
+		if err != nil {
+			t.Fatalf("Failed to get artists: %v", err)
+		}
+		counts := make(map[string]int64)
+		for _, artist := range artists {
+			counts[artist.Name] = artist.TracksCount
+		}
+		return counts
+	}
+
+	// By default, only the featured artists are split from the tag
+	expected := map[string]int64{"A": 2, "A & B": 1, "C": 2, "Various Artists": 1}
+	if counts := artistCounts(); fmt.Sprint(counts) != fmt.Sprint(expected) {
+		t.Errorf("Expected artist track counts %v, got %v", expected, counts)
+	}
+
+	// With a separator, existing tracks credit each artist once rebuilt
+	config, err := conn.GetConfig()
+	if err != nil {
+		t.Fatalf("Failed to get config: %v", err)
+	}
+	config.ArtistSeparators = []string{" & "}
+	if err := conn.UpdateConfig(config); err != nil {
+		t.Fatalf("Failed to update config: %v", err)
+	}
+	if err := conn.RebuildTrackArtists(); err != nil {
+		t.Fatalf("Failed to rebuild track artists: %v", err)
+	}
+
+	expected = map[string]int64{"A": 2, "B": 1, "C": 2, "Various Artists": 1}
+	if counts := artistCounts(); fmt.Sprint(counts) != fmt.Sprint(expected) {
+		t.Errorf("Expected artist track counts %v after a rebuild, got %v", expected, counts)
+	}
+
+	// The track keeps its artist tag for lookups, and its primary artist for albums
+	track, err := conn.GetTrackByID(tracks[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to get track: %v", err)
+	}
+	solo, err := conn.GetTrackByID(tracks[1].ID)
+	if err != nil {
+		t.Fatalf("Failed to get track: %v", err)
+	}
+	if track.ArtistName != "A & B feat. C" || track.ArtistID != solo.ArtistID || track.AlbumID != solo.AlbumID {
+		t.Errorf("Unexpected track artist: %+v", track)
+	}
+
+	credits, err := conn.GetTrackArtists(track.ID)
+	if err != nil {
+		t.Fatalf("Failed to get track artists: %v", err)
+	}
+	var roles []string
+	for _, credit := range credits {
+		roles = append(roles, credit.Name+":"+credit.Role)
+	}
+	if fmt.Sprint(roles) != "[A:primary B:primary C:featured A:album_artist]" {
+		t.Errorf("Unexpected track artists: %v", roles)
+	}
+
+	featuredTracks, err := conn.GetTracksByArtistID(tracks[2].ArtistID)
+	if err != nil {
+		t.Fatalf("Failed to get tracks by artist: %v", err)
+	}
+	if len(featuredTracks) != 2 {
+		t.Errorf("Expected the tracks of C and those featuring C, got %d tracks", len(featuredTracks))
+	}
+
+	// Artists only credited by deleted tracks are removed
+	if _, err := conn.DeleteTracksByPath("/music/03.mp3"); err != nil {
+		t.Fatalf("Failed to delete track: %v", err)
+	}
+	expected = map[string]int64{"A": 2, "B": 1, "C": 1}
+	if counts := artistCounts(); fmt.Sprint(counts) != fmt.Sprint(expected) {
+		t.Errorf("Expected artist track counts %v after a deletion, got %v", expected, counts)
+	}
+}